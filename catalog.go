@@ -0,0 +1,674 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/Hossein-Roshandel/webswags/discovery"
+	"github.com/Hossein-Roshandel/webswags/lint"
+	"github.com/Hossein-Roshandel/webswags/quality"
+)
+
+// IndexData represents the data structure for the index page template.
+type IndexData struct {
+	TotalServices int
+	Services      []discovery.Service
+	Empty         bool
+	RootOrigin    string                       // origin of specs found under -root; others are badged with theirs
+	Lint          map[string]lint.Result       // lint results of each service's latest spec, by spec slug
+	Scores        map[string]quality.Scorecard // quality scores of each service's latest spec, by spec slug
+}
+
+type ServiceData struct {
+	ServiceTitle     string
+	SwaggerUIVersion string
+	Format           string
+	FormatColor      string
+	SpecURL          string
+	Version          string
+	Versions         []VersionOption
+	Drift            bool
+	Variants         []discovery.SpecVariant
+	HistoryURL       string              // git history of the spec, loaded by the revision switcher
+	Revision         *discovery.Revision // set when a past revision is shown
+	CurrentURL       string              // page of the current version, for revision pages
+	DiffURL          string              // changes from the revision to the current version, for revision pages
+	SpecRef          string              // reference to the spec (see resolveSpecRef), sent with proxied requests
+	ContractDriftURL string              // responses of the service that broke the spec, for the contract banner
+}
+
+// VersionOption is one entry of the version switcher on the service page.
+type VersionOption struct {
+	Key      string
+	Label    string
+	URL      string
+	Default  bool
+	Selected bool
+}
+
+// VersionInfo describes one version of a service in the /api/specs/{service}/versions response.
+type VersionInfo struct {
+	Version string `json:"version"`
+	Label   string `json:"label"`
+	Title   string `json:"title"`
+	Slug    string `json:"slug"`
+	Format  string `json:"format"`
+	Path    string `json:"path"`
+	Default bool   `json:"default"`
+	PageURL string `json:"pageUrl"`
+	SpecURL string `json:"specUrl"`
+}
+
+// DiscoveryData is the data for the discovery report page.
+type DiscoveryData struct {
+	Root     string
+	Accepted int
+	Ignored  int
+	Rejected int
+	Rows     []DiscoveryRow
+}
+
+// DiscoveryRow is one report entry with its path shown relative to the root. Source names
+// the origin of entries found in an additional source, and is empty for the root's.
+type DiscoveryRow struct {
+	discovery.ReportEntry
+
+	RelPath string
+	Source  string
+}
+
+// IndexedOperation is one operation of the /api/operations response, with the spec it belongs to.
+type IndexedOperation struct {
+	Service string `json:"service"`
+	Slug    string `json:"slug"`
+	Version string `json:"version"`
+	DocsURL string `json:"docsUrl"` // service page of the spec
+	discovery.Operation
+}
+
+// OperationsReport is the /api/operations response.
+type OperationsReport struct {
+	Count      int                `json:"count"`
+	Operations []IndexedOperation `json:"operations"`
+}
+
+// Limits of the number of /api/search results.
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// SearchResult is one result of /api/search, with the link that opens it.
+type SearchResult struct {
+	discovery.SearchHit
+	URL string `json:"url"` // service page, deep-linked to the operation or schema
+}
+
+// SearchReport is the /api/search response.
+type SearchReport struct {
+	Query   string         `json:"query"`
+	Count   int            `json:"count"`
+	Results []SearchResult `json:"results"`
+}
+
+// handleIndex serves the main page listing all services.
+func handleIndex(registry *discovery.Registry, scores *catalogQuality) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		services := registry.Services()
+
+		data := IndexData{
+			TotalServices: len(services),
+			Services:      services,
+			Empty:         len(services) == 0,
+			RootOrigin:    discovery.DirSource{Path: registry.Root()}.Origin(),
+			Lint:          make(map[string]lint.Result, len(services)),
+			Scores:        make(map[string]quality.Scorecard, len(services)),
+		}
+		for _, svc := range services {
+			latest := svc.Latest()
+			report, ok := scores.Report(latest.Slug)
+			if !ok {
+				continue // the catalog changed since services was read
+			}
+			data.Lint[latest.Slug] = report.Lint
+			if latest.Quality != nil {
+				data.Scores[latest.Slug] = report.Scorecard
+			}
+		}
+
+		renderPage(w, "index.html", data)
+	}
+}
+
+// handleDiscoveryPage renders the discovery report: every candidate file and what happened to it.
+func handleDiscoveryPage(registry *discovery.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		report := registry.Report()
+
+		data := DiscoveryData{
+			Root:     report.Root,
+			Accepted: report.Accepted,
+			Ignored:  report.Ignored,
+			Rejected: report.Rejected,
+			Rows:     make([]DiscoveryRow, 0, len(report.Entries)),
+		}
+		rootOrigin := discovery.DirSource{Path: report.Root}.Origin()
+		for _, entry := range report.Entries {
+			row := DiscoveryRow{ReportEntry: entry, RelPath: entry.Path}
+			if entry.Origin != rootOrigin {
+				row.Source = entry.Origin
+			}
+			if rel, relErr := filepath.Rel(report.Root, entry.Path); relErr == nil && row.Source == "" {
+				row.RelPath = filepath.ToSlash(rel)
+			}
+			data.Rows = append(data.Rows, row)
+		}
+
+		renderPage(w, "discovery.html", data)
+	}
+}
+
+// handleDiscoveryReport returns the discovery report as JSON.
+func handleDiscoveryReport(registry *discovery.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+
+		if err := json.NewEncoder(w).Encode(registry.Report()); err != nil {
+			slog.Error("Failed to encode discovery report", "error", err)
+			http.Error(w, "Failed to encode discovery report", http.StatusInternalServerError)
+		}
+	}
+}
+
+// lookupSpec resolves the {service}, optional {version} and optional {rev} route variables to
+// a spec.
+//
+// {service} is normally a service slug, in which case the requested version (or the default
+// one) is returned. A spec slug is accepted too, so links to an individual file keep working.
+// URLs that still use a service display name (e.g. "/service/User Service") are redirected
+// to their slug equivalent; anything else is a 404. With {rev}, the spec is returned as it was
+// at that git revision. It reports whether the caller should proceed.
+func lookupSpec(
+	registry *discovery.Registry,
+	w http.ResponseWriter,
+	r *http.Request,
+) (discovery.Service, discovery.SwaggerSpec, bool) {
+	svc, spec, ok := lookupCurrentSpec(registry, w, r)
+	rev := mux.Vars(r)["rev"]
+	if !ok || rev == "" {
+		return svc, spec, ok
+	}
+
+	at, err := registry.SpecAt(r.Context(), spec, rev)
+	switch {
+	case errors.Is(err, discovery.ErrNoHistory), errors.Is(err, discovery.ErrUnknownRevision):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return discovery.Service{}, discovery.SwaggerSpec{}, false
+	case err != nil:
+		slog.Error("Failed to load spec revision", "service", spec.Slug, "rev", rev, "error", err)
+		http.Error(w, "Failed to load spec revision", http.StatusInternalServerError)
+		return discovery.Service{}, discovery.SwaggerSpec{}, false
+	}
+	return svc, at, true
+}
+
+// lookupCurrentSpec is lookupSpec without the {rev} variable.
+func lookupCurrentSpec(
+	registry *discovery.Registry,
+	w http.ResponseWriter,
+	r *http.Request,
+) (discovery.Service, discovery.SwaggerSpec, bool) {
+	vars := mux.Vars(r)
+	service, version := vars["service"], vars["version"]
+
+	if svc, spec, found := findSpec(registry, service, version); found {
+		return svc, spec, true
+	}
+	if _, isService := registry.Service(service); isService {
+		http.NotFound(w, r)
+		return discovery.Service{}, discovery.SwaggerSpec{}, false
+	}
+	if spec, found := registry.LookupByName(service); found {
+		target := url.URL{
+			Path:     strings.Replace(r.URL.Path, "/"+service, "/"+spec.ServiceSlug, 1),
+			RawQuery: r.URL.RawQuery,
+		}
+		http.Redirect(w, r, target.String(), http.StatusMovedPermanently)
+		return discovery.Service{}, discovery.SwaggerSpec{}, false
+	}
+
+	http.NotFound(w, r)
+	return discovery.Service{}, discovery.SwaggerSpec{}, false
+}
+
+// findSpec returns a version of a service, or the default one if version is "". service may
+// also be the slug of a spec, whose own version key version must then be, if given.
+func findSpec(registry *discovery.Registry, service, version string) (discovery.Service, discovery.SwaggerSpec, bool) {
+	if svc, ok := registry.Service(service); ok {
+		if version == "" {
+			return svc, svc.Latest(), true
+		}
+		spec, found := svc.Version(version)
+		return svc, spec, found
+	}
+	if spec, found := registry.Lookup(service); found && (version == "" || version == spec.VersionKey) {
+		svc, _ := registry.Service(spec.ServiceSlug)
+		return svc, spec, true
+	}
+	return discovery.Service{}, discovery.SwaggerSpec{}, false
+}
+
+// specFileURL returns the API URL serving a specific version of a service in the given format.
+func specFileURL(svc discovery.Service, spec discovery.SwaggerSpec, format string) string {
+	return fmt.Sprintf("/api/specs/%s/v/%s/swagger.%s", svc.Slug, versionRef(spec), format)
+}
+
+// versionRef returns the path segment naming spec's version in URLs: its escaped version key,
+// followed by @commit for a past revision.
+func versionRef(spec discovery.SwaggerSpec) string {
+	ref := url.PathEscape(spec.VersionKey)
+	if spec.Revision != nil {
+		ref += "@" + spec.Revision.Commit
+	}
+	return ref
+}
+
+// versionLabel returns the version of spec as its document states it, for display; URLs use its VersionKey.
+func versionLabel(spec discovery.SwaggerSpec) string {
+	if v := strings.TrimSpace(spec.Version); v != "" {
+		return v
+	}
+	return spec.VersionKey
+}
+
+// specDocumentURL returns the URL Swagger UI should load a spec from.
+// Specs split across files are served bundled when possible; otherwise (Swagger 2.0) the root
+// file is loaded from the spec's file tree, so its relative $refs resolve to sibling URLs.
+func specDocumentURL(svc discovery.Service, spec discovery.SwaggerSpec) string {
+	if len(spec.Dependencies) == 0 || spec.Bundled != nil {
+		return specFileURL(svc, spec, spec.Format)
+	}
+	rel, ok := spec.RelPath(spec.Path)
+	if !ok {
+		return specFileURL(svc, spec, spec.Format)
+	}
+	return fmt.Sprintf("/api/specs/%s/v/%s/files/%s", svc.Slug, versionRef(spec), rel)
+}
+
+// servicePageURL returns the Swagger UI page URL for a specific version of a service.
+func servicePageURL(svc discovery.Service, spec discovery.SwaggerSpec) string {
+	return fmt.Sprintf("/service/%s/v/%s", svc.Slug, versionRef(spec))
+}
+
+// handleServiceSwagger serves the Swagger UI for a specific service.
+func handleServiceSwagger(registry *discovery.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		svc, spec, ok := lookupSpec(registry, w, r)
+		if !ok {
+			return
+		}
+
+		// Determine the correct URL based on format.
+		specFormat := spec.Format
+		specURL := specDocumentURL(svc, spec)
+
+		versions := make([]VersionOption, 0, len(svc.Versions))
+		for _, v := range svc.Versions {
+			versions = append(versions, VersionOption{
+				Key:      v.VersionKey,
+				Label:    versionLabel(v),
+				URL:      servicePageURL(svc, v),
+				Default:  v.VersionKey == svc.Default,
+				Selected: v.Path == spec.Path || (spec.Revision != nil && v.VersionKey == spec.VersionKey),
+			})
+		}
+
+		current := spec
+		current.Revision = nil
+
+		data := ServiceData{
+			ServiceTitle:     svc.Name,
+			SwaggerUIVersion: swaggerUIVersion,
+			Format:           specFormat,
+			FormatColor:      getFormatColor(specFormat),
+			SpecURL:          specURL,
+			Version:          spec.VersionKey,
+			Versions:         versions,
+			Drift:            spec.Drift,
+			Variants:         spec.Variants,
+			HistoryURL:       fmt.Sprintf("/api/specs/%s/v/%s/history", svc.Slug, url.PathEscape(spec.VersionKey)),
+			Revision:         spec.Revision,
+			CurrentURL:       servicePageURL(svc, current),
+			SpecRef:          svc.Slug + "/v/" + spec.VersionKey,
+			ContractDriftURL: "/api/drift?" + url.Values{"service": {svc.Slug}}.Encode(),
+		}
+		if spec.Revision != nil {
+			to := data.SpecRef
+			data.SpecRef += "@" + spec.Revision.Commit
+			data.DiffURL = "/diff?" + url.Values{"from": {data.SpecRef}, "to": {to}}.Encode()
+		}
+
+		renderPage(w, "service.html", data)
+	}
+}
+
+// getFormatColor returns a color for the format badge.
+func getFormatColor(format string) string {
+	if format == jsonFormat {
+		return colorJSON // Orange for JSON.
+	}
+	return colorYAML // Green for YAML.
+}
+
+// handleSpecs returns JSON list of all discovered specs.
+func handleSpecs(registry *discovery.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if err := json.NewEncoder(w).Encode(registry.Specs()); err != nil {
+			http.Error(w, "Failed to encode specs to JSON", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// handleOperations lists the operations of every service's default version, or of the version
+// of one service, filtered by the query: service (slug), version, tag, method and path (prefix).
+func handleOperations(registry *discovery.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		service, version := query.Get("service"), query.Get("version")
+
+		var specs []discovery.SwaggerSpec
+		var services []discovery.Service
+		switch {
+		case service != "":
+			svc, spec, found := findSpec(registry, service, version)
+			if !found {
+				http.Error(w, "Service not found", http.StatusNotFound)
+				return
+			}
+			specs, services = []discovery.SwaggerSpec{spec}, []discovery.Service{svc}
+		case version != "":
+			http.Error(w, "The version parameter requires a service", http.StatusBadRequest)
+			return
+		default:
+			services = registry.Services()
+			for _, svc := range services {
+				specs = append(specs, svc.Latest())
+			}
+		}
+
+		tag := query.Get("tag")
+		method := strings.ToUpper(query.Get("method"))
+		prefix := query.Get("path")
+		report := OperationsReport{Operations: []IndexedOperation{}}
+		for i, spec := range specs {
+			for _, op := range spec.Operations {
+				if (tag != "" && !op.HasTag(tag)) || (method != "" && op.Method != method) ||
+					!strings.HasPrefix(op.Path, prefix) {
+					continue
+				}
+				report.Operations = append(report.Operations, IndexedOperation{
+					Service:   spec.Service,
+					Slug:      spec.Slug,
+					Version:   spec.VersionKey,
+					DocsURL:   servicePageURL(services[i], spec),
+					Operation: op,
+				})
+			}
+		}
+		report.Count = len(report.Operations)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			slog.Error("Failed to encode operations", "error", err)
+			http.Error(w, "Failed to encode operations", http.StatusInternalServerError)
+		}
+	}
+}
+
+// handleSearch searches the services, operations and schemas of the catalog: ?q=words&limit=n.
+func handleSearch(registry *discovery.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := strings.TrimSpace(r.URL.Query().Get("q"))
+		limit := defaultSearchLimit
+		if raw := r.URL.Query().Get("limit"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 1 {
+				http.Error(w, "limit must be a positive number", http.StatusBadRequest)
+				return
+			}
+			limit = min(n, maxSearchLimit)
+		}
+
+		report := SearchReport{Query: query, Results: []SearchResult{}}
+		for _, hit := range registry.Search(query, limit) {
+			report.Results = append(report.Results, SearchResult{SearchHit: hit, URL: searchResultURL(hit)})
+		}
+		report.Count = len(report.Results)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			slog.Error("Failed to encode search results", "error", err)
+			http.Error(w, "Failed to encode search results", http.StatusInternalServerError)
+		}
+	}
+}
+
+// searchResultURL links to the service page of a hit, with a fragment the page uses to open
+// the operation (#op=METHOD /path) or schema (#schema=Name) it is about.
+func searchResultURL(hit discovery.SearchHit) string {
+	page := fmt.Sprintf("/service/%s/v/%s", hit.ServiceSlug, url.PathEscape(hit.Version))
+	switch hit.Kind {
+	case discovery.HitOperation:
+		return page + "#op=" + url.PathEscape(hit.Method+" "+hit.Path)
+	case discovery.HitSchema:
+		return page + "#schema=" + url.PathEscape(hit.Schema)
+	}
+	return page
+}
+
+// handleSwaggerFile serves the YAML or JSON document for a specific service.
+// The format comes from the URL suffix (swagger.yaml / swagger.json) or, for the suffix-less
+// swagger URL, from the Accept header. A file already stored in that format is served as-is;
+// otherwise the document is converted on the fly.
+func handleSwaggerFile(registry *discovery.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, spec, ok := lookupSpec(registry, w, r)
+		if !ok {
+			return
+		}
+
+		// Determine requested format from URL, falling back to content negotiation
+		var requestedFormat string
+		switch {
+		case strings.HasSuffix(r.URL.Path, ".json"):
+			requestedFormat = jsonFormat
+		case strings.HasSuffix(r.URL.Path, ".yaml"):
+			requestedFormat = yamlFormat
+		default:
+			w.Header().Set("Vary", "Accept")
+			requestedFormat = negotiateFormat(r.Header.Get("Accept"), spec.Format)
+		}
+
+		w.Header().Set("Content-Type", specContentType(requestedFormat))
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+
+		// Serve the file (a bundle, when the spec has one, replaces the files on disk)
+		if variant, found := spec.Variant(requestedFormat); found && spec.Bundled == nil {
+			data, err := spec.ReadFile(variant.Path)
+			if err != nil {
+				slog.Error("Failed to read spec", "service", spec.Slug, "path", variant.Path, "error", err)
+				http.Error(w, "Failed to read spec", http.StatusInternalServerError)
+				return
+			}
+			if _, writeErr := w.Write(data); writeErr != nil {
+				slog.Error("Failed to write spec", "service", spec.Slug, "error", writeErr)
+			}
+			return
+		}
+
+		data, err := spec.Convert(requestedFormat)
+		if err != nil {
+			slog.Error("Failed to convert spec", "service", spec.Slug, "format", requestedFormat, "error", err)
+			http.Error(w, "Failed to convert spec", http.StatusInternalServerError)
+			return
+		}
+		if _, writeErr := w.Write(data); writeErr != nil {
+			slog.Error("Failed to write spec", "service", spec.Slug, "error", writeErr)
+		}
+	}
+}
+
+// handleOpenAPI3 serves a spec as an OpenAPI 3 document (openapi3.yaml / openapi3.json).
+// Swagger 2.0 specs are upgraded to OpenAPI 3.0; OpenAPI 3 specs are served as by handleSwaggerFile.
+func handleOpenAPI3(registry *discovery.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, spec, ok := lookupSpec(registry, w, r)
+		if !ok {
+			return
+		}
+		if spec.DocV3 == nil {
+			http.Error(w, "No OpenAPI 3 view available for this spec", http.StatusNotFound)
+			return
+		}
+
+		format := yamlFormat
+		if strings.HasSuffix(r.URL.Path, ".json") {
+			format = jsonFormat
+		}
+
+		data, err := spec.OpenAPI3(format)
+		if err != nil {
+			slog.Error("Failed to encode OpenAPI 3 document", "service", spec.Slug, "error", err)
+			http.Error(w, "Failed to encode OpenAPI 3 document", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", specContentType(format))
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if _, writeErr := w.Write(data); writeErr != nil {
+			slog.Error("Failed to write spec", "service", spec.Slug, "error", writeErr)
+		}
+	}
+}
+
+// specContentType returns the Content-Type header value for a spec format.
+func specContentType(format string) string {
+	if format == jsonFormat {
+		return "application/json"
+	}
+	return "text/yaml"
+}
+
+// handleSpecFiles serves the files making up a multi-file spec at their paths relative to the
+// root of their source, e.g. /api/specs/{service}/files/apis/pets/definitions.yaml. Only the
+// spec's own files and the files it references through relative $refs are served, and only if
+// their real path, symlinks resolved, lies under the root.
+func handleSpecFiles(registry *discovery.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, spec, ok := lookupSpec(registry, w, r)
+		if !ok {
+			return
+		}
+
+		target, found := spec.FileAt(mux.Vars(r)["file"])
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		data, err := spec.ReadFile(target)
+		if err != nil {
+			slog.Error("Failed to read spec file", "service", spec.Slug, "file", target, "error", err)
+			http.Error(w, "Failed to read spec file", http.StatusInternalServerError)
+			return
+		}
+
+		format := yamlFormat
+		if strings.EqualFold(filepath.Ext(target), ".json") {
+			format = jsonFormat
+		}
+		w.Header().Set("Content-Type", specContentType(format))
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if _, writeErr := w.Write(data); writeErr != nil {
+			slog.Error("Failed to write spec file", "service", spec.Slug, "error", writeErr)
+		}
+	}
+}
+
+// negotiateFormat picks "json" or "yaml" from an Accept header, honouring q-values.
+// Wildcards, unknown media types and an empty header yield fallback.
+func negotiateFormat(accept, fallback string) string {
+	best, bestQ := fallback, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if value, found := strings.CutPrefix(strings.TrimSpace(param), "q="); found {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+
+		var format string
+		switch strings.ToLower(strings.TrimSpace(mediaType)) {
+		case "application/json", "application/vnd.oai.openapi+json", "text/json":
+			format = jsonFormat
+		case "application/yaml", "application/x-yaml", "application/vnd.oai.openapi",
+			"application/vnd.oai.openapi+yaml", "text/yaml", "text/x-yaml":
+			format = yamlFormat
+		default:
+			continue
+		}
+		if q > bestQ {
+			best, bestQ = format, q
+		}
+	}
+	return best
+}
+
+// handleVersions returns the versions of a service as JSON, newest first.
+func handleVersions(registry *discovery.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		svc, _, ok := lookupSpec(registry, w, r)
+		if !ok {
+			return
+		}
+
+		versions := make([]VersionInfo, 0, len(svc.Versions))
+		for _, spec := range svc.Versions {
+			versions = append(versions, VersionInfo{
+				Version: spec.VersionKey,
+				Label:   versionLabel(spec),
+				Title:   spec.Title,
+				Slug:    spec.Slug,
+				Format:  spec.Format,
+				Path:    spec.Path,
+				Default: spec.VersionKey == svc.Default,
+				PageURL: servicePageURL(svc, spec),
+				SpecURL: specFileURL(svc, spec, spec.Format),
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if err := json.NewEncoder(w).Encode(versions); err != nil {
+			http.Error(w, "Failed to encode versions to JSON", http.StatusInternalServerError)
+			return
+		}
+	}
+}
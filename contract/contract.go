@@ -0,0 +1,216 @@
+// Package contract checks HTTP traffic against an OpenAPI 3 document: it finds the operation of
+// the spec a request is for and reports where the request breaks the operation's contract, so
+// mistakes surface where the call is made rather than as an opaque error from the server.
+package contract
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	oas3 "github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+)
+
+// ErrNoOperation is returned by Find for requests no operation of the spec describes.
+var ErrNoOperation = errors.New("no operation of the spec matches")
+
+// Where a violation is, besides the location of a parameter ("path", "query", "header" or
+// "cookie").
+const (
+	InBody   = "body"
+	InHeader = "header" // a header of a response
+	InStatus = "status" // the status of a response
+)
+
+// Violation is one way a request or response breaks its operation's contract.
+type Violation struct {
+	In      string `json:"in,omitempty"`   // "path", "query", "header", "cookie", "body" or "status"
+	Name    string `json:"name,omitempty"` // parameter name, or JSON pointer into the body
+	Message string `json:"message"`
+}
+
+// String describes the violation on one line, e.g. `query "limit": number must be at most 100`.
+func (v Violation) String() string {
+	message := strings.Join(strings.Fields(v.Message), " ")
+	switch {
+	case v.In == "":
+		return message
+	case v.Name == "":
+		return v.In + ": " + message
+	default:
+		return fmt.Sprintf("%s %q: %s", v.In, v.Name, message)
+	}
+}
+
+// Validator finds the operations of one document.
+type Validator struct {
+	doc    *oas3.T
+	routes []route
+	bases  []string // path components of the servers, which request paths may start with
+}
+
+// New returns a Validator of doc.
+func New(doc *oas3.T) *Validator {
+	bases := basePaths(doc)
+	// Longer bases first: "/api/v1/pets" is "/pets" of a server at "/api/v1" before one at "/api".
+	sort.Slice(bases, func(i, j int) bool { return len(bases[i]) > len(bases[j]) })
+	return &Validator{doc: doc, routes: compileRoutes(doc), bases: bases}
+}
+
+// Validators keeps the Validator of each spec, so a spec is compiled again only when it changes.
+type Validators struct {
+	mu         sync.Mutex
+	validators map[string]*Validator
+}
+
+// NewValidators returns an empty set of validators.
+func NewValidators() *Validators {
+	return &Validators{validators: make(map[string]*Validator)}
+}
+
+// Get returns the Validator of doc, known by key (e.g. the slug of its spec).
+func (vs *Validators) Get(key string, doc *oas3.T) *Validator {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	v := vs.validators[key]
+	if v == nil || v.doc != doc {
+		v = New(doc)
+		vs.validators[key] = v
+	}
+	return v
+}
+
+// Operation is the operation of the spec a request is for.
+type Operation struct {
+	Method string // e.g. "GET"
+	Path   string // path template, e.g. "/pets/{petId}"
+	ID     string // operationId, if any
+
+	route  *routers.Route
+	params map[string]string
+}
+
+// String names the operation, e.g. "GET /pets/{petId}".
+func (op *Operation) String() string {
+	return op.Method + " " + op.Path
+}
+
+// Find returns the operation method and u address. The path of u may start with the path of one
+// of the spec's servers, as URLs built from the spec do: "https://api.example.com/v1/pets" is
+// "/pets" of a server at "https://api.example.com/v1".
+func (v *Validator) Find(method string, u *url.URL) (*Operation, error) {
+	method = strings.ToUpper(method)
+	candidates := make([]string, 0, len(v.bases)+1)
+	for _, base := range v.bases {
+		if rest, ok := strings.CutPrefix(u.Path, base); ok && (rest == "" || rest[0] == '/') {
+			candidates = append(candidates, rest)
+		}
+	}
+	candidates = append(candidates, u.Path)
+
+	// A path that matches but lacks the method is only the answer if no later one has it:
+	// DELETE /pets/mine is the DELETE of "/pets/{petId}" when "/pets/mine" only has a GET.
+	var missing error
+	for _, candidate := range candidates {
+		for _, rt := range v.routes {
+			params, ok := rt.match(candidate)
+			if !ok {
+				continue
+			}
+			op := rt.item.GetOperation(method)
+			if op == nil {
+				if missing == nil {
+					missing = fmt.Errorf("%w: %s does not define %s", ErrNoOperation, rt.template, method)
+				}
+				continue
+			}
+			return &Operation{
+				Method: method,
+				Path:   rt.template,
+				ID:     op.OperationID,
+				route: &routers.Route{
+					Spec: v.doc, Path: rt.template, PathItem: rt.item, Method: method, Operation: op,
+				},
+				params: params,
+			}, nil
+		}
+	}
+	if missing != nil {
+		return nil, missing
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrNoOperation, method, u.Path)
+}
+
+// ValidateRequest checks the path, query, header and cookie parameters and the body of req
+// against the operation. Security requirements are left to the server. req is not changed,
+// except that its body is buffered so it can still be sent.
+func (op *Operation) ValidateRequest(req *http.Request) []Violation {
+	input := &openapi3filter.RequestValidationInput{
+		Request:    req,
+		PathParams: op.params,
+		Route:      op.route,
+		Options: &openapi3filter.Options{
+			MultiError:          true,
+			SkipSettingDefaults: true,
+			AuthenticationFunc:  openapi3filter.NoopAuthenticationFunc,
+			ExcludeRequestBody:  !decodable(op.route.Operation, req.Header.Get("Content-Type")),
+		},
+	}
+	err := openapi3filter.ValidateRequest(req.Context(), input)
+	if err == nil {
+		return nil
+	}
+	return violations("", "", err)
+}
+
+// decodable reports whether the body of a request with the given content type can be checked:
+// it can unless the operation accepts a media type the validator cannot decode, such as XML.
+func decodable(op *oas3.Operation, contentType string) bool {
+	if op.RequestBody == nil || op.RequestBody.Value == nil || op.RequestBody.Value.Content.Get(contentType) == nil {
+		return true // a missing body or an unexpected content type is reported
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && openapi3filter.RegisteredBodyDecoder(mediaType) != nil
+}
+
+// violations flattens a validation error into the violations it holds. in and name locate the
+// value err is about, when the error itself does not.
+func violations(in, name string, err error) []Violation {
+	switch e := err.(type) {
+	case oas3.MultiError:
+		var all []Violation
+		for _, inner := range e {
+			all = append(all, violations(in, name, inner)...)
+		}
+		return all
+	case *openapi3filter.RequestError:
+		switch {
+		case e.Parameter != nil:
+			in, name = e.Parameter.In, e.Parameter.Name
+		case e.RequestBody != nil:
+			in = InBody
+		}
+		switch e.Err.(type) {
+		case oas3.MultiError, *oas3.SchemaError:
+			return violations(in, name, e.Err)
+		}
+		// The error without its "parameter ... has an error" prefix, which in and name replace.
+		err = &openapi3filter.RequestError{Reason: e.Reason, Err: e.Err}
+	case *oas3.SchemaError:
+		if pointer := e.JSONPointer(); in == InBody && len(pointer) > 0 {
+			name = "/" + strings.Join(pointer, "/")
+		}
+		if e.Reason != "" {
+			return []Violation{{In: in, Name: name, Message: e.Reason}}
+		}
+	}
+	return []Violation{{In: in, Name: name, Message: err.Error()}}
+}
@@ -0,0 +1,247 @@
+package contract_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	oas3 "github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/Hossein-Roshandel/webswags/contract"
+)
+
+const petsSpec = `openapi: 3.0.3
+info: {title: Pets, version: "1"}
+servers:
+  - url: https://api.example.com/v1
+paths:
+  /pets/mine:
+    get:
+      operationId: listMyPets
+      responses:
+        "200": {description: My pets}
+  /pets/{petId}:
+    parameters:
+      - {name: petId, in: path, required: true, schema: {type: integer}}
+    get:
+      operationId: getPet
+      parameters:
+        - {name: verbose, in: query, schema: {type: boolean}}
+      responses:
+        "200":
+          description: A pet
+          headers:
+            X-Rate-Limit: {required: true, schema: {type: integer}}
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [id, name]
+                properties:
+                  id: {type: integer}
+                  name: {type: string}
+        4XX:
+          description: Error
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [message]
+                properties:
+                  message: {type: string}
+    delete:
+      operationId: deletePet
+      responses:
+        "204": {description: Deleted}
+`
+
+// load parses petsSpec.
+func load(t *testing.T) *oas3.T {
+	t.Helper()
+	doc, err := oas3.NewLoader().LoadFromData([]byte(petsSpec))
+	if err != nil {
+		t.Fatalf("LoadFromData: %v", err)
+	}
+	return doc
+}
+
+func validator(t *testing.T) *contract.Validator {
+	t.Helper()
+	return contract.New(load(t))
+}
+
+// find returns the operation of a request, failing the test if there is none.
+func find(t *testing.T, v *contract.Validator, method, rawURL string) *contract.Operation {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	op, err := v.Find(method, u)
+	if err != nil {
+		t.Fatalf("Find(%s %s): %v", method, rawURL, err)
+	}
+	return op
+}
+
+// described lists violations as their String.
+func described(found []contract.Violation) []string {
+	out := make([]string, 0, len(found))
+	for _, v := range found {
+		out = append(out, v.String())
+	}
+	return out
+}
+
+func TestFind(t *testing.T) {
+	t.Parallel()
+	v := validator(t)
+	tests := []struct {
+		method, url string
+		wantID      string
+		wantPath    string
+	}{
+		{method: "get", url: "/pets/mine", wantID: "listMyPets", wantPath: "/pets/mine"},
+		{method: http.MethodGet, url: "/pets/7", wantID: "getPet", wantPath: "/pets/{petId}"},
+		{method: http.MethodGet, url: "https://api.example.com/v1/pets/7", wantID: "getPet", wantPath: "/pets/{petId}"},
+		{method: http.MethodDelete, url: "/pets/mine", wantID: "deletePet", wantPath: "/pets/{petId}"},
+	}
+	for _, tt := range tests {
+		op := find(t, v, tt.method, tt.url)
+		if op.ID != tt.wantID || op.Path != tt.wantPath {
+			t.Errorf("Find(%s %s) = %s (%s), want %s (%s)", tt.method, tt.url, op, op.ID, tt.wantPath, tt.wantID)
+		}
+	}
+}
+
+func TestFindNoOperation(t *testing.T) {
+	t.Parallel()
+	v := validator(t)
+	tests := []struct {
+		method, url string
+		wantErr     string
+	}{
+		{method: http.MethodPost, url: "/pets/mine", wantErr: "/pets/mine does not define POST"},
+		{method: http.MethodGet, url: "/owners", wantErr: "GET /owners"},
+		{method: http.MethodGet, url: "/v10/pets/7", wantErr: "GET /v10/pets/7"},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.url)
+		_, err := v.Find(tt.method, u)
+		if !errors.Is(err, contract.ErrNoOperation) || !strings.HasSuffix(err.Error(), tt.wantErr) {
+			t.Errorf("Find(%s %s) error = %v, want ErrNoOperation ending in %q", tt.method, tt.url, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	t.Parallel()
+	v := validator(t)
+	tests := []struct {
+		url    string
+		wantIn []string
+	}{
+		{url: "/pets/7?verbose=true"},
+		{url: "/pets/seven", wantIn: []string{"path"}},
+		{url: "/pets/7?verbose=maybe", wantIn: []string{"query"}},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+		var in []string
+		for _, violation := range find(t, v, http.MethodGet, tt.url).ValidateRequest(req) {
+			in = append(in, violation.In)
+		}
+		if !slices.Equal(in, tt.wantIn) {
+			t.Errorf("violations of GET %s are in %q, want %q", tt.url, in, tt.wantIn)
+		}
+	}
+}
+
+func TestValidateResponse(t *testing.T) {
+	t.Parallel()
+	op := find(t, validator(t), http.MethodGet, "/pets/7")
+	jsonHeader := func(rateLimit string) http.Header {
+		header := http.Header{"Content-Type": {"application/json"}}
+		if rateLimit != "" {
+			header.Set("X-Rate-Limit", rateLimit)
+		}
+		return header
+	}
+	tests := []struct {
+		name   string
+		status int
+		header http.Header
+		body   string
+		want   []string
+	}{
+		{
+			name: "valid", status: http.StatusOK,
+			header: jsonHeader("10"), body: `{"id": 7, "name": "Rex"}`,
+		},
+		{
+			name: "status range", status: http.StatusNotFound,
+			header: jsonHeader(""), body: `{"message": "no such pet"}`,
+		},
+		{
+			name: "not modified", status: http.StatusNotModified,
+		},
+		{
+			name: "undeclared status", status: http.StatusInternalServerError,
+			want: []string{"status: 500 is not declared"},
+		},
+		{
+			name: "missing header", status: http.StatusOK,
+			header: jsonHeader(""), body: `{"id": 7, "name": "Rex"}`,
+			want: []string{`header "X-Rate-Limit": required header is missing`},
+		},
+		{
+			name: "invalid header", status: http.StatusOK,
+			header: jsonHeader("many"), body: `{"id": 7, "name": "Rex"}`,
+			want: []string{`header "X-Rate-Limit": value must be an integer`},
+		},
+		{
+			name: "invalid body", status: http.StatusOK,
+			header: jsonHeader("10"), body: `{"id": "7"}`,
+			want: []string{
+				`body "/id": value must be an integer`,
+				`body "/name": property "name" is missing`,
+			},
+		},
+		{
+			name: "undeclared content type", status: http.StatusOK,
+			header: http.Header{"Content-Type": {"text/html"}, "X-Rate-Limit": {"10"}}, body: "<p>Rex</p>",
+			want: []string{`body: content type "text/html" is not declared for status 200`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var body []byte
+			if tt.body != "" {
+				body = []byte(tt.body)
+			}
+			got := described(op.ValidateResponse(tt.status, tt.header, body))
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("violations = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatorsRecompileChangedSpecs(t *testing.T) {
+	t.Parallel()
+	validators := contract.NewValidators()
+	doc := load(t)
+	first := validators.Get("pets", doc)
+	if validators.Get("pets", doc) != first {
+		t.Error("Get of an unchanged document compiled it again")
+	}
+	if validators.Get("pets", load(t)) == first {
+		t.Error("Get of a changed document returned the old Validator")
+	}
+}
@@ -0,0 +1,87 @@
+package contract
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DriftEntry is what a DriftLog knows about the responses of one operation of one spec.
+type DriftEntry struct {
+	Service    string      `json:"service"`
+	Version    string      `json:"version"`
+	Operation  string      `json:"operation"`  // e.g. "GET /pets/{petId}"
+	Checked    int         `json:"checked"`    // responses checked
+	Violated   int         `json:"violated"`   // responses that broke the contract
+	LastStatus int         `json:"lastStatus"` // status of the last response that broke the contract
+	LastSeen   time.Time   `json:"lastSeen"`   // when it was received
+	Violations []Violation `json:"violations"` // how it broke the contract
+}
+
+// driftKey identifies an operation of a spec in a DriftLog.
+type driftKey struct {
+	service, version, operation string
+}
+
+// DriftLog records, per operation, how the responses of a server compare with its spec, so
+// servers that drift from their contract are noticed. It is safe for concurrent use.
+type DriftLog struct {
+	mu      sync.Mutex
+	entries map[driftKey]*DriftEntry
+}
+
+// NewDriftLog returns an empty DriftLog.
+func NewDriftLog() *DriftLog {
+	return &DriftLog{entries: make(map[driftKey]*DriftEntry)}
+}
+
+// Record adds a checked response to op of the version of service, with the violations
+// ValidateResponse found in it.
+func (l *DriftLog) Record(service, version string, op *Operation, status int, violations []Violation) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := driftKey{service: service, version: version, operation: op.String()}
+	entry, ok := l.entries[key]
+	if !ok {
+		entry = &DriftEntry{Service: service, Version: version, Operation: op.String(), Violations: []Violation{}}
+		l.entries[key] = entry
+	}
+	entry.Checked++
+	if len(violations) > 0 {
+		entry.Violated++
+		entry.LastStatus = status
+		entry.LastSeen = time.Now()
+		entry.Violations = violations
+	}
+}
+
+// Entries returns the operations of service, or of every service if it is empty, that received
+// a response breaking their contract, the most recent first.
+func (l *DriftLog) Entries(service string) []DriftEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entries := []DriftEntry{}
+	for key, entry := range l.entries {
+		if entry.Violated > 0 && (service == "" || key.service == service) {
+			entries = append(entries, *entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].LastSeen.Equal(entries[j].LastSeen) {
+			return entries[i].LastSeen.After(entries[j].LastSeen)
+		}
+		return entries[i].Operation < entries[j].Operation
+	})
+	return entries
+}
+
+// Reset forgets the responses recorded for service, or for every service if it is empty.
+func (l *DriftLog) Reset(service string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for key := range l.entries {
+		if service == "" || key.service == service {
+			delete(l.entries, key)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package contract
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	oas3 "github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+)
+
+// ValidateResponse checks a response to the operation: its status must be declared (as itself,
+// its range such as 4XX, or default), the headers the response declares as required must be
+// present, and the headers and body present must match their schemas. A nil body is not checked,
+// for responses too large to buffer. Bodies of media types that cannot be decoded, such as XML,
+// are not checked either.
+func (op *Operation) ValidateResponse(status int, header http.Header, body []byte) []Violation {
+	if status == http.StatusNotModified {
+		return nil // bodyless by definition, and rarely declared
+	}
+	responses := op.route.Operation.Responses
+	if responses == nil || responses.Len() == 0 {
+		return nil
+	}
+	ref := responses.Status(status)
+	if ref == nil {
+		ref = responses.Default()
+	}
+	if ref == nil {
+		return []Violation{{In: InStatus, Message: fmt.Sprintf("%d is not declared", status)}}
+	}
+	response := ref.Value
+	if response == nil {
+		return nil
+	}
+
+	var found []Violation
+	names := make([]string, 0, len(response.Headers))
+	for name := range response.Headers {
+		if !strings.EqualFold(name, "Content-Type") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		found = append(found, checkHeader(name, response.Headers[name], header)...)
+	}
+	if body != nil && op.Method != http.MethodHead {
+		found = append(found, checkBody(status, response.Content, header.Get("Content-Type"), body)...)
+	}
+	return found
+}
+
+// checkHeader checks the header name of a response against its declaration.
+func checkHeader(name string, ref *oas3.HeaderRef, header http.Header) []Violation {
+	if ref == nil || ref.Value == nil {
+		return nil
+	}
+	values := header.Values(name)
+	switch {
+	case len(values) == 0 && ref.Value.Required:
+		return []Violation{{In: InHeader, Name: name, Message: "required header is missing"}}
+	case len(values) == 0 || ref.Value.Schema == nil || ref.Value.Schema.Value == nil:
+		return nil
+	}
+	schema := ref.Value.Schema.Value
+	err := schema.VisitJSON(headerValue(strings.Join(values, ","), schema), oas3.MultiErrors(), oas3.VisitAsResponse())
+	if err == nil {
+		return nil
+	}
+	return violations(InHeader, name, err)
+}
+
+// headerValue reads the raw value of a header as the type its schema declares: "42" is a number
+// for an integer schema, and "a, b" an array for an array schema (the simple style of headers).
+// Values that do not parse stay strings, which the schema then rejects.
+func headerValue(raw string, schema *oas3.Schema) any {
+	switch {
+	case schema.Type.Is(oas3.TypeArray):
+		var items []any
+		for _, item := range strings.Split(raw, ",") {
+			item = strings.TrimSpace(item)
+			if schema.Items != nil && schema.Items.Value != nil {
+				items = append(items, headerValue(item, schema.Items.Value))
+			} else {
+				items = append(items, item)
+			}
+		}
+		return items
+	case schema.Type.Is(oas3.TypeInteger), schema.Type.Is(oas3.TypeNumber):
+		if number, err := strconv.ParseFloat(raw, 64); err == nil {
+			return number
+		}
+	case schema.Type.Is(oas3.TypeBoolean):
+		if b, err := strconv.ParseBool(raw); err == nil {
+			return b
+		}
+	}
+	return raw
+}
+
+// checkBody checks the body of a response with the given status and content type against the
+// schema the response declares for that content type.
+func checkBody(status int, content oas3.Content, contentType string, body []byte) []Violation {
+	if len(content) == 0 {
+		return nil // no body declared, so anything goes
+	}
+	media := content.Get(contentType)
+	if media == nil {
+		if len(body) == 0 {
+			return nil
+		}
+		return []Violation{{In: InBody, Message: fmt.Sprintf("content type %q is not declared for status %d",
+			contentType, status)}}
+	}
+	if media.Schema == nil || media.Schema.Value == nil {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil
+	}
+	decode := openapi3filter.RegisteredBodyDecoder(mediaType)
+	if decode == nil {
+		return nil
+	}
+	encoding := func(name string) *oas3.Encoding { return media.Encoding[name] }
+	value, err := decode(bytes.NewReader(body), http.Header{"Content-Type": {contentType}}, media.Schema, encoding)
+	if err != nil {
+		return []Violation{{In: InBody, Message: "cannot be decoded as " + mediaType + ": " + err.Error()}}
+	}
+	err = media.Schema.Value.VisitJSON(value, oas3.MultiErrors(), oas3.VisitAsResponse())
+	if err == nil {
+		return nil
+	}
+	return violations(InBody, "", err)
+}
@@ -0,0 +1,104 @@
+package contract
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	oas3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+// paramPattern matches a path parameter of a path template, e.g. "{petId}".
+var paramPattern = regexp.MustCompile(`\{([^{}/]+)\}`)
+
+// route is a path of the spec compiled for matching request paths.
+type route struct {
+	template string // e.g. "/pets/{petId}"
+	pattern  *regexp.Regexp
+	params   []string // names of the path parameters, in the order pattern captures them
+	literals int      // segments without parameters; more specific routes are tried first
+	item     *oas3.PathItem
+}
+
+// compileRoutes compiles the paths of doc, most specific first: "/pets/mine" is tried before
+// "/pets/{petId}".
+func compileRoutes(doc *oas3.T) []route {
+	if doc.Paths == nil {
+		return nil
+	}
+	var routes []route
+	for template, item := range doc.Paths.Map() {
+		if item == nil {
+			continue
+		}
+		r := route{template: template, item: item}
+		var pattern strings.Builder
+		pattern.WriteString("^")
+		for _, segment := range strings.Split(strings.Trim(template, "/"), "/") {
+			if segment == "" {
+				continue
+			}
+			pattern.WriteString("/")
+			if !strings.Contains(segment, "{") {
+				r.literals++
+			}
+			last := 0
+			for _, loc := range paramPattern.FindAllStringSubmatchIndex(segment, -1) {
+				pattern.WriteString(regexp.QuoteMeta(segment[last:loc[0]]))
+				pattern.WriteString("([^/]+)")
+				r.params = append(r.params, segment[loc[2]:loc[3]])
+				last = loc[1]
+			}
+			pattern.WriteString(regexp.QuoteMeta(segment[last:]))
+		}
+		pattern.WriteString("/?$")
+		r.pattern = regexp.MustCompile(pattern.String())
+		routes = append(routes, r)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].literals != routes[j].literals {
+			return routes[i].literals > routes[j].literals
+		}
+		return routes[i].template < routes[j].template
+	})
+	return routes
+}
+
+// match returns the path parameters of path if it is a path of the route.
+func (r route) match(path string) (map[string]string, bool) {
+	values := r.pattern.FindStringSubmatch(path)
+	if values == nil {
+		return nil, false
+	}
+	params := make(map[string]string, len(r.params))
+	for i, name := range r.params {
+		params[name] = values[i+1]
+	}
+	return params, true
+}
+
+// basePaths returns the path components of the servers of doc, with server variables at their
+// defaults: clients written against "https://api.example.com/v1" call "/v1/pets".
+func basePaths(doc *oas3.T) []string {
+	var bases []string
+	for _, server := range doc.Servers {
+		if server == nil {
+			continue
+		}
+		raw := server.URL
+		for name, variable := range server.Variables {
+			if variable != nil {
+				raw = strings.ReplaceAll(raw, "{"+name+"}", variable.Default)
+			}
+		}
+		u, err := url.Parse(raw)
+		if err != nil {
+			continue
+		}
+		if base := strings.TrimRight(u.Path, "/"); base != "" && base != "." {
+			bases = append(bases, "/"+strings.TrimLeft(base, "/"))
+		}
+	}
+	return bases
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Hossein-Roshandel/webswags/discovery"
+)
+
+// Exit codes of the subcommands.
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// outputFileMode is the permission of files written by the subcommands.
+const outputFileMode = 0o644
+
+// runConvert implements "webswags convert": it upgrades Swagger 2.0 files to OpenAPI 3.0 and
+// writes the result to disk. It returns the process exit code.
+func runConvert(args []string) int {
+	flags := flag.NewFlagSet("convert", flag.ContinueOnError)
+	output := flags.String("o", "",
+		"Output file, or - for stdout (single input only; default: <name>.openapi3.<format> next to each input)")
+	format := flags.String("format", "", "Output format: yaml or json (default: from -o, else the input's format)")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: webswags convert [-o file] [-format yaml|json] swagger.yaml...\n\n")
+		fmt.Fprintf(flags.Output(), "Upgrades Swagger 2.0 specs to OpenAPI 3.0.\n\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	inputs := flags.Args()
+	if len(inputs) == 0 || (*output != "" && len(inputs) > 1) {
+		flags.Usage()
+		return exitUsage
+	}
+	if *format != "" && *format != jsonFormat && *format != yamlFormat {
+		fmt.Fprintf(os.Stderr, "convert: unsupported format %q\n", *format)
+		return exitUsage
+	}
+
+	code := exitOK
+	for _, input := range inputs {
+		if err := convertFile(input, *output, *format); err != nil {
+			fmt.Fprintf(os.Stderr, "convert: %v\n", err)
+			code = exitError
+		}
+	}
+	return code
+}
+
+// convertFile upgrades one Swagger 2.0 file and writes it to output (see runConvert).
+func convertFile(input, output, format string) error {
+	spec, err := discovery.ParseFile(input)
+	if err != nil {
+		return err
+	}
+	if !spec.Upgraded() {
+		if spec.OpenAPIVersion != "" {
+			return fmt.Errorf("%s is already OpenAPI %s", input, spec.OpenAPIVersion)
+		}
+		return fmt.Errorf("%s could not be upgraded to OpenAPI 3", input)
+	}
+
+	if format == "" {
+		format = spec.Format
+		if output != "" && output != "-" {
+			format = yamlFormat
+			if strings.EqualFold(filepath.Ext(output), ".json") {
+				format = jsonFormat
+			}
+		}
+	}
+	if output == "" {
+		stem := strings.TrimSuffix(input, filepath.Ext(input))
+		output = stem + ".openapi3." + format
+	}
+
+	data, err := spec.OpenAPI3(format)
+	if err != nil {
+		return err
+	}
+
+	if output == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(output, data, outputFileMode); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s\n", output)
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunConvert(t *testing.T) {
+	t.Parallel()
+	swagger := `swagger: "2.0"
+info: {title: Pets, version: "1.0.0"}
+basePath: /v1
+paths: {}
+`
+	tests := []struct {
+		name     string
+		input    string // file written to the directory of the test
+		data     string
+		args     []string // before the input
+		wantCode int
+		wantFile string // relative to the directory of the test
+		wantText string
+	}{
+		{
+			name: "next to the input", input: "pets.yaml", data: swagger,
+			wantCode: exitOK, wantFile: "pets.openapi3.yaml", wantText: "openapi: 3.0.3",
+		},
+		{
+			name: "format from the output", input: "pets.yaml", data: swagger, args: []string{"-o", "out.json"},
+			wantCode: exitOK, wantFile: "out.json", wantText: `"openapi": "3.0.3"`,
+		},
+		{
+			name: "explicit format", input: "pets.yaml", data: swagger, args: []string{"-format", "json"},
+			wantCode: exitOK, wantFile: "pets.openapi3.json", wantText: `"openapi": "3.0.3"`,
+		},
+		{name: "already OpenAPI 3", input: "pets.yaml", data: petsYAML, wantCode: exitError},
+		{
+			name: "unknown format", input: "pets.yaml", data: swagger, args: []string{"-format", "xml"},
+			wantCode: exitUsage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			input := filepath.Join(dir, tt.input)
+			if err := os.WriteFile(input, []byte(tt.data), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			args := append([]string{}, tt.args...)
+			for i, arg := range args {
+				if i > 0 && args[i-1] == "-o" {
+					args[i] = filepath.Join(dir, arg)
+				}
+			}
+			if code := runConvert(append(args, input)); code != tt.wantCode {
+				t.Fatalf("runConvert = %d, want %d", code, tt.wantCode)
+			}
+			if tt.wantFile == "" {
+				return
+			}
+			data, err := os.ReadFile(filepath.Join(dir, tt.wantFile))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(data), tt.wantText) {
+				t.Errorf("%s = %q, want it to contain %q", tt.wantFile, data, tt.wantText)
+			}
+		})
+	}
+}
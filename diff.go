@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Hossein-Roshandel/webswags/diff"
+	"github.com/Hossein-Roshandel/webswags/discovery"
+)
+
+// exitBreaking is the exit code of "webswags diff" when breaking changes were found.
+const exitBreaking = 3
+
+// runDiff implements "webswags diff": it compares two specs and lists the changes, exiting
+// with exitBreaking if any of them is breaking. It returns the process exit code.
+func runDiff(args []string) int {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	root := flags.String("root", ".", "Root directory to search for the services FROM and TO name")
+	format := flags.String("format", "text", "Output format: text or json")
+	var sources []string
+	flags.Var((*stringList)(&sources), "source",
+		"Also discover specs from this source (repeatable, see the server's -source)")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: webswags diff [-root dir] [-format text|json] FROM TO\n\n")
+		fmt.Fprintf(flags.Output(), "Lists the changes from one spec to another and whether they break clients.\n")
+		fmt.Fprintf(flags.Output(), "FROM and TO are spec files, FILE@REV for a file at a git revision, or\n")
+		fmt.Fprintf(flags.Output(), "service[/v/version][@REV] for a spec discovered below -root.\n\n")
+		fmt.Fprintf(flags.Output(), "Exits with %d if any change is breaking.\n\n", exitBreaking)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() != 2 { //nolint:mnd // FROM and TO
+		flags.Usage()
+		return exitUsage
+	}
+	if *format != "text" && *format != jsonFormat {
+		fmt.Fprintf(os.Stderr, "diff: unsupported format %q\n", *format)
+		return exitUsage
+	}
+
+	resolver := &diffResolver{root: *root}
+	for _, spec := range sources {
+		source, err := discovery.ParseSource(spec)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "diff: %v\n", err)
+			return exitUsage
+		}
+		resolver.sources = append(resolver.sources, source)
+	}
+
+	ctx := context.Background()
+	var report DiffReport
+	docs := make([]discovery.SwaggerSpec, 0, 2) //nolint:mnd // FROM and TO
+	for i, side := range []*DiffSide{&report.From, &report.To} {
+		ref := flags.Arg(i)
+		spec, err := resolver.resolve(ctx, ref)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "diff: %v\n", err)
+			return exitError
+		}
+		if spec.DocV3 == nil {
+			fmt.Fprintf(os.Stderr, "diff: %s could not be loaded as an OpenAPI document\n", ref)
+			return exitError
+		}
+		*side = newDiffSide(ref, spec)
+		docs = append(docs, spec)
+	}
+	report.Report = diff.Compare(docs[0].DocV3, docs[1].DocV3)
+
+	write := writeDiffText
+	if *format == jsonFormat {
+		write = writeDiffJSON
+	}
+	if err := write(os.Stdout, report); err != nil {
+		fmt.Fprintf(os.Stderr, "diff: %v\n", err)
+		return exitError
+	}
+	if report.HasBreaking() {
+		return exitBreaking
+	}
+	return exitOK
+}
+
+// diffResolver finds the specs "webswags diff" compares, discovering the specs below root
+// only if an argument is not a file.
+type diffResolver struct {
+	root     string
+	sources  []discovery.Source
+	registry *discovery.Registry
+}
+
+// resolve returns the spec ref names: a file, a file at a git revision (FILE@REV) or a
+// discovered spec (see resolveSpecRef).
+func (dr *diffResolver) resolve(ctx context.Context, ref string) (discovery.SwaggerSpec, error) {
+	file, rev := ref, ""
+	if i := strings.LastIndex(ref, "@"); i > 0 && !isFile(ref) {
+		file, rev = ref[:i], ref[i+1:]
+	}
+	if isFile(file) {
+		spec, err := discovery.ParseFile(file)
+		if err != nil || rev == "" {
+			return spec, err
+		}
+		return spec.AtRevision(ctx, rev)
+	}
+
+	if dr.registry == nil {
+		dr.registry = discovery.NewRegistry(dr.root, discovery.DiscoverOptions{}, dr.sources...)
+		if err := dr.registry.Load(ctx); err != nil {
+			return discovery.SwaggerSpec{}, fmt.Errorf("failed to discover specs in %s: %w", dr.root, err)
+		}
+	}
+	spec, err := resolveSpecRef(ctx, dr.registry, ref)
+	if errors.Is(err, errSpecNotFound) {
+		return spec, fmt.Errorf("%q is neither a file nor a spec found in %s", ref, dr.root)
+	}
+	return spec, err
+}
+
+// isFile reports whether name is an existing regular file.
+func isFile(name string) bool {
+	info, err := os.Stat(name)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// writeDiffText prints a diff report for humans: one line per change, then a summary.
+func writeDiffText(w io.Writer, report DiffReport) error {
+	for _, c := range report.Changes {
+		level := "non-breaking"
+		if c.Level == diff.Breaking {
+			level = "BREAKING"
+		}
+		operation := c.Operation
+		if operation == "" {
+			operation = "-"
+		}
+		_, err := fmt.Fprintf(w, "%-12s  %s  %s\n              at %s\n", level, operation, c.Message, c.Pointer)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d breaking, %d non-breaking change(s) from %s to %s\n",
+		report.Breaking, report.NonBreaking, report.From.Ref, report.To.Ref)
+	return err
+}
+
+// writeDiffJSON prints a diff report as the /api/diff endpoint returns it.
+func writeDiffJSON(w io.Writer, report DiffReport) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
@@ -0,0 +1,445 @@
+// Package diff compares two versions of an OpenAPI 3 document and classifies every change as
+// breaking or non-breaking for the clients of the older one.
+//
+// Swagger 2.0 specs are compared through their OpenAPI 3 upgrade (discovery.SwaggerSpec.DocV3).
+package diff
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	oas3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+// Level says whether a change can break existing clients.
+type Level string
+
+const (
+	// Breaking marks a change that can make requests of existing clients fail, or hand them
+	// responses they do not expect.
+	Breaking Level = "breaking"
+	// NonBreaking marks a change existing clients keep working with.
+	NonBreaking Level = "non-breaking"
+)
+
+// Change is one difference between two versions of an API.
+type Change struct {
+	Rule      string `json:"rule"`                // what changed, e.g. "request-parameter-required-added"
+	Level     Level  `json:"level"`               // breaking or non-breaking
+	Pointer   string `json:"pointer"`             // JSON pointer into the new document (the old one for removals)
+	Operation string `json:"operation,omitempty"` // affected operation, e.g. "GET /pets/{id}"
+	Message   string `json:"message"`
+}
+
+// Report lists the changes between two documents, in document order: paths and methods
+// sorted, and each operation's parameters, request body and responses in turn.
+type Report struct {
+	Breaking    int      `json:"breaking"`
+	NonBreaking int      `json:"nonBreaking"`
+	Changes     []Change `json:"changes"`
+}
+
+// HasBreaking reports whether any change is breaking.
+func (r Report) HasBreaking() bool {
+	return r.Breaking > 0
+}
+
+// pathParamPattern matches the parameters of a path template.
+var pathParamPattern = regexp.MustCompile(`\{[^}]*\}`)
+
+// Compare classifies the changes from one document to the next. Either may be nil, which is
+// treated as a document without paths.
+func Compare(from, to *oas3.T) Report {
+	d := &differ{
+		from:   orEmpty(from),
+		to:     orEmpty(to),
+		active: make(map[[2]*oas3.Schema]bool),
+	}
+	d.paths()
+
+	report := Report{Changes: d.changes}
+	if report.Changes == nil {
+		report.Changes = []Change{}
+	}
+	for _, c := range report.Changes {
+		if c.Level == Breaking {
+			report.Breaking++
+		} else {
+			report.NonBreaking++
+		}
+	}
+	return report
+}
+
+// orEmpty returns doc, or an empty document for nil.
+func orEmpty(doc *oas3.T) *oas3.T {
+	if doc == nil {
+		return &oas3.T{}
+	}
+	return doc
+}
+
+// differ collects the changes between two documents.
+type differ struct {
+	from, to *oas3.T
+	changes  []Change
+	active   map[[2]*oas3.Schema]bool // schema pairs being compared, to stop at recursive schemas
+}
+
+// location is where a change is reported: a JSON pointer and the operation it belongs to.
+type location struct {
+	pointer   string
+	operation string
+}
+
+// child returns the location of a member of l.
+func (l location) child(tokens ...string) location {
+	for _, t := range tokens {
+		l.pointer += "/" + escapePointer(t)
+	}
+	return l
+}
+
+// escapePointer escapes a JSON pointer token (RFC 6901).
+func escapePointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+func (d *differ) add(level Level, at location, rule, format string, args ...any) {
+	d.changes = append(d.changes, Change{
+		Rule:      rule,
+		Level:     level,
+		Pointer:   at.pointer,
+		Operation: at.operation,
+		Message:   fmt.Sprintf(format, args...),
+	})
+}
+
+// pathKey identifies a path template regardless of its parameter names, so "/pets/{id}" and
+// "/pets/{petId}" are the same endpoint.
+func pathKey(path string) string {
+	return pathParamPattern.ReplaceAllString(path, "{}")
+}
+
+// pathIndex maps the path keys of a document to its paths.
+func pathIndex(doc *oas3.T) map[string]string {
+	index := make(map[string]string)
+	if doc.Paths != nil {
+		for path := range doc.Paths.Map() {
+			index[pathKey(path)] = path
+		}
+	}
+	return index
+}
+
+// paths compares the paths of both documents and, for paths in both, their operations.
+func (d *differ) paths() {
+	oldPaths, newPaths := pathIndex(d.from), pathIndex(d.to)
+	keys := slices.Sorted(maps.Keys(oldPaths))
+	for key := range newPaths {
+		if _, ok := oldPaths[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	root := location{pointer: "/paths"}
+	for _, key := range keys {
+		oldPath, inOld := oldPaths[key]
+		newPath, inNew := newPaths[key]
+		switch {
+		case !inNew:
+			d.add(Breaking, root.child(oldPath), "path-removed", "path %s was removed", oldPath)
+		case !inOld:
+			d.add(NonBreaking, root.child(newPath), "path-added", "path %s was added", newPath)
+		default:
+			d.pathItem(root.child(oldPath), root.child(newPath), oldPath, newPath)
+		}
+	}
+}
+
+// pathItem compares the operations of a path present in both documents.
+func (d *differ) pathItem(oldAt, at location, oldPath, newPath string) {
+	oldItem, newItem := d.from.Paths.Value(oldPath), d.to.Paths.Value(newPath)
+	oldOps, newOps := oldItem.Operations(), newItem.Operations()
+
+	methods := slices.Sorted(maps.Keys(oldOps))
+	for method := range newOps {
+		if _, ok := oldOps[method]; !ok {
+			methods = append(methods, method)
+		}
+	}
+	sort.Strings(methods)
+
+	for _, method := range methods {
+		opAt := at.child(strings.ToLower(method))
+		opAt.operation = method + " " + newPath
+		oldOp, inOld := oldOps[method]
+		newOp, inNew := newOps[method]
+		switch {
+		case !inNew:
+			removedAt := oldAt.child(strings.ToLower(method))
+			removedAt.operation = method + " " + oldPath
+			d.add(Breaking, removedAt, "operation-removed", "operation %s %s was removed", method, oldPath)
+		case !inOld:
+			d.add(NonBreaking, opAt, "operation-added", "operation %s %s was added", method, newPath)
+		default:
+			d.operation(opAt, operationPair{
+				method: strings.ToLower(method), oldItem: oldItem, newItem: newItem, oldOp: oldOp, newOp: newOp,
+				oldPath: oldPath, newPath: newPath, oldPointer: oldAt.pointer, newPointer: at.pointer,
+			})
+		}
+	}
+}
+
+// operationPair is an operation as it is in both documents.
+type operationPair struct {
+	method                 string // lower case, as in pointers
+	oldItem, newItem       *oas3.PathItem
+	oldOp, newOp           *oas3.Operation
+	oldPath, newPath       string
+	oldPointer, newPointer string // pointers of the path items
+}
+
+func (d *differ) operation(at location, op operationPair) {
+	if op.newOp.Deprecated && !op.oldOp.Deprecated {
+		d.add(NonBreaking, at.child("deprecated"), "operation-deprecated", "operation was deprecated")
+	}
+	if op.oldOp.OperationID != "" && op.newOp.OperationID != "" && op.oldOp.OperationID != op.newOp.OperationID {
+		d.add(NonBreaking, at.child("operationId"), "operation-id-changed",
+			"operationId changed from %q to %q", op.oldOp.OperationID, op.newOp.OperationID)
+	}
+
+	d.parameters(at, op)
+	d.requestBody(at.child("requestBody"), op.oldOp.RequestBody, op.newOp.RequestBody)
+	d.responses(at.child("responses"), op.oldOp.Responses, op.newOp.Responses)
+	d.security(at, op.oldOp, op.newOp)
+}
+
+// parameter is an operation parameter with the pointer it is declared at.
+type parameter struct {
+	value   *oas3.Parameter
+	pointer string
+}
+
+// parameters returns the effective parameters of an operation: those of its path item,
+// overridden by its own. Path parameters are keyed by position in the path template, the
+// others by location and name.
+func parameters(item *oas3.PathItem, op *oas3.Operation, path, pathPointer, opPointer string) map[string]parameter {
+	position := make(map[string]int)
+	for i, match := range pathParamPattern.FindAllString(path, -1) {
+		position[strings.Trim(match, "{}")] = i
+	}
+
+	params := make(map[string]parameter)
+	collect := func(refs oas3.Parameters, pointer string) {
+		for i, ref := range refs {
+			if ref == nil || ref.Value == nil {
+				continue
+			}
+			p := ref.Value
+			key := p.In + ":" + p.Name
+			if pos, ok := position[p.Name]; ok && p.In == oas3.ParameterInPath {
+				key = p.In + ":#" + strconv.Itoa(pos)
+			}
+			params[key] = parameter{value: p, pointer: pointer + "/parameters/" + strconv.Itoa(i)}
+		}
+	}
+	collect(item.Parameters, pathPointer)
+	collect(op.Parameters, opPointer)
+	return params
+}
+
+func (d *differ) parameters(at location, op operationPair) {
+	oldParams := parameters(op.oldItem, op.oldOp, op.oldPath, op.oldPointer, op.oldPointer+"/"+op.method)
+	newParams := parameters(op.newItem, op.newOp, op.newPath, op.newPointer, at.pointer)
+
+	keys := slices.Sorted(maps.Keys(oldParams))
+	for key := range newParams {
+		if _, ok := oldParams[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		oldParam, inOld := oldParams[key]
+		newParam, inNew := newParams[key]
+		switch {
+		case !inNew:
+			p := oldParam.value
+			paramAt := location{pointer: oldParam.pointer, operation: at.operation}
+			d.add(NonBreaking, paramAt, "request-parameter-removed", "%s parameter %q was removed", p.In, p.Name)
+		case !inOld:
+			p, paramAt := newParam.value, location{pointer: newParam.pointer, operation: at.operation}
+			if p.Required {
+				d.add(Breaking, paramAt, "request-parameter-required-added",
+					"required %s parameter %q was added", p.In, p.Name)
+			} else {
+				d.add(NonBreaking, paramAt, "request-parameter-added",
+					"optional %s parameter %q was added", p.In, p.Name)
+			}
+		default:
+			d.parameter(location{pointer: newParam.pointer, operation: at.operation}, oldParam.value, newParam.value)
+		}
+	}
+}
+
+func (d *differ) parameter(at location, from, to *oas3.Parameter) {
+	switch {
+	case to.Required && !from.Required:
+		d.add(Breaking, at.child("required"), "request-parameter-became-required",
+			"%s parameter %q became required", to.In, to.Name)
+	case from.Required && !to.Required:
+		d.add(NonBreaking, at.child("required"), "request-parameter-became-optional",
+			"%s parameter %q became optional", to.In, to.Name)
+	}
+	if to.Deprecated && !from.Deprecated {
+		d.add(NonBreaking, at.child("deprecated"), "request-parameter-deprecated",
+			"%s parameter %q was deprecated", to.In, to.Name)
+	}
+	d.schema(request, at.child("schema"), from.Schema, to.Schema)
+}
+
+func (d *differ) requestBody(at location, from, to *oas3.RequestBodyRef) {
+	var oldBody, newBody *oas3.RequestBody
+	if from != nil {
+		oldBody = from.Value
+	}
+	if to != nil {
+		newBody = to.Value
+	}
+
+	switch {
+	case oldBody == nil && newBody == nil:
+		return
+	case oldBody == nil:
+		if newBody.Required {
+			d.add(Breaking, at, "request-body-required-added", "a required request body was added")
+		} else {
+			d.add(NonBreaking, at, "request-body-added", "an optional request body was added")
+		}
+		return
+	case newBody == nil:
+		d.add(NonBreaking, at, "request-body-removed", "the request body was removed")
+		return
+	}
+
+	if newBody.Required && !oldBody.Required {
+		d.add(Breaking, at.child("required"), "request-body-became-required", "the request body became required")
+	}
+	d.content(request, at.child("content"), oldBody.Content, newBody.Content)
+}
+
+func (d *differ) responses(at location, from, to *oas3.Responses) {
+	oldResponses, newResponses := responseMap(from), responseMap(to)
+
+	codes := slices.Sorted(maps.Keys(oldResponses))
+	for code := range newResponses {
+		if _, ok := oldResponses[code]; !ok {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		oldResponse, inOld := oldResponses[code]
+		newResponse, inNew := newResponses[code]
+		codeAt := at.child(code)
+		switch {
+		case !inNew:
+			level := NonBreaking
+			if strings.HasPrefix(code, "2") {
+				level = Breaking // clients expecting this success response no longer get it
+			}
+			d.add(level, codeAt, "response-removed", "response %s was removed", code)
+		case !inOld:
+			d.add(NonBreaking, codeAt, "response-added", "response %s was added", code)
+		default:
+			d.content(response, codeAt.child("content"), oldResponse.Content, newResponse.Content)
+		}
+	}
+}
+
+// responseMap returns the responses with a value, keyed by status code.
+func responseMap(responses *oas3.Responses) map[string]*oas3.Response {
+	out := make(map[string]*oas3.Response)
+	if responses == nil {
+		return out
+	}
+	for code, ref := range responses.Map() {
+		if ref != nil && ref.Value != nil {
+			out[code] = ref.Value
+		}
+	}
+	return out
+}
+
+// content compares the media types of a request body or response.
+func (d *differ) content(dir direction, at location, from, to oas3.Content) {
+	types := slices.Sorted(maps.Keys(from))
+	for mediaType := range to {
+		if _, ok := from[mediaType]; !ok {
+			types = append(types, mediaType)
+		}
+	}
+	sort.Strings(types)
+
+	for _, mediaType := range types {
+		oldMedia, inOld := from[mediaType]
+		newMedia, inNew := to[mediaType]
+		mediaAt := at.child(mediaType)
+		switch {
+		case !inNew:
+			d.add(Breaking, mediaAt, dir.rule("media-type-removed"), "%s media type %s was removed", dir, mediaType)
+		case !inOld:
+			d.add(NonBreaking, mediaAt, dir.rule("media-type-added"), "%s media type %s was added", dir, mediaType)
+		case oldMedia != nil && newMedia != nil:
+			d.schema(dir, mediaAt.child("schema"), oldMedia.Schema, newMedia.Schema)
+		}
+	}
+}
+
+// security compares the effective security requirements of an operation. Requirements are
+// alternatives: removing one breaks the clients using it, and requiring any where none were
+// required breaks anonymous clients.
+func (d *differ) security(at location, from, to *oas3.Operation) {
+	oldReqs, newReqs := requirements(d.from, from), requirements(d.to, to)
+	at = at.child("security")
+
+	if len(oldReqs) == 0 || slices.Contains(oldReqs, "") {
+		if len(newReqs) > 0 && !slices.Contains(newReqs, "") {
+			d.add(Breaking, at, "security-added", "authentication became required (%s)", strings.Join(newReqs, " or "))
+		}
+		return
+	}
+	for _, req := range oldReqs {
+		if !slices.Contains(newReqs, req) {
+			d.add(Breaking, at, "security-requirement-removed", "security requirement %s was removed", req)
+		}
+	}
+	for _, req := range newReqs {
+		if !slices.Contains(oldReqs, req) && req != "" {
+			d.add(NonBreaking, at, "security-requirement-added", "security requirement %s was added", req)
+		}
+	}
+}
+
+// requirements describes the security requirements in force for op, each as its sorted
+// scheme names joined with "+" ("" for the empty requirement that allows anonymous access).
+func requirements(doc *oas3.T, op *oas3.Operation) []string {
+	reqs := doc.Security
+	if op.Security != nil {
+		reqs = *op.Security
+	}
+	out := make([]string, 0, len(reqs))
+	for _, req := range reqs {
+		out = append(out, strings.Join(slices.Sorted(maps.Keys(req)), "+"))
+	}
+	return out
+}
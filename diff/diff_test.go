@@ -0,0 +1,198 @@
+package diff_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	oas3 "github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/Hossein-Roshandel/webswags/diff"
+)
+
+const petsV1 = `openapi: 3.0.3
+info: {title: Pets, version: "1"}
+paths:
+  /pets/{id}:
+    get:
+      operationId: getPet
+      parameters:
+        - {name: id, in: path, required: true, schema: {type: string}}
+        - {name: fields, in: query, schema: {type: string}}
+      responses:
+        "200":
+          description: A pet
+          content:
+            application/json:
+              schema: {$ref: '#/components/schemas/Pet'}
+        "404": {description: Not found}
+  /pets:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema: {$ref: '#/components/schemas/Pet'}
+      responses:
+        "201": {description: Created}
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [name]
+      properties:
+        name: {type: string, maxLength: 50}
+        kind: {type: string, enum: [cat, dog]}
+`
+
+func load(t *testing.T, data string) *oas3.T {
+	t.Helper()
+	doc, err := oas3.NewLoader().LoadFromData([]byte(data))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	return doc
+}
+
+// change is the part of a diff.Change a test checks.
+type change struct {
+	rule  string
+	level diff.Level
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		edits []string // old and new strings, replaced in petsV1 to make the next version
+		want  []change
+	}{
+		{name: "unchanged"},
+		{
+			name:  "path parameter renamed",
+			edits: []string{"/pets/{id}:", "/pets/{petId}:", "name: id, in: path", "name: petId, in: path"},
+		},
+		{
+			name: "optional parameter added",
+			edits: []string{
+				"- {name: fields,",
+				"- {name: limit, in: query, schema: {type: integer}}\n        - {name: fields,",
+			},
+			want: []change{{"request-parameter-added", diff.NonBreaking}},
+		},
+		{
+			name: "required parameter added",
+			edits: []string{
+				"- {name: fields,",
+				"- {name: limit, in: query, required: true, schema: {type: integer}}\n        - {name: fields,",
+			},
+			want: []change{{"request-parameter-required-added", diff.Breaking}},
+		},
+		{
+			name:  "parameter became required",
+			edits: []string{"{name: fields, in: query,", "{name: fields, in: query, required: true,"},
+			want:  []change{{"request-parameter-became-required", diff.Breaking}},
+		},
+		{
+			name:  "parameter removed",
+			edits: []string{"        - {name: fields, in: query, schema: {type: string}}\n", ""},
+			want:  []change{{"request-parameter-removed", diff.NonBreaking}},
+		},
+		{
+			name:  "request body became required",
+			edits: []string{"      requestBody:\n", "      requestBody:\n        required: true\n"},
+			want:  []change{{"request-body-became-required", diff.Breaking}},
+		},
+		{
+			name:  "enum value added",
+			edits: []string{"enum: [cat, dog]", "enum: [cat, dog, bird]"},
+			want: []change{
+				{"request-enum-value-added", diff.NonBreaking},
+				{"response-enum-value-added", diff.Breaking},
+			},
+		},
+		{
+			name:  "enum value removed",
+			edits: []string{"enum: [cat, dog]", "enum: [cat]"},
+			want: []change{
+				{"request-enum-value-removed", diff.Breaking},
+				{"response-enum-value-removed", diff.NonBreaking},
+			},
+		},
+		{
+			name:  "maxLength decreased",
+			edits: []string{"maxLength: 50", "maxLength: 20"},
+			want: []change{
+				{"request-maxLength-decreased", diff.Breaking},
+				{"response-maxLength-decreased", diff.NonBreaking},
+			},
+		},
+		{
+			name:  "property became required",
+			edits: []string{"required: [name]", "required: [name, kind]"},
+			want: []change{
+				{"request-property-became-required", diff.Breaking},
+				{"response-property-became-required", diff.NonBreaking},
+			},
+		},
+		{
+			name:  "type changed",
+			edits: []string{"name: {type: string,", "name: {type: integer,"},
+			want:  []change{{"request-type-changed", diff.Breaking}, {"response-type-changed", diff.Breaking}},
+		},
+		{
+			name:  "response removed",
+			edits: []string{"        \"404\": {description: Not found}\n", ""},
+			want:  []change{{"response-removed", diff.NonBreaking}},
+		},
+		{
+			name:  "operation id changed",
+			edits: []string{"operationId: getPet", "operationId: fetchPet"},
+			want:  []change{{"operation-id-changed", diff.NonBreaking}},
+		},
+		{
+			name:  "path removed",
+			edits: []string{"  /pets:\n    post:", "  /animals:\n    post:"},
+			want:  []change{{"path-added", diff.NonBreaking}, {"path-removed", diff.Breaking}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			for i := 0; i < len(tt.edits); i += 2 {
+				if !strings.Contains(petsV1, tt.edits[i]) {
+					t.Fatalf("%q is not in the spec", tt.edits[i])
+				}
+			}
+			next := strings.NewReplacer(tt.edits...).Replace(petsV1)
+			report := diff.Compare(load(t, petsV1), load(t, next))
+			got := make([]change, 0, len(report.Changes))
+			breaking := 0
+			for _, c := range report.Changes {
+				got = append(got, change{c.Rule, c.Level})
+				if c.Level == diff.Breaking {
+					breaking++
+				}
+			}
+			if !slices.Equal(got, tt.want) && (len(got) > 0 || len(tt.want) > 0) {
+				t.Errorf("changes = %v, want %v", got, tt.want)
+			}
+			if report.Breaking != breaking || report.HasBreaking() != (breaking > 0) {
+				t.Errorf("Breaking = %d, HasBreaking = %t; %d changes are breaking",
+					report.Breaking, report.HasBreaking(), breaking)
+			}
+		})
+	}
+}
+
+func TestCompareNil(t *testing.T) {
+	t.Parallel()
+	report := diff.Compare(nil, load(t, petsV1))
+	if report.Breaking != 0 || report.NonBreaking != 2 {
+		t.Errorf("Compare(nil, doc) = %d breaking, %d non-breaking; want 0, 2 (the added paths)",
+			report.Breaking, report.NonBreaking)
+	}
+	report = diff.Compare(load(t, petsV1), nil)
+	if report.Breaking != 2 {
+		t.Errorf("Compare(doc, nil) = %d breaking, want 2 (the removed paths)", report.Breaking)
+	}
+}
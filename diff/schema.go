@@ -0,0 +1,339 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	oas3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+// direction says which way the values a schema describes travel.
+type direction int
+
+const (
+	// request schemas describe what clients send: narrowing them breaks clients.
+	request direction = iota
+	// response schemas describe what clients receive: widening them breaks clients.
+	response
+)
+
+func (dir direction) String() string {
+	if dir == request {
+		return "request"
+	}
+	return "response"
+}
+
+// rule prefixes a rule name with the direction, e.g. "request-enum-value-removed".
+func (dir direction) rule(name string) string {
+	return dir.String() + "-" + name
+}
+
+// level classifies a change to a schema. A change narrows the schema when values it accepted
+// are no longer valid, and widens it when new values become valid; it may do both.
+func (dir direction) level(narrows, widens bool) Level {
+	if (dir == request && narrows) || (dir == response && widens) {
+		return Breaking
+	}
+	return NonBreaking
+}
+
+// constraint records a change to a schema that narrows and/or widens it.
+func (d *differ) constraint(dir direction, at location, narrows, widens bool, rule, format string, args ...any) {
+	d.add(dir.level(narrows, widens), at, dir.rule(rule), format, args...)
+}
+
+// schema compares two schemas found at the same place of both documents.
+func (d *differ) schema(dir direction, at location, fromRef, toRef *oas3.SchemaRef) {
+	if fromRef == nil || toRef == nil || fromRef.Value == nil || toRef.Value == nil {
+		return
+	}
+	from, to := fromRef.Value, toRef.Value
+	pair := [2]*oas3.Schema{from, to}
+	if d.active[pair] {
+		return // a recursive schema, already being compared further up
+	}
+	d.active[pair] = true
+	defer delete(d.active, pair)
+
+	if !d.schemaType(dir, at, from, to) {
+		return // values of the two schemas have little in common; comparing further is noise
+	}
+	d.stringChange(dir, at.child("format"), "format", from.Format, to.Format)
+	d.stringChange(dir, at.child("pattern"), "pattern", from.Pattern, to.Pattern)
+	d.enum(dir, at.child("enum"), from.Enum, to.Enum)
+
+	if from.Nullable != to.Nullable {
+		d.constraint(dir, at.child("nullable"), from.Nullable, to.Nullable, "nullable-changed",
+			"nullable changed from %t to %t", from.Nullable, to.Nullable)
+	}
+	d.bounds(dir, at, from, to)
+
+	d.properties(dir, at, from, to)
+	d.schema(dir, at.child("items"), from.Items, to.Items)
+	d.composition(dir, at, "oneOf", from.OneOf, to.OneOf)
+	d.composition(dir, at, "anyOf", from.AnyOf, to.AnyOf)
+	d.composition(dir, at, "allOf", from.AllOf, to.AllOf)
+}
+
+// schemaType compares the types of two schemas and reports whether they are still compatible.
+// "integer" is part of "number", so changing one into the other only narrows or widens.
+func (d *differ) schemaType(dir direction, at location, from, to *oas3.Schema) bool {
+	oldTypes, newTypes := typeSet(from), typeSet(to)
+	if len(oldTypes) == 0 || len(newTypes) == 0 {
+		if len(oldTypes) != len(newTypes) {
+			d.constraint(dir, at.child("type"), len(oldTypes) == 0, len(newTypes) == 0, "type-changed",
+				"type changed from %s to %s", describeTypes(oldTypes), describeTypes(newTypes))
+		}
+		return true
+	}
+
+	narrows := !coversTypes(newTypes, oldTypes)
+	widens := !coversTypes(oldTypes, newTypes)
+	if !narrows && !widens {
+		return true
+	}
+	d.constraint(dir, at.child("type"), narrows, widens, "type-changed",
+		"type changed from %s to %s", describeTypes(oldTypes), describeTypes(newTypes))
+	return !(narrows && widens)
+}
+
+// typeSet returns the types a schema allows, sorted; empty means any.
+func typeSet(schema *oas3.Schema) []string {
+	if schema.Type == nil {
+		return nil
+	}
+	types := slices.Clone(schema.Type.Slice())
+	sort.Strings(types)
+	return types
+}
+
+// coversTypes reports whether every type of inner is allowed by outer.
+func coversTypes(outer, inner []string) bool {
+	for _, t := range inner {
+		if !slices.Contains(outer, t) && !(t == "integer" && slices.Contains(outer, "number")) {
+			return false
+		}
+	}
+	return true
+}
+
+func describeTypes(types []string) string {
+	if len(types) == 0 {
+		return "any"
+	}
+	return strings.Join(types, "|")
+}
+
+// stringChange compares a keyword that restricts values when set, such as format or pattern:
+// adding it narrows the schema, removing it widens it and changing it does both.
+func (d *differ) stringChange(dir direction, at location, keyword, from, to string) {
+	switch {
+	case from == to:
+	case from == "":
+		d.constraint(dir, at, true, false, keyword+"-added", "%s %q was added", keyword, to)
+	case to == "":
+		d.constraint(dir, at, false, true, keyword+"-removed", "%s %q was removed", keyword, from)
+	default:
+		d.constraint(dir, at, true, true, keyword+"-changed", "%s changed from %q to %q", keyword, from, to)
+	}
+}
+
+// enum compares the allowed values of two schemas.
+func (d *differ) enum(dir direction, at location, from, to []any) {
+	switch {
+	case len(from) == 0 && len(to) == 0:
+		return
+	case len(from) == 0:
+		d.constraint(dir, at, true, false, "enum-added", "values were restricted to %s", describeValues(to))
+		return
+	case len(to) == 0:
+		d.constraint(dir, at, false, true, "enum-removed", "the enum restricting values to %s was removed",
+			describeValues(from))
+		return
+	}
+
+	oldValues, newValues := valueSet(from), valueSet(to)
+	for _, key := range slices.Sorted(maps.Keys(oldValues)) {
+		if _, ok := newValues[key]; !ok {
+			d.constraint(dir, at, true, false, "enum-value-removed", "enum value %s was removed", key)
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(newValues)) {
+		if _, ok := oldValues[key]; !ok {
+			d.constraint(dir, at, false, true, "enum-value-added", "enum value %s was added", key)
+		}
+	}
+}
+
+// valueSet keys enum values by their JSON encoding, so 1 and "1" differ.
+func valueSet(values []any) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[encodeValue(v)] = true
+	}
+	return set
+}
+
+func encodeValue(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+func describeValues(values []any) string {
+	encoded := make([]string, 0, len(values))
+	for _, v := range values {
+		encoded = append(encoded, encodeValue(v))
+	}
+	return strings.Join(encoded, ", ")
+}
+
+// bounds compares the numeric, length and size limits of two schemas.
+func (d *differ) bounds(dir direction, at location, from, to *oas3.Schema) {
+	d.lowerBound(dir, at.child("minimum"), "minimum", from.Min, to.Min)
+	d.upperBound(dir, at.child("maximum"), "maximum", from.Max, to.Max)
+	d.lowerBound(dir, at.child("minLength"), "minLength", countBound(from.MinLength), countBound(to.MinLength))
+	d.upperBound(dir, at.child("maxLength"), "maxLength", countLimit(from.MaxLength), countLimit(to.MaxLength))
+	d.lowerBound(dir, at.child("minItems"), "minItems", countBound(from.MinItems), countBound(to.MinItems))
+	d.upperBound(dir, at.child("maxItems"), "maxItems", countLimit(from.MaxItems), countLimit(to.MaxItems))
+}
+
+// countBound converts a minimum count, where 0 means none, to a bound.
+func countBound(n uint64) *float64 {
+	if n == 0 {
+		return nil
+	}
+	f := float64(n)
+	return &f
+}
+
+// countLimit converts an optional maximum count to a bound.
+func countLimit(n *uint64) *float64 {
+	if n == nil {
+		return nil
+	}
+	f := float64(*n)
+	return &f
+}
+
+// lowerBound compares a lower limit: raising or adding it narrows the schema.
+func (d *differ) lowerBound(dir direction, at location, keyword string, from, to *float64) {
+	switch {
+	case from == nil && to == nil:
+	case from == nil:
+		d.constraint(dir, at, true, false, keyword+"-added", "%s %g was added", keyword, *to)
+	case to == nil:
+		d.constraint(dir, at, false, true, keyword+"-removed", "%s %g was removed", keyword, *from)
+	case *to > *from:
+		d.constraint(dir, at, true, false, keyword+"-increased", "%s increased from %g to %g", keyword, *from, *to)
+	case *to < *from:
+		d.constraint(dir, at, false, true, keyword+"-decreased", "%s decreased from %g to %g", keyword, *from, *to)
+	}
+}
+
+// upperBound compares an upper limit: lowering or adding it narrows the schema.
+func (d *differ) upperBound(dir direction, at location, keyword string, from, to *float64) {
+	switch {
+	case from == nil && to == nil:
+	case from == nil:
+		d.constraint(dir, at, true, false, keyword+"-added", "%s %g was added", keyword, *to)
+	case to == nil:
+		d.constraint(dir, at, false, true, keyword+"-removed", "%s %g was removed", keyword, *from)
+	case *to < *from:
+		d.constraint(dir, at, true, false, keyword+"-decreased", "%s decreased from %g to %g", keyword, *from, *to)
+	case *to > *from:
+		d.constraint(dir, at, false, true, keyword+"-increased", "%s increased from %g to %g", keyword, *from, *to)
+	}
+}
+
+// properties compares the properties of two object schemas. Read-only properties are not
+// sent in requests and write-only ones are not returned in responses, so they are skipped.
+func (d *differ) properties(dir direction, at location, from, to *oas3.Schema) {
+	names := slices.Sorted(maps.Keys(from.Properties))
+	for name := range to.Properties {
+		if _, ok := from.Properties[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	propsAt := at.child("properties")
+	for _, name := range names {
+		oldProp, inOld := from.Properties[name]
+		newProp, inNew := to.Properties[name]
+		if !relevant(dir, oldProp) && !relevant(dir, newProp) {
+			continue
+		}
+		oldRequired, newRequired := slices.Contains(from.Required, name), slices.Contains(to.Required, name)
+		propAt := propsAt.child(name)
+
+		switch {
+		case !inNew:
+			// Clients may still send it (the server ignores it), but those reading it lose it.
+			level := NonBreaking
+			if dir == response {
+				level = Breaking
+			}
+			d.add(level, propAt, dir.rule("property-removed"), "%s property %q was removed", dir, name)
+		case !inOld:
+			if newRequired {
+				d.constraint(dir, propAt, true, false, "property-required-added",
+					"required %s property %q was added", dir, name)
+			} else {
+				d.add(NonBreaking, propAt, dir.rule("property-added"), "optional %s property %q was added", dir, name)
+			}
+		default:
+			switch {
+			case newRequired && !oldRequired:
+				d.constraint(dir, propAt, true, false, "property-became-required",
+					"%s property %q became required", dir, name)
+			case oldRequired && !newRequired:
+				d.constraint(dir, propAt, false, true, "property-became-optional",
+					"%s property %q became optional", dir, name)
+			}
+			d.schema(dir, propAt, oldProp, newProp)
+		}
+	}
+}
+
+// relevant reports whether a property travels in the given direction.
+func relevant(dir direction, prop *oas3.SchemaRef) bool {
+	if prop == nil || prop.Value == nil {
+		return false
+	}
+	if dir == request {
+		return !prop.Value.ReadOnly
+	}
+	return !prop.Value.WriteOnly
+}
+
+// composition compares the subschemas of oneOf, anyOf or allOf, position by position. Extra
+// alternatives (oneOf, anyOf) widen the schema; extra allOf members narrow it.
+func (d *differ) composition(dir direction, at location, keyword string, from, to oas3.SchemaRefs) {
+	at = at.child(keyword)
+	for i := range min(len(from), len(to)) {
+		d.schema(dir, at.child(fmt.Sprint(i)), from[i], to[i])
+	}
+	if len(from) == len(to) {
+		return
+	}
+
+	more := len(to) > len(from)
+	narrows, widens := !more, more
+	if keyword == "allOf" {
+		narrows, widens = widens, narrows
+	}
+	verb := "removed"
+	if more {
+		verb = "added"
+	}
+	d.constraint(dir, at, narrows, widens, keyword+"-"+verb, "%s subschemas were %s (%d → %d)",
+		keyword, verb, len(from), len(to))
+}
@@ -0,0 +1,391 @@
+package discovery
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	oas3 "github.com/getkin/kin-openapi/openapi3"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Severity classifies a Diagnostic.
+type Severity string
+
+const (
+	// SeverityError marks a problem that makes the spec invalid; tools may reject or misrender it.
+	SeverityError Severity = "error"
+	// SeverityWarning marks a problem that does not make the spec invalid, e.g. an example that
+	// does not match its schema.
+	SeverityWarning Severity = "warning"
+)
+
+// Health summarises a spec's diagnostics.
+const (
+	HealthValid    = "valid"
+	HealthWarnings = "warnings"
+	HealthErrors   = "errors"
+)
+
+// Diagnostic is one problem found while loading or validating a spec.
+type Diagnostic struct {
+	Severity Severity `json:"severity"         yaml:"severity"`
+	Message  string   `json:"message"          yaml:"message"`
+	Pointer  string   `json:"pointer"          yaml:"pointer"`          // JSON pointer into the file, "" for the root
+	Line     int      `json:"line,omitempty"   yaml:"line,omitempty"`   // 1-based line in the file, 0 if unknown
+	Column   int      `json:"column,omitempty" yaml:"column,omitempty"` // 1-based column in the file, 0 if unknown
+}
+
+// Health returns HealthErrors, HealthWarnings or HealthValid depending on the worst diagnostic.
+func (s SwaggerSpec) Health() string {
+	switch {
+	case s.ErrorCount() > 0:
+		return HealthErrors
+	case len(s.Diagnostics) > 0:
+		return HealthWarnings
+	default:
+		return HealthValid
+	}
+}
+
+// ErrorCount returns the number of error diagnostics.
+func (s SwaggerSpec) ErrorCount() int {
+	n := 0
+	for _, d := range s.Diagnostics {
+		if d.Severity == SeverityError {
+			n++
+		}
+	}
+	return n
+}
+
+// WarningCount returns the number of warning diagnostics.
+func (s SwaggerSpec) WarningCount() int {
+	return len(s.Diagnostics) - s.ErrorCount()
+}
+
+var (
+	// yamlErrorLinePattern extracts the line number from YAML/JSON syntax errors ("yaml: line 12: ...").
+	yamlErrorLinePattern = regexp.MustCompile(`line (\d+)`)
+	// invalidPathPattern extracts the path from kin-openapi's "invalid path /pets: ..." errors.
+	invalidPathPattern = regexp.MustCompile(`^invalid paths: invalid path (\S+):`)
+	// invalidOperationPattern extracts method and path from kin-openapi's "operation GET /pets ..." errors.
+	invalidOperationPattern = regexp.MustCompile(`^invalid paths: operation ([A-Z]+) (\S+) `)
+	// oas30RulePattern matches kin-openapi errors that enforce OpenAPI 3.0 rules OpenAPI 3.1 relaxed:
+	// keywords next to a $ref, the "null" type and arrays without items.
+	oas30RulePattern = regexp.MustCompile(
+		`extra sibling fields|unsupported 'type' value "null"|'items' must be non-null`)
+)
+
+// documentSections maps kin-openapi's top-level validation error prefixes to JSON pointers.
+func documentSections() map[string]string {
+	return map[string]string{
+		"invalid components":    "/components",
+		"invalid info":          "/info",
+		"invalid paths":         "/paths",
+		"invalid security":      "/security",
+		"invalid servers":       "/servers",
+		"invalid tags":          "/tags",
+		"invalid external docs": "/externalDocs",
+	}
+}
+
+// validator is implemented by every kin-openapi type that can validate itself.
+type validator interface {
+	Validate(ctx context.Context, opts ...oas3.ValidationOption) error
+}
+
+// diagnoser collects the diagnostics of one spec.
+type diagnoser struct {
+	spec    *SwaggerSpec
+	root    *yamlv3.Node // parsed source file, for line numbers; nil if unavailable
+	lenient bool         // report errors of OpenAPI 3.0 rules as warnings, for 3.1 documents
+	opts    []oas3.ValidationOption
+	found   []Diagnostic
+}
+
+// diagnose validates spec.DocV3 and records the results in spec.Diagnostics.
+//
+// Each part of the document (info, servers, every operation and component) is validated on
+// its own, so that one broken operation does not hide the others and every problem gets a
+// precise location. A final whole-document pass catches cross-cutting problems such as
+// duplicate operation IDs. Examples that do not match their schema are reported as warnings.
+//
+// For Swagger 2.0 specs the upgraded document is validated and locations are mapped back to
+// the 2.0 layout. OpenAPI 3.1 documents also get their webhooks validated and the fields 3.1
+// adds are accepted, but kin-openapi still validates schemas by 3.0 rules, so errors of the
+// rules 3.1 relaxed (see oas30RulePattern) are reported as warnings; structural errors such as
+// a missing info object stay errors.
+func diagnose(spec *SwaggerSpec) {
+	d := newDiagnoser(spec)
+	doc := spec.DocV3
+	if doc == nil {
+		if spec.DocV2 != nil {
+			d.add(SeverityWarning, "",
+				"The Swagger 2.0 document could not be upgraded to OpenAPI 3, so it was not validated")
+		}
+		spec.Diagnostics = d.found
+		return
+	}
+
+	if doc.Info != nil {
+		d.check("/info", doc.Info)
+	} else {
+		d.add(SeverityError, "", "invalid info: must be an object")
+	}
+	for i, server := range doc.Servers {
+		d.check("/servers/"+strconv.Itoa(i), server)
+	}
+	if doc.Paths != nil {
+		paths := doc.Paths.Map()
+		for _, p := range sortedKeys(paths) {
+			item := paths[p]
+			base := "/paths/" + escapePointer(p)
+			for i, param := range item.Parameters {
+				d.check(base+"/parameters/"+strconv.Itoa(i), param)
+			}
+			ops := item.Operations()
+			for _, method := range sortedKeys(ops) {
+				d.check(base+"/"+strings.ToLower(method), ops[method])
+			}
+		}
+	}
+	for _, name := range sortedKeys(spec.WebhooksV3) {
+		if item := spec.WebhooksV3[name]; item != nil {
+			ops := item.Operations()
+			for _, method := range sortedKeys(ops) {
+				d.check("/webhooks/"+escapePointer(name)+"/"+strings.ToLower(method), ops[method])
+			}
+		}
+	}
+	if msg := dialectWarning(spec.jsonSchemaDialect()); msg != "" {
+		d.add(SeverityWarning, "/jsonSchemaDialect", msg)
+	}
+	if c := doc.Components; c != nil {
+		checkAll(d, "/components/schemas", c.Schemas)
+		checkAll(d, "/components/parameters", c.Parameters)
+		checkAll(d, "/components/headers", c.Headers)
+		checkAll(d, "/components/requestBodies", c.RequestBodies)
+		checkAll(d, "/components/responses", c.Responses)
+		checkAll(d, "/components/securitySchemes", c.SecuritySchemes)
+		checkAll(d, "/components/examples", c.Examples)
+		checkAll(d, "/components/links", c.Links)
+		checkAll(d, "/components/callbacks", c.Callbacks)
+	}
+
+	// Section-wide and whole-document passes; each stops at its first problem, so only report new ones.
+	ctx, noExamples := context.Background(), append(d.opts, oas3.DisableExamplesValidation())
+	if doc.Paths != nil {
+		if err := doc.Paths.Validate(ctx, noExamples...); err != nil && !d.reported(err) {
+			msg := "invalid paths: " + err.Error()
+			d.add(SeverityError, documentPointer(msg), msg)
+		}
+	}
+	if err := doc.Validate(ctx, noExamples...); err != nil && !d.reported(err) {
+		d.add(SeverityError, documentPointer(err.Error()), err.Error())
+	}
+
+	spec.Diagnostics = d.found
+}
+
+// newDiagnoser prepares the validation of spec.
+func newDiagnoser(spec *SwaggerSpec) *diagnoser {
+	d := &diagnoser{spec: spec, root: parseNodes(spec.Raw), lenient: isOAS31(spec.OpenAPIVersion)}
+	if d.lenient {
+		d.opts = []oas3.ValidationOption{oas3.AllowExtraSiblingFields(oas31Fields()...)}
+	}
+	return d
+}
+
+// addDiagnostic records one more diagnostic for spec, after diagnose has run.
+func addDiagnostic(spec *SwaggerSpec, severity Severity, pointer, message string) {
+	d := newDiagnoser(spec)
+	d.found = spec.Diagnostics
+	d.add(severity, pointer, message)
+	spec.Diagnostics = d.found
+}
+
+// diagnoseLoadFailure records why a file that looks like a spec could not be loaded at all.
+func diagnoseLoadFailure(spec *SwaggerSpec, err error) {
+	d := &diagnoser{spec: spec, root: parseNodes(spec.Raw)}
+	d.add(SeverityError, "", err.Error())
+	if m := yamlErrorLinePattern.FindStringSubmatch(err.Error()); m != nil {
+		// Syntax errors carry their own position, which beats the document root.
+		d.found[0].Line, _ = strconv.Atoi(m[1])
+		d.found[0].Column = 0
+	}
+	spec.Diagnostics = d.found
+}
+
+// check validates one part of the document, found at pointer in the OpenAPI 3 layout.
+func (d *diagnoser) check(pointer string, part validator) {
+	ctx := context.Background()
+	if err := part.Validate(ctx, append(d.opts, oas3.DisableExamplesValidation())...); err != nil {
+		d.add(SeverityError, pointer, err.Error())
+	} else if err := part.Validate(ctx, append(d.opts, oas3.EnableExamplesValidation())...); err != nil {
+		d.add(SeverityWarning, pointer, err.Error())
+	}
+}
+
+// checkAll validates every entry of a components map, in name order.
+func checkAll[V validator](d *diagnoser, base string, parts map[string]V) {
+	for _, name := range sortedKeys(parts) {
+		d.check(base+"/"+escapePointer(name), parts[name])
+	}
+}
+
+// add records a diagnostic. pointer uses the OpenAPI 3 layout and is translated for 2.0 specs.
+func (d *diagnoser) add(severity Severity, pointer, message string) {
+	if d.spec.Upgraded() {
+		pointer = swagger2Pointer(pointer)
+	}
+	if severity == SeverityError && d.lenient && oas30RulePattern.MatchString(message) {
+		severity = SeverityWarning
+	}
+	line, column := locate(d.root, pointer)
+	d.found = append(d.found, Diagnostic{
+		Severity: severity,
+		Message:  message,
+		Pointer:  pointer,
+		Line:     line,
+		Column:   column,
+	})
+}
+
+// Locator returns a function that translates a pointer into DocV3 to the layout of the spec's
+// own file (as Diagnostic.Pointer is) and finds the line and column it refers to there, for
+// tools that report problems of their own.
+func (s SwaggerSpec) Locator() func(pointer string) (string, int, int) {
+	root := parseNodes(s.Raw)
+	upgraded := s.Upgraded()
+	return func(pointer string) (string, int, int) {
+		if upgraded {
+			pointer = swagger2Pointer(pointer)
+		}
+		line, column := locate(root, pointer)
+		return pointer, line, column
+	}
+}
+
+// reported reports whether err repeats a problem already recorded by a per-part check.
+func (d *diagnoser) reported(err error) bool {
+	msg := err.Error()
+	for _, found := range d.found {
+		if strings.HasSuffix(msg, found.Message) {
+			return true
+		}
+	}
+	return false
+}
+
+// documentPointer guesses the location of a whole-document validation error from its prefix.
+func documentPointer(msg string) string {
+	if m := invalidOperationPattern.FindStringSubmatch(msg); m != nil {
+		return "/paths/" + escapePointer(m[2]) + "/" + strings.ToLower(m[1])
+	}
+	if m := invalidPathPattern.FindStringSubmatch(msg); m != nil {
+		return "/paths/" + escapePointer(m[1])
+	}
+	for prefix, pointer := range documentSections() {
+		if strings.HasPrefix(msg, prefix+":") {
+			return pointer
+		}
+	}
+	return ""
+}
+
+// swagger2Pointer translates a pointer into an upgraded document to the Swagger 2.0 layout.
+func swagger2Pointer(pointer string) string {
+	for from, to := range map[string]string{
+		"/components/schemas/":         "/definitions/",
+		"/components/parameters/":      "/parameters/",
+		"/components/responses/":       "/responses/",
+		"/components/securitySchemes/": "/securityDefinitions/",
+	} {
+		if strings.HasPrefix(pointer, from) {
+			return to + strings.TrimPrefix(pointer, from)
+		}
+	}
+	if strings.HasPrefix(pointer, "/servers") {
+		return "/host"
+	}
+	return pointer
+}
+
+// parseNodes parses a YAML or JSON document into a node tree that keeps source positions.
+func parseNodes(data []byte) *yamlv3.Node {
+	var root yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &root); err != nil || len(root.Content) == 0 {
+		return nil
+	}
+	return root.Content[0]
+}
+
+// locate returns the line and column of the node at pointer, or of its deepest existing
+// ancestor if the pointer does not resolve completely. It returns 0, 0 if root is nil.
+func locate(root *yamlv3.Node, pointer string) (int, int) {
+	if root == nil {
+		return 0, 0
+	}
+	line, column := root.Line, root.Column
+	node := root
+	for _, token := range pointerTokens(pointer) {
+		next, key := child(node, token)
+		if next == nil {
+			break
+		}
+		node = next
+		if key != nil {
+			line, column = key.Line, key.Column
+		} else {
+			line, column = next.Line, next.Column
+		}
+	}
+	return line, column
+}
+
+// child returns the value under token in a mapping or sequence node, plus the key node for mappings.
+func child(node *yamlv3.Node, token string) (*yamlv3.Node, *yamlv3.Node) {
+	switch node.Kind {
+	case yamlv3.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == token {
+				return node.Content[i+1], node.Content[i]
+			}
+		}
+	case yamlv3.SequenceNode:
+		if i, err := strconv.Atoi(token); err == nil && i >= 0 && i < len(node.Content) {
+			return node.Content[i], nil
+		}
+	case yamlv3.DocumentNode, yamlv3.ScalarNode, yamlv3.AliasNode:
+	}
+	return nil, nil
+}
+
+// pointerTokens splits a JSON pointer into its unescaped reference tokens.
+func pointerTokens(pointer string) []string {
+	if pointer == "" || pointer == "/" {
+		return nil
+	}
+	tokens := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens
+}
+
+// escapePointer escapes a single JSON pointer reference token.
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,78 @@
+package discovery_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Hossein-Roshandel/webswags/discovery"
+)
+
+func TestDiagnostics(t *testing.T) {
+	t.Parallel()
+	const head = "openapi: 3.0.3\ninfo: {title: Pets, version: \"1\"}\n"
+	tests := []struct {
+		name       string
+		data       string
+		wantHealth string
+		want       discovery.Diagnostic // Message is matched as a substring
+	}{
+		{name: "valid", data: head + "paths: {}\n", wantHealth: discovery.HealthValid},
+		{
+			name:       "response without description",
+			data:       head + "paths:\n  /pets:\n    get:\n      responses:\n        \"200\": {}\n",
+			wantHealth: discovery.HealthErrors,
+			want: discovery.Diagnostic{
+				Severity: discovery.SeverityError, Pointer: "/paths/~1pets/get", Line: 5, Column: 5,
+				Message: "description of the response is required",
+			},
+		},
+		{
+			name: "example not matching its schema",
+			data: head + "paths: {}\n" +
+				"components:\n  schemas:\n    Pet:\n      type: integer\n      example: abc\n",
+			wantHealth: discovery.HealthWarnings,
+			want: discovery.Diagnostic{
+				Severity: discovery.SeverityWarning, Pointer: "/components/schemas/Pet", Line: 6, Column: 5,
+				Message: "invalid example",
+			},
+		},
+		{
+			name:       "missing version",
+			data:       "openapi: 3.0.3\ninfo: {title: Pets}\npaths: {}\n",
+			wantHealth: discovery.HealthErrors,
+			want: discovery.Diagnostic{
+				Severity: discovery.SeverityError, Pointer: "/info", Line: 2, Column: 1,
+				Message: "version must be a non-empty string",
+			},
+		},
+		{
+			name:       "syntax error",
+			data:       head + "paths:\n  /pets: [\n",
+			wantHealth: discovery.HealthErrors,
+			want:       discovery.Diagnostic{Severity: discovery.SeverityError, Line: 4, Message: "failed to load"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			spec := parse(t, tt.data)
+			if got := spec.Health(); got != tt.wantHealth {
+				t.Errorf("Health = %s, want %s (diagnostics %+v)", got, tt.wantHealth, spec.Diagnostics)
+			}
+			if tt.want.Message == "" {
+				if len(spec.Diagnostics) != 0 {
+					t.Errorf("diagnostics = %+v, want none", spec.Diagnostics)
+				}
+				return
+			}
+			if len(spec.Diagnostics) != 1 {
+				t.Fatalf("diagnostics = %+v, want one", spec.Diagnostics)
+			}
+			got := spec.Diagnostics[0]
+			if got.Severity != tt.want.Severity || got.Pointer != tt.want.Pointer || got.Line != tt.want.Line ||
+				got.Column != tt.want.Column || !strings.Contains(got.Message, tt.want.Message) {
+				t.Errorf("diagnostic = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
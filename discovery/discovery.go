@@ -1,13 +1,20 @@
 package discovery
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"log/slog"
-	"os"
+	"net/http"
+	"net/url"
 	"path/filepath"
+	"regexp"
+	"runtime"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	oas3 "github.com/getkin/kin-openapi/openapi3"
 	oas2 "github.com/go-openapi/spec"
@@ -92,19 +99,132 @@ type SwaggerSpec struct {
 	Description string `json:"description" yaml:"description"`
 	Path        string `json:"path"        yaml:"path"`
 	Service     string `json:"service"     yaml:"service"`
-	Format      string `json:"format"      yaml:"format"` // "yaml" or "json"
+	Slug        string `json:"slug"        yaml:"slug"`        // URL-safe, unique identifier used in routes
+	ServiceSlug string `json:"serviceSlug" yaml:"serviceSlug"` // slug of the Service this spec is a version of
+	VersionKey  string `json:"versionKey"  yaml:"versionKey"`  // identifies this spec among its service's versions
+	Format      string `json:"format"      yaml:"format"`      // "yaml" or "json"
 	FileName    string `json:"fileName"    yaml:"fileName"`
+	// Source the spec was found in, e.g. "dir:apis".
+	Origin string `json:"origin,omitempty" yaml:"origin,omitempty"`
+	// Commit of a spec loaded from history (see AtRevision).
+	Revision *Revision `json:"revision,omitempty" yaml:"revision,omitempty"`
+
+	// --- Variants (the same document committed as both YAML and JSON) ---
+	ContentHash string        `json:"contentHash"     yaml:"contentHash"`     // SHA-256 of the normalised document
+	Formats     []string      `json:"formats"         yaml:"formats"`         // every format available on disk
+	Variants    []SpecVariant `json:"variants"        yaml:"variants"`        // every file holding this document
+	Drift       bool          `json:"drift,omitempty" yaml:"drift,omitempty"` // variants exist but differ
+
+	// --- Multi-file specs (external $refs) ---
+	// Files pulled in via external $ref.
+	Dependencies []string `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
+	// OpenAPI 3 document with external refs inlined (JSON).
+	Bundled []byte `json:"-" yaml:"-"`
+
+	// --- Validation ---
+	// Load and validation problems, see Health.
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty" yaml:"diagnostics,omitempty"`
+	// Documentation coverage of the operations, nil if not loaded.
+	Quality *Quality `json:"quality,omitempty" yaml:"quality,omitempty"`
+
+	// --- Operation index (nil if the spec could not be loaded) ---
+	// Counts of paths, operations, schemas and webhooks.
+	Stats *Stats `json:"stats,omitempty" yaml:"stats,omitempty"`
+	// Every operation of the paths, by path and method.
+	Operations []Operation `json:"operations,omitempty" yaml:"operations,omitempty"`
 
 	// --- Version markers (redundant but handy for quick checks) ---
 	OpenAPIVersion string `json:"openapiVersion,omitempty" yaml:"openapiVersion,omitempty"` // e.g., "3.1.0"
 	SwaggerVersion string `json:"swaggerVersion,omitempty" yaml:"swaggerVersion,omitempty"` // e.g., "2.0"
 
 	// --- Canonical, full documents (for complete fidelity & downstream logic) ---
-	DocV3 *oas3.T       `json:"-" yaml:"-"` // full OpenAPI 3.x/3.1 document
-	DocV2 *oas2.Swagger `json:"-" yaml:"-"` // full Swagger 2.0 document
+	// Full OpenAPI 3.x/3.1 document, or the 3.0 upgrade of a 2.0 one.
+	DocV3      *oas3.T                   `json:"-" yaml:"-"`
+	DocV2      *oas2.Swagger             `json:"-" yaml:"-"` // full Swagger 2.0 document
+	WebhooksV3 map[string]*oas3.PathItem `json:"-" yaml:"-"` // OpenAPI 3.1 webhooks, which oas3.T does not model
 
 	// (Optional) Keep raw bytes if you need to re-serve the original file as-is.
 	Raw []byte `json:"-" yaml:"-"`
+
+	// Where the spec's files are read from (see ReadFile).
+	fsys       fs.FS
+	sourceRoot string // directory of fsys the spec was discovered below
+}
+
+const (
+	jsonFormat = "json"
+	yamlFormat = "yaml"
+)
+
+// DefaultMaxFileSize is the largest file Discover will attempt to parse unless overridden.
+const DefaultMaxFileSize int64 = 10 << 20 // 10 MiB
+
+// DiscoverOptions tunes how Discover walks the tree and parses candidate files.
+// The zero value is ready to use.
+type DiscoverOptions struct {
+	// Workers is the number of files parsed concurrently. Zero means runtime.GOMAXPROCS(0).
+	Workers int
+	// MaxFileSize skips files larger than this many bytes.
+	// Zero means DefaultMaxFileSize; a negative value disables the limit.
+	MaxFileSize int64
+	// MaxDepth limits how many directory levels below the root are descended.
+	// Files directly in the root are at depth 0. Zero means unlimited.
+	MaxDepth int
+	// FollowSymlinks makes the walk parse symlinked files and descend into symlinked directories.
+	// Symlink cycles are detected and walked only once.
+	FollowSymlinks bool
+	// Include, when non-empty, restricts discovery to files matching at least one glob.
+	// Globs without a slash match the file name; others match the root-relative path ("**" spans directories).
+	Include []string
+	// Exclude skips files and whole directories matching any of these .gitignore-style patterns.
+	// They take precedence over the ignore files.
+	Exclude []string
+	// DisableIgnoreFiles turns off .gitignore and .webswagsignore handling. ".git" is always skipped.
+	DisableIgnoreFiles bool
+}
+
+// FileTiming records how long a single candidate file took to read and parse.
+type FileTiming struct {
+	Path     string        `json:"path"`
+	Size     int64         `json:"size"`
+	Duration time.Duration `json:"duration"`
+	Accepted bool          `json:"accepted"`
+}
+
+// DiscoverResult is the outcome of a Discover run.
+type DiscoverResult struct {
+	// Specs holds every parsed spec, sorted by service name and path.
+	Specs []SwaggerSpec `json:"specs"`
+	// Services groups Specs by API, each with its versions newest first.
+	Services []Service `json:"services"`
+	// Timings holds one entry per candidate file, sorted by path.
+	Timings []FileTiming `json:"timings"`
+	// Report explains what happened to every candidate file and skipped directory.
+	Report DiscoveryReport `json:"report"`
+	// Elapsed is the wall-clock time of the whole run.
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+func (o DiscoverOptions) workers() int {
+	if o.Workers > 0 {
+		return o.Workers
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+func (o DiscoverOptions) withinSize(size int64) bool {
+	switch {
+	case o.MaxFileSize < 0:
+		return true
+	case o.MaxFileSize == 0:
+		return size <= DefaultMaxFileSize
+	default:
+		return size <= o.MaxFileSize
+	}
+}
+
+func (o DiscoverOptions) withinDepth(depth int) bool {
+	return o.MaxDepth <= 0 || depth <= o.MaxDepth
 }
 
 // DiscoverSwaggerSpecs scans within the given project root recursively
@@ -125,73 +245,231 @@ type SwaggerSpec struct {
 //
 // Returns:
 //   - A sorted slice of SwaggerSpec objects by service name.
+//   - A report of every candidate file: accepted, ignored or rejected, and why.
 //   - An error if the directory walk fails.
-func DiscoverSwaggerSpecs(projectRoot string) ([]SwaggerSpec, error) {
-	var specs []SwaggerSpec
-
-	err := filepath.Walk(projectRoot, func(path string, info os.FileInfo, walkErr error) error {
-		if walkErr != nil {
-			// Skip this file or directory if there's an error accessing it
-			slog.Debug("Skipping path due to access error", "path", path, "error", walkErr)
-			return nil // Continue walking despite access errors
-		}
+//
+// It is a convenience wrapper around Discover with default options.
+func DiscoverSwaggerSpecs(projectRoot string) ([]SwaggerSpec, DiscoveryReport, error) {
+	result, err := Discover(context.Background(), projectRoot, DiscoverOptions{})
+	if err != nil {
+		return nil, DiscoveryReport{}, err
+	}
+	return result.Specs, result.Report, nil
+}
 
-		// Skip directories
-		if info.IsDir() {
-			return nil
-		}
+// Discover walks root and parses every candidate file with a bounded pool of workers.
+//
+// The walk honours opts (depth, file size, symlinks) and stops early when ctx is cancelled,
+// in which case ctx.Err() is returned. The result is deterministic regardless of the
+// number of workers: specs are sorted by service name and path, timings by path.
+func Discover(ctx context.Context, root string, opts DiscoverOptions) (DiscoverResult, error) {
+	return discover(ctx, osFS{}, root, DirSource{Path: root}.Origin(), opts)
+}
 
-		// Check if file has a valid OpenAPI/Swagger extension
-		ext := strings.ToLower(filepath.Ext(path))
-		if ext != ".yaml" && ext != ".yml" && ext != ".json" {
-			return nil
-		}
+// DiscoverFS is Discover for any file system, such as an embed.FS, an fstest.MapFS or a
+// zip.Reader. It walks root, a slash-separated path within fsys ("." for all of it), and
+// the specs it finds carry slash-separated paths relative to the root of fsys. External
+// $refs are resolved within fsys, except remote ones ("https://..."), which are fetched.
+func DiscoverFS(ctx context.Context, fsys fs.FS, root string, opts DiscoverOptions) (DiscoverResult, error) {
+	if root == "" {
+		root = "."
+	}
+	if !fs.ValidPath(root) {
+		return DiscoverResult{}, &fs.PathError{Op: "discover", Path: root, Err: fs.ErrInvalid}
+	}
+	return discover(ctx, slashFS{fsys: fsys}, filepath.FromSlash(root), "fs:"+root, opts)
+}
 
-		// Try to parse as an OpenAPI/Swagger spec
-		spec, err := parseSwaggerSpec(path)
-		if err != nil {
-			// Only log at debug level since many YAML/JSON files won't be OpenAPI specs
-			slog.Debug("Skipping file (not a valid OpenAPI spec)", "path", path, "error", err)
-			return nil // Continue processing other files
-		}
+// discover implements Discover and DiscoverFS. origin tags the specs found (see Source).
+func discover(ctx context.Context, fsys fs.FS, root, origin string, opts DiscoverOptions) (DiscoverResult, error) {
+	start := time.Now()
 
-		specs = append(specs, spec)
-		slog.Info("Discovered OpenAPI spec", "service", spec.Service, "version", spec.Version, "path", path)
-		return nil
-	})
+	found, err := discoverFiles(ctx, fsys, root, opts)
 	if err != nil {
-		return nil, fmt.Errorf("error walking directory: %w", err)
+		return DiscoverResult{}, err
+	}
+	found.tag(origin, root)
+
+	result := DiscoverResult{Timings: found.timings}
+	result.Specs, result.Services = buildCatalog(found.files)
+	result.Report = buildReport(root, found.entries, result.Specs)
+	result.Elapsed = time.Since(start)
+
+	return result, nil
+}
+
+// scan is the raw outcome of walking and parsing a tree, before the catalog is built.
+type scan struct {
+	files   []SwaggerSpec          // one per accepted file, variants not yet merged
+	timings []FileTiming           // one per candidate, sorted by path
+	entries map[string]ReportEntry // one per candidate and skipped path, keyed by path
+}
+
+// tag records on every spec and report entry the origin and root of the source it was found in.
+func (s *scan) tag(origin, root string) {
+	for i := range s.files {
+		s.files[i].Origin = origin
+		s.files[i].sourceRoot = root
+	}
+	for key, entry := range s.entries {
+		entry.Origin = origin
+		s.entries[key] = entry
+	}
+}
+
+// discoverFiles walks root in fsys and parses every candidate file with a bounded pool of workers.
+func discoverFiles(ctx context.Context, fsys fs.FS, root string, opts DiscoverOptions) (scan, error) {
+	candidates := make(chan candidate)
+	w := newWalker(fsys, root, opts)
+	var walkErr error
+	go func() {
+		defer close(candidates)
+		walkErr = w.walk(ctx, candidates)
+	}()
+
+	var (
+		mu      sync.Mutex
+		found   = scan{entries: make(map[string]ReportEntry)}
+		workers sync.WaitGroup
+	)
+	for range opts.workers() {
+		workers.Go(func() {
+			for c := range candidates {
+				if ctx.Err() != nil {
+					continue // drain so the walker can exit
+				}
+
+				parseStart := time.Now()
+				spec, err := parseSwaggerSpec(fsys, c.path)
+				timing := FileTiming{Path: c.path, Size: c.size, Duration: time.Since(parseStart), Accepted: err == nil}
+
+				if err != nil {
+					// Only log at debug level since many YAML/JSON files won't be OpenAPI specs
+					slog.Debug("Skipping file (not a valid OpenAPI spec)", "path", c.path, "error", err)
+				} else {
+					slog.Info("Discovered OpenAPI spec",
+						"service", spec.Service, "version", spec.Version, "path", c.path)
+					logDiagnostics(spec)
+				}
+
+				mu.Lock()
+				found.timings = append(found.timings, timing)
+				found.entries[c.path] = parsedEntry(c, timing.Duration, err)
+				if err == nil {
+					found.files = append(found.files, spec)
+				}
+				mu.Unlock()
+			}
+		})
+	}
+	workers.Wait()
+
+	if walkErr != nil {
+		if ctx.Err() != nil {
+			return scan{}, ctx.Err()
+		}
+		return scan{}, fmt.Errorf("error walking directory: %w", walkErr)
+	}
+	if err := ctx.Err(); err != nil {
+		return scan{}, err
 	}
 
+	for _, entry := range w.skipped {
+		found.entries[entry.Path] = entry
+	}
+	sortTimings(found.timings)
+	return found, nil
+}
+
+// buildCatalog turns per-file specs into the logical catalog: YAML/JSON variants merged,
+// sorted by service name, slugged and grouped into services.
+func buildCatalog(files []SwaggerSpec) ([]SwaggerSpec, []Service) {
+	specs := mergeVariants(files)
+
 	// Sort specs alphabetically by service name
+	sortSpecs(specs)
+	assignSlugs(specs)
+	services := groupServices(specs)
+
+	return specs, services
+}
+
+// sortSpecs orders specs alphabetically by service name, using the file path and origin as
+// tie-breakers so that the order is stable across runs.
+func sortSpecs(specs []SwaggerSpec) {
 	sort.Slice(specs, func(i, j int) bool {
-		return specs[i].Service < specs[j].Service
+		if specs[i].Service != specs[j].Service {
+			return specs[i].Service < specs[j].Service
+		}
+		if specs[i].Path != specs[j].Path {
+			return specs[i].Path < specs[j].Path
+		}
+		return specs[i].Origin < specs[j].Origin
 	})
+}
 
-	return specs, nil
+// logDiagnostics warns about a spec that has validation errors, so broken specs stand out in the log.
+func logDiagnostics(spec SwaggerSpec) {
+	if first := firstError(spec); first != "" {
+		slog.Warn("OpenAPI spec has errors", "path", spec.Path, "errors", spec.ErrorCount(), "first", first)
+	}
 }
 
-// parseSwaggerSpec parses a YAML or JSON file and extracts OpenAPI/Swagger information.
+// ParseFile parses a single OpenAPI/Swagger file, resolving its external refs against its location.
+func ParseFile(path string) (SwaggerSpec, error) {
+	return parseSwaggerSpec(osFS{}, path)
+}
+
+// ParseFS is ParseFile for the file name (slash-separated) in fsys; see DiscoverFS.
+func ParseFS(fsys fs.FS, name string) (SwaggerSpec, error) {
+	return parseSwaggerSpec(slashFS{fsys: fsys}, filepath.FromSlash(name))
+}
+
+// parseSwaggerSpec parses a YAML or JSON file of fsys and extracts OpenAPI/Swagger information.
 // It tries OpenAPI 3.x/3.1 first (kin-openapi), then falls back to Swagger 2.0 (go-openapi/spec).
-func parseSwaggerSpec(path string) (SwaggerSpec, error) {
+func parseSwaggerSpec(fsys fs.FS, path string) (SwaggerSpec, error) {
 	// Read the file
-	data, err := os.ReadFile(path)
+	data, err := fs.ReadFile(fsys, path)
 	if err != nil {
 		return SwaggerSpec{}, fmt.Errorf("failed to read file: %w", err)
 	}
 
+	// Cheap pre-check so CI configs, fixtures and package manifests never reach the full loaders.
+	if !declaresSpecVersion(data) {
+		return SwaggerSpec{}, fmt.Errorf("file %q has no top-level openapi or swagger field", path)
+	}
+
 	spec := SwaggerSpec{
-		Path:     path,
-		FileName: filepath.Base(path),
-		Format:   detectFormatFromExtOrContent(path, data),
-		Raw:      data,
+		fsys:        fsys,
+		Path:        path,
+		FileName:    filepath.Base(path),
+		Format:      detectFormatFromExtOrContent(path, data),
+		ContentHash: contentHash(data),
+		Raw:         data,
+	}
+
+	spec.Dependencies = externalRefs(fsys, path, data)
+	files := filesFS{fsys: fsys, files: map[string]bool{filepath.Clean(path): true}}
+	for _, dep := range spec.Dependencies {
+		files.files[dep] = true
 	}
 
 	// --- Try OpenAPI 3.x/3.1 first using kin-openapi ---
-	loader := &oas3.Loader{IsExternalRefsAllowed: true}
-	if doc3, err3 := loader.LoadFromData(data); err3 == nil && doc3 != nil && strings.TrimSpace(doc3.OpenAPI) != "" {
+	// Loading from the file's location lets relative external refs resolve against its directory.
+	// kin-openapi models OpenAPI 3.0, so 3.1 documents are normalised before loading.
+	loader, data3 := newLoader(files), data
+	if isOAS31(declaredOpenAPIVersion(data)) {
+		if normalized, normErr := normalizeOAS31(data); normErr == nil {
+			loader, data3 = newOAS31Loader(files), normalized
+		}
+	}
+	doc3, err3 := loader.LoadFromDataWithPath(data3, fileLocation(path))
+	if err3 == nil && doc3 != nil && strings.TrimSpace(doc3.OpenAPI) != "" {
 		spec.DocV3 = doc3
 		spec.OpenAPIVersion = strings.TrimSpace(doc3.OpenAPI)
+		if len(spec.Dependencies) > 0 {
+			spec.Bundled = bundleOAS3(doc3, path)
+		}
 
 		// Fill version-specific view (inlined when marshalled)
 		spec.OpenAPI3Doc = &OpenAPI3Doc{
@@ -204,6 +482,10 @@ func parseSwaggerSpec(path string) (SwaggerSpec, error) {
 			Tags:         toRaw(doc3.Tags),
 			ExternalDocs: toRaw(doc3.ExternalDocs),
 		}
+		var hooksErr error
+		if isOAS31(spec.OpenAPIVersion) {
+			hooksErr = loadOAS31Fields(&spec, loader, fileLocation(path))
+		}
 
 		// Fill metadata summary (version-agnostic)
 		spec.Title = spec.OpenAPI3Doc.Info.Title
@@ -212,6 +494,12 @@ func parseSwaggerSpec(path string) (SwaggerSpec, error) {
 		spec.Name = deriveName(spec.Title, path)
 		spec.Service = deriveName(spec.Title, path)
 
+		diagnose(&spec)
+		spec.Quality = measureQuality(spec.DocV3)
+		spec.Operations, spec.Stats = indexOperations(spec.DocV3, spec.WebhooksV3)
+		if hooksErr != nil {
+			addDiagnostic(&spec, SeverityError, "/webhooks", hooksErr.Error())
+		}
 		return spec, nil
 	}
 
@@ -239,37 +527,144 @@ func parseSwaggerSpec(path string) (SwaggerSpec, error) {
 			ExternalDocs:        toRaw(doc2.ExternalDocs),
 		}
 
+		// Downstream consumers only need to understand OpenAPI 3, so 2.0 specs get an upgraded DocV3 too.
+		if upgraded, upErr := upgradeSwagger2(files, path, data); upErr == nil {
+			spec.DocV3 = upgraded
+		} else {
+			slog.Debug("Failed to upgrade Swagger 2.0 spec", "path", path, "error", upErr)
+		}
+
 		spec.Title = spec.Swagger2Doc.Info.Title
 		spec.Version = spec.Swagger2Doc.Info.Version
 		spec.Description = spec.Swagger2Doc.Info.Description
 		spec.Name = deriveName(spec.Title, path)
 		spec.Service = deriveName(spec.Title, path)
 
+		diagnose(&spec)
+		spec.Quality = measureQuality(spec.DocV3)
+		spec.Operations, spec.Stats = indexOperations(spec.DocV3, spec.WebhooksV3)
 		return spec, nil
 	}
 
-	return SwaggerSpec{}, fmt.Errorf("file %q is not a valid OpenAPI 3.x/3.1 or Swagger 2.0 document", path)
+	// It declares a spec version but neither loader accepts it: keep it, so it shows up as broken
+	// instead of silently disappearing from the portal.
+	loadErr := fmt.Errorf("file %q is not a valid OpenAPI 3.x/3.1 or Swagger 2.0 document", path)
+	if err3 != nil && len(spec.Dependencies) > 0 {
+		loadErr = fmt.Errorf("failed to resolve external refs of %q: %w", path, err3)
+	} else if err3 != nil {
+		loadErr = fmt.Errorf("failed to load %q: %w", path, err3)
+	}
+	return invalidSpec(spec, loadErr), nil
+}
+
+// newLoader returns a kin-openapi loader that resolves external refs in fsys.
+// Each loader gets its own URI cache: kin-openapi's default one is global and would keep
+// serving stale copies of referenced files after they change.
+func newLoader(fsys fs.FS) *oas3.Loader {
+	return &oas3.Loader{
+		IsExternalRefsAllowed: true,
+		ReadFromURIFunc:       oas3.URIMapCache(readFromURIs(fsys)),
+	}
+}
+
+// newOAS31Loader is newLoader for OpenAPI 3.1 specs: referenced files are normalised like the
+// spec itself (see normalizeOAS31).
+func newOAS31Loader(fsys fs.FS) *oas3.Loader {
+	return &oas3.Loader{
+		IsExternalRefsAllowed: true,
+		ReadFromURIFunc:       oas3.URIMapCache(normalizingReader(readFromURIs(fsys))),
+	}
+}
+
+// refFetchTimeout bounds the download of a document referenced over HTTP, so an unreachable
+// ref cannot stall discovery and every reload after it.
+const refFetchTimeout = 30 * time.Second
+
+// readFromURIs reads referenced documents from fsys or over HTTP.
+func readFromURIs(fsys fs.FS) oas3.ReadFromURIFunc {
+	client := &http.Client{Timeout: refFetchTimeout}
+	return oas3.ReadFromURIs(oas3.ReadFromHTTP(client), readFromFS(fsys))
+}
+
+// fileLocation returns the URL kin-openapi resolves relative refs of the file at path against.
+func fileLocation(path string) *url.URL {
+	return &url.URL{Path: filepath.ToSlash(path)}
+}
+
+// invalidSpec fills in what can still be read from a spec that failed to load, with loadErr
+// as its only diagnostic. Its typed documents (DocV2/DocV3) are nil.
+func invalidSpec(spec SwaggerSpec, loadErr error) SwaggerSpec {
+	var head struct {
+		OpenAPI string `json:"openapi"`
+		Swagger string `json:"swagger"`
+		Info    Info   `json:"info"`
+	}
+	_ = unmarshalYAMLOrJSON(spec.Raw, &head) // best effort: whatever decodes is used
+
+	spec.OpenAPIVersion = strings.TrimSpace(head.OpenAPI)
+	spec.SwaggerVersion = strings.TrimSpace(head.Swagger)
+	spec.Title = head.Info.Title
+	spec.Version = head.Info.Version
+	spec.Description = head.Info.Description
+	spec.Name = deriveName(spec.Title, spec.Path)
+	spec.Service = deriveName(spec.Title, spec.Path)
+	diagnoseLoadFailure(&spec, loadErr)
+	return spec
+}
+
+// bundleOAS3 moves every externally referenced component of doc into its components section
+// and returns the resulting self-contained document as JSON, or nil if it cannot be encoded.
+// doc is modified in place, so later consumers of DocV3 also see a single document.
+func bundleOAS3(doc *oas3.T, path string) []byte {
+	doc.InternalizeRefs(context.Background(), componentNamer(path))
+	bundled, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		slog.Debug("Failed to bundle spec", "path", path, "error", err)
+		return nil
+	}
+	return bundled
 }
 
 // --- Helpers ---
 
+// isCandidateFile reports whether path has an extension that may hold an OpenAPI/Swagger spec.
+func isCandidateFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	default:
+		return false
+	}
+}
+
 // detectFormatFromExtOrContent determines the file format (yaml or json) based on file extension
 // or by examining the content if the extension is ambiguous.
 func detectFormatFromExtOrContent(path string, data []byte) string {
 	ext := strings.ToLower(filepath.Ext(path))
 	switch ext {
 	case ".json":
-		return "json"
+		return jsonFormat
 	case ".yaml", ".yml":
-		return "yaml"
+		return yamlFormat
 	default:
 		if looksLikeJSON(data) {
-			return "json"
+			return jsonFormat
 		}
-		return "yaml"
+		return yamlFormat
 	}
 }
 
+// specVersionPattern matches a top-level "openapi:"/"swagger:" key in YAML or an
+// "openapi"/"swagger" member in JSON.
+var specVersionPattern = regexp.MustCompile(`(?m)(^['"]?(openapi|swagger)['"]?\s*:)|("(openapi|swagger)"\s*:)`)
+
+// declaresSpecVersion reports whether data plausibly contains an OpenAPI or Swagger version field.
+// It may return false positives (the key nested somewhere in JSON) but never false negatives.
+func declaresSpecVersion(data []byte) bool {
+	return specVersionPattern.Match(data)
+}
+
+// looksLikeJSON reports whether data is JSON
 // by examining if it starts with '{' or '[' after trimming whitespace.
 func looksLikeJSON(data []byte) bool {
 	trim := strings.TrimLeftFunc(string(data), func(r rune) bool {
@@ -0,0 +1,123 @@
+package discovery_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Hossein-Roshandel/webswags/discovery"
+)
+
+// optionsTree is a tree for exercising DiscoverOptions, discovered from "apis".
+func optionsTree() fstest.MapFS {
+	big := specFile("Big", "1")
+	big.Data = append(big.Data, strings.Repeat("# padding\n", 100)...)
+	return fstest.MapFS{
+		"apis/pets.yaml":             specFile("Pets", "1"),
+		"apis/big.yaml":              big,
+		"apis/orders/orders.yaml":    specFile("Orders", "1"),
+		"apis/orders/v2/orders.yaml": specFile("Orders", "2"),
+		"apis/package.json":          {Data: []byte(`{"name": "apis"}`)},
+		"apis/shared":                {Data: []byte("../shared"), Mode: fs.ModeSymlink},
+		"shared/store.yaml":          specFile("Store", "1"),
+	}
+}
+
+func TestDiscoverOptions(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		opts discovery.DiscoverOptions
+		want []string
+	}{
+		{
+			name: "defaults",
+			want: []string{"apis/big.yaml", "apis/orders/orders.yaml", "apis/orders/v2/orders.yaml", "apis/pets.yaml"},
+		},
+		{
+			name: "one worker",
+			opts: discovery.DiscoverOptions{Workers: 1},
+			want: []string{"apis/big.yaml", "apis/orders/orders.yaml", "apis/orders/v2/orders.yaml", "apis/pets.yaml"},
+		},
+		{
+			name: "max file size",
+			opts: discovery.DiscoverOptions{MaxFileSize: 200},
+			want: []string{"apis/orders/orders.yaml", "apis/orders/v2/orders.yaml", "apis/pets.yaml"},
+		},
+		{
+			name: "max depth",
+			opts: discovery.DiscoverOptions{MaxDepth: 1},
+			want: []string{"apis/big.yaml", "apis/orders/orders.yaml", "apis/pets.yaml"},
+		},
+		{
+			name: "follow symlinks",
+			opts: discovery.DiscoverOptions{FollowSymlinks: true},
+			want: []string{
+				"apis/big.yaml", "apis/orders/orders.yaml", "apis/orders/v2/orders.yaml", "apis/pets.yaml",
+				"apis/shared/store.yaml",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			result, err := discovery.DiscoverFS(t.Context(), optionsTree(), "apis", tt.opts)
+			if err != nil {
+				t.Fatalf("DiscoverFS: %v", err)
+			}
+			var paths []string
+			for _, spec := range result.Specs {
+				paths = append(paths, spec.Path)
+			}
+			slices.Sort(paths)
+			if !slices.Equal(paths, tt.want) {
+				t.Errorf("specs = %q, want %q", paths, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscoverIsDeterministic(t *testing.T) {
+	t.Parallel()
+	run := func() ([]string, []string) {
+		result, err := discovery.DiscoverFS(t.Context(), optionsTree(), "apis", discovery.DiscoverOptions{Workers: 8})
+		if err != nil {
+			t.Fatalf("DiscoverFS: %v", err)
+		}
+		var specs, timings []string
+		for _, spec := range result.Specs {
+			specs = append(specs, spec.Path)
+		}
+		for _, timing := range result.Timings {
+			timings = append(timings, timing.Path)
+			if accepted := timing.Path != "apis/package.json"; timing.Accepted != accepted {
+				t.Errorf("timing of %s accepted = %t, want %t", timing.Path, timing.Accepted, accepted)
+			}
+		}
+		return specs, timings
+	}
+
+	specs, timings := run()
+	if !slices.IsSorted(timings) || !slices.Contains(timings, "apis/package.json") {
+		t.Errorf("timings = %q, want every candidate sorted by path", timings)
+	}
+	for range 10 {
+		if gotSpecs, gotTimings := run(); !slices.Equal(gotSpecs, specs) || !slices.Equal(gotTimings, timings) {
+			t.Fatalf("second run found %q timed %q, first %q timed %q", gotSpecs, gotTimings, specs, timings)
+		}
+	}
+}
+
+func TestDiscoverCancelled(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	_, err := discovery.DiscoverFS(ctx, optionsTree(), "apis", discovery.DiscoverOptions{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("DiscoverFS = %v, want context.Canceled", err)
+	}
+}
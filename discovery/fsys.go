@@ -0,0 +1,113 @@
+package discovery
+
+import (
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	oas3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+// maxLinkHops bounds how many symlinks evalLinks follows, like the OS's ELOOP limit.
+const maxLinkHops = 40
+
+// osFS reads the operating system's file system using native paths. Unlike os.DirFS it is not
+// confined to a root, so specs can $ref files outside the discovered directory through relative paths.
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error)          { return os.Open(name) }
+func (osFS) Stat(name string) (fs.FileInfo, error)      { return os.Stat(name) }
+func (osFS) Lstat(name string) (fs.FileInfo, error)     { return os.Lstat(name) }
+func (osFS) ReadFile(name string) ([]byte, error)       { return os.ReadFile(name) }
+func (osFS) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(name) }
+func (osFS) ReadLink(name string) (string, error)       { return os.Readlink(name) }
+
+// slashFS adapts an arbitrary fs.FS to the path handling of discovery, which joins and splits
+// paths with path/filepath: names are converted to slashes and cleaned before use.
+type slashFS struct {
+	fsys fs.FS
+}
+
+func (s slashFS) name(p string) string {
+	return path.Clean(filepath.ToSlash(p))
+}
+
+func (s slashFS) Open(name string) (fs.File, error)          { return s.fsys.Open(s.name(name)) }
+func (s slashFS) Stat(name string) (fs.FileInfo, error)      { return fs.Stat(s.fsys, s.name(name)) }
+func (s slashFS) Lstat(name string) (fs.FileInfo, error)     { return fs.Lstat(s.fsys, s.name(name)) }
+func (s slashFS) ReadFile(name string) ([]byte, error)       { return fs.ReadFile(s.fsys, s.name(name)) }
+func (s slashFS) ReadDir(name string) ([]fs.DirEntry, error) { return fs.ReadDir(s.fsys, s.name(name)) }
+func (s slashFS) ReadLink(name string) (string, error)       { return fs.ReadLink(s.fsys, s.name(name)) }
+
+// realPath resolves every symlink in p, for detecting directories reached twice.
+func realPath(fsys fs.FS, p string) (string, error) {
+	if _, ok := fsys.(osFS); ok {
+		return filepath.EvalSymlinks(p)
+	}
+	return evalLinks(fsys, filepath.ToSlash(p))
+}
+
+// evalLinks is filepath.EvalSymlinks for a slash-separated name in fsys. Links pointing outside
+// fsys (absolute, or above its root) cannot be resolved and are reported as errors.
+func evalLinks(fsys fs.FS, name string) (string, error) {
+	resolved, rest := ".", strings.Split(path.Clean(name), "/")
+	for hops := 0; len(rest) > 0; {
+		elem := rest[0]
+		rest = rest[1:]
+		if elem == "." {
+			continue
+		}
+
+		next := path.Join(resolved, elem)
+		info, err := fs.Lstat(fsys, next)
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&fs.ModeSymlink == 0 {
+			resolved = next
+			continue
+		}
+
+		if hops++; hops > maxLinkHops {
+			return "", fmt.Errorf("too many links resolving %q", name)
+		}
+		target, err := fs.ReadLink(fsys, next)
+		if err != nil {
+			return "", err
+		}
+		target = path.Join(resolved, target)
+		if path.IsAbs(target) || !fs.ValidPath(target) {
+			return "", fmt.Errorf("link %q points outside the file system", next)
+		}
+		resolved, rest = ".", append(strings.Split(target, "/"), rest...)
+	}
+	return resolved, nil
+}
+
+// filesFS limits an fs.FS to a set of files, keyed by cleaned native path. Loaders of a spec
+// read through it, so they open only the spec and the files it references (see externalRefs).
+type filesFS struct {
+	fsys  fs.FS
+	files map[string]bool
+}
+
+func (f filesFS) Open(name string) (fs.File, error) {
+	if !f.files[filepath.Clean(name)] {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return f.fsys.Open(name)
+}
+
+// readFromFS returns a kin-openapi reader that loads relative and file: refs from fsys.
+func readFromFS(fsys fs.FS) oas3.ReadFromURIFunc {
+	return func(_ *oas3.Loader, location *url.URL) ([]byte, error) {
+		if location.Host != "" || (location.Scheme != "" && location.Scheme != "file") {
+			return nil, oas3.ErrURINotSupported
+		}
+		return fs.ReadFile(fsys, filepath.FromSlash(location.Path))
+	}
+}
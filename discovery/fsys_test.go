@@ -0,0 +1,101 @@
+package discovery_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Hossein-Roshandel/webswags/discovery"
+)
+
+// fsysFiles is a spec split across two files below specs/, next to a spec outside it.
+var fsysFiles = map[string]string{
+	"specs/pets.yaml":    refSpec("Pets", "./schemas.yaml#/Pet"),
+	"specs/schemas.yaml": "Pet: {type: object}\n",
+	"other/store.yaml":   refSpec("Store", "../specs/schemas.yaml#/Pet"),
+}
+
+// zipFS returns fsysFiles as a zip archive.
+func zipFS(t *testing.T) fs.FS {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range slices.Sorted(maps.Keys(fsysFiles)) {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(fsysFiles[name])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return zr
+}
+
+func TestDiscoverFS(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		fsys func(t *testing.T) fs.FS
+	}{
+		{name: "map", fsys: func(*testing.T) fs.FS {
+			fsys := fstest.MapFS{}
+			for name, data := range fsysFiles {
+				fsys[name] = &fstest.MapFile{Data: []byte(data)}
+			}
+			return fsys
+		}},
+		{name: "directory", fsys: func(t *testing.T) fs.FS {
+			dir := t.TempDir()
+			for name, data := range fsysFiles {
+				writeFile(t, dir, name, data)
+			}
+			return os.DirFS(dir)
+		}},
+		{name: "zip", fsys: zipFS},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fsys := tt.fsys(t)
+			result, err := discovery.DiscoverFS(t.Context(), fsys, "specs", discovery.DiscoverOptions{})
+			if err != nil {
+				t.Fatalf("DiscoverFS: %v", err)
+			}
+			if len(result.Specs) != 1 {
+				t.Fatalf("found %d specs, want 1", len(result.Specs))
+			}
+			spec := result.Specs[0]
+			if spec.Path != "specs/pets.yaml" || spec.ErrorCount() != 0 {
+				t.Errorf("spec %s with diagnostics %+v, want specs/pets.yaml without errors",
+					spec.Path, spec.Diagnostics)
+			}
+			if want := []string{"specs/schemas.yaml"}; !slices.Equal(spec.Dependencies, want) {
+				t.Errorf("Dependencies = %q, want %q", spec.Dependencies, want)
+			}
+			data, err := spec.ReadFile("specs/schemas.yaml")
+			if err != nil || string(data) != fsysFiles["specs/schemas.yaml"] {
+				t.Errorf("ReadFile(specs/schemas.yaml) = %q, %v", data, err)
+			}
+
+			// ParseFS resolves refs against the file's own directory, even outside a discovered root.
+			store, err := discovery.ParseFS(fsys, "other/store.yaml")
+			if err != nil || store.ErrorCount() != 0 || filepath.ToSlash(store.Path) != "other/store.yaml" {
+				t.Errorf("ParseFS(other/store.yaml) = %s with %+v, %v", store.Path, store.Diagnostics, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// shortHashLen is the length of abbreviated commit hashes, as git shows them.
+const shortHashLen = 7
+
+var (
+	// ErrNoHistory is returned for specs whose files are not tracked in a git repository.
+	ErrNoHistory = errors.New("spec is not tracked in a git repository")
+	// ErrUnknownRevision is returned for revisions that do not exist or do not contain the spec.
+	ErrUnknownRevision = errors.New("unknown revision")
+)
+
+// Revision is a commit that changed one of a spec's files.
+type Revision struct {
+	Commit  string    `json:"commit"         yaml:"commit"`
+	Author  string    `json:"author"         yaml:"author"`
+	Date    time.Time `json:"date"           yaml:"date"`
+	Subject string    `json:"subject"        yaml:"subject"`
+	Tags    []string  `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// Short returns the abbreviated commit hash.
+func (r Revision) Short() string {
+	if len(r.Commit) > shortHashLen {
+		return r.Commit[:shortHashLen]
+	}
+	return r.Commit
+}
+
+// specRepo locates a spec's files in the git repository that tracks them.
+type specRepo struct {
+	repo  *git.Repository
+	dir   string            // where the repository was opened, as for a GitSource
+	head  plumbing.Hash     // commit the history starts from
+	paths map[string]string // spec file → slash-separated path in the repository
+}
+
+// openSpecRepo finds the repository of a spec from the local file system (whose history starts
+// at HEAD) or from a GitSource (whose history starts at the source's revision).
+func openSpecRepo(spec SwaggerSpec) (*specRepo, error) {
+	if _, ok := spec.fsys.(osFS); ok {
+		return openWorkingTreeRepo(spec)
+	}
+	if strings.HasPrefix(spec.Origin, "git:") {
+		if src, err := ParseSource(spec.Origin); err == nil {
+			if gs, ok := src.(GitSource); ok {
+				return openSourceRepo(spec, gs)
+			}
+		}
+	}
+	return nil, ErrNoHistory
+}
+
+func openWorkingTreeRepo(spec SwaggerSpec) (*specRepo, error) {
+	dir := filepath.Dir(spec.Path)
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, ErrNoHistory
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, ErrNoHistory
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNoHistory, err)
+	}
+	root, err := filepath.EvalSymlinks(wt.Filesystem.Root())
+	if err != nil {
+		return nil, err
+	}
+
+	sr := &specRepo{repo: repo, dir: dir, head: head.Hash(), paths: make(map[string]string)}
+	for _, file := range spec.Files() {
+		abs, absErr := filepath.Abs(file)
+		if absErr != nil {
+			continue
+		}
+		real, realErr := filepath.EvalSymlinks(abs)
+		if realErr != nil {
+			continue
+		}
+		rel, relErr := filepath.Rel(root, real)
+		if relErr != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		sr.paths[file] = filepath.ToSlash(rel)
+	}
+	return sr, nil
+}
+
+func openSourceRepo(spec SwaggerSpec, src GitSource) (*specRepo, error) {
+	repo, err := git.PlainOpenWithOptions(src.Repo, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open git repository %s: %w", src.Repo, err)
+	}
+	head, err := repo.ResolveRevision(plumbing.Revision(src.ref()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %q in %s: %w", src.ref(), src.Repo, err)
+	}
+	sub, err := repoSubdir(repo, src.Repo)
+	if err != nil {
+		sub = "." // a bare repository is discovered whole
+	}
+
+	sr := &specRepo{repo: repo, dir: src.Repo, head: *head, paths: make(map[string]string)}
+	for _, file := range spec.Files() {
+		sr.paths[file] = path.Join(sub, filepath.ToSlash(file))
+	}
+	return sr, nil
+}
+
+// resolve returns the commit rev names.
+func (sr *specRepo) resolve(rev string) (*object.Commit, error) {
+	hash, err := sr.repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("%w %q", ErrUnknownRevision, rev)
+	}
+	commit, err := sr.repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit %s: %w", hash, err)
+	}
+	return commit, nil
+}
+
+// tags maps commits to the names of the tags pointing at them, annotated or not.
+func (sr *specRepo) tags() map[plumbing.Hash][]string {
+	tags := make(map[plumbing.Hash][]string)
+	iter, err := sr.repo.Tags()
+	if err != nil {
+		return tags
+	}
+	_ = iter.ForEach(func(ref *plumbing.Reference) error {
+		hash := ref.Hash()
+		if tag, tagErr := sr.repo.TagObject(hash); tagErr == nil {
+			if commit, commitErr := tag.Commit(); commitErr == nil {
+				hash = commit.Hash
+			}
+		}
+		tags[hash] = append(tags[hash], ref.Name().Short())
+		return nil
+	})
+	for _, names := range tags {
+		sort.Strings(names)
+	}
+	return tags
+}
+
+// revision describes commit, with the tags found by sr.tags.
+func revision(commit *object.Commit, tags map[plumbing.Hash][]string) Revision {
+	subject, _, _ := strings.Cut(strings.TrimSpace(commit.Message), "\n")
+	return Revision{
+		Commit:  commit.Hash.String(),
+		Author:  commit.Author.Name,
+		Date:    commit.Author.When,
+		Subject: strings.TrimSpace(subject),
+		Tags:    tags[commit.Hash],
+	}
+}
+
+// History lists the commits that changed any of the spec's Files, newest first. It starts at
+// HEAD for specs of the local file system and at the source's revision for those of a
+// GitSource. Renames are not followed. Specs from other sources return ErrNoHistory.
+func (s SwaggerSpec) History(ctx context.Context) ([]Revision, error) {
+	sr, err := openSpecRepo(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(sr.paths) == 0 {
+		return nil, ErrNoHistory
+	}
+	tracked := make(map[string]bool, len(sr.paths))
+	for _, p := range sr.paths {
+		tracked[p] = true
+	}
+
+	iter, err := sr.repo.Log(&git.LogOptions{
+		From:       sr.head,
+		Order:      git.LogOrderCommitterTime,
+		PathFilter: func(p string) bool { return tracked[p] },
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	defer iter.Close()
+
+	tags := sr.tags()
+	revisions := []Revision{}
+	err = iter.ForEach(func(commit *object.Commit) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		revisions = append(revisions, revision(commit, tags))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return revisions, nil
+}
+
+// AtRevision parses the spec as it was at rev: a commit, tag, branch or any revision git
+// understands. The result keeps the identity of s (service, slugs and version key) so it can
+// be served in its place; its Revision describes the commit and its paths are relative to the
+// repository root. Its $refs resolve against the same commit.
+func (s SwaggerSpec) AtRevision(ctx context.Context, rev string) (SwaggerSpec, error) {
+	sr, err := openSpecRepo(s)
+	if err != nil {
+		return SwaggerSpec{}, err
+	}
+	commit, err := sr.resolve(rev)
+	if err != nil {
+		return SwaggerSpec{}, err
+	}
+	return sr.specAt(ctx, s, commit)
+}
+
+// specAt parses spec as it was at commit, reading only the files it opens from the commit's tree.
+func (sr *specRepo) specAt(ctx context.Context, spec SwaggerSpec, commit *object.Commit) (SwaggerSpec, error) {
+	name, ok := sr.paths[spec.Path]
+	if !ok {
+		return SwaggerSpec{}, ErrNoHistory
+	}
+	if err := ctx.Err(); err != nil {
+		return SwaggerSpec{}, err
+	}
+	files, err := newTreeFS(commit, 0)
+	if err != nil {
+		return SwaggerSpec{}, err
+	}
+	if _, statErr := fs.Stat(files, name); statErr != nil {
+		return SwaggerSpec{}, fmt.Errorf("%w: %s does not exist at %s", ErrUnknownRevision, name, commit.Hash)
+	}
+
+	at, err := parseSwaggerSpec(slashFS{fsys: files}, name)
+	if err != nil {
+		return SwaggerSpec{}, fmt.Errorf("failed to parse %s at %s: %w", name, commit.Hash, err)
+	}
+	at.Origin = GitSource{Repo: sr.dir, Ref: commit.Hash.String()}.Origin()
+	at.sourceRoot = "."
+	at = mergeComponent([]SwaggerSpec{at}, []int{0})
+	at.Service, at.ServiceSlug, at.Slug, at.VersionKey = spec.Service, spec.ServiceSlug, spec.Slug, spec.VersionKey
+	rev := revision(commit, sr.tags())
+	at.Revision = &rev
+	return at, nil
+}
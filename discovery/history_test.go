@@ -0,0 +1,176 @@
+package discovery_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"github.com/Hossein-Roshandel/webswags/discovery"
+)
+
+// historySpec is a spec at the given version whose Pet schema is in schemas.yaml.
+func historySpec(version string) string {
+	return `openapi: 3.0.3
+info: {title: Pets, version: "` + version + `"}
+paths: {}
+components:
+  schemas:
+    Pet: {$ref: "./schemas.yaml#/Pet"}
+`
+}
+
+// gitRepo is a throwaway repository whose commits are an hour apart.
+type gitRepo struct {
+	t    *testing.T
+	dir  string
+	repo *git.Repository
+	when time.Time
+}
+
+func newGitRepo(t *testing.T) *gitRepo {
+	t.Helper()
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &gitRepo{t: t, dir: dir, repo: repo, when: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+// commit writes files (name → data) and commits them with the given subject.
+func (g *gitRepo) commit(subject string, files map[string]string) plumbing.Hash {
+	g.t.Helper()
+	wt, err := g.repo.Worktree()
+	if err != nil {
+		g.t.Fatal(err)
+	}
+	for name, data := range files {
+		writeFile(g.t, g.dir, name, data)
+		if _, err := wt.Add(name); err != nil {
+			g.t.Fatal(err)
+		}
+	}
+	g.when = g.when.Add(time.Hour)
+	hash, err := wt.Commit(subject, &git.CommitOptions{
+		Author: &object.Signature{Name: "Tester", Email: "tester@example.com", When: g.when},
+	})
+	if err != nil {
+		g.t.Fatal(err)
+	}
+	return hash
+}
+
+func TestSpecHistory(t *testing.T) {
+	t.Parallel()
+	g := newGitRepo(t)
+	g.commit("Add readme", map[string]string{"README.md": "# APIs\n"})
+	first := g.commit("Add pets", map[string]string{
+		"pets.yaml":    historySpec("1.0.0"),
+		"schemas.yaml": "Pet: {type: object}\n",
+	})
+	if _, err := g.repo.CreateTag("v1.0.0", first, nil); err != nil {
+		t.Fatal(err)
+	}
+	second := g.commit("Describe pets", map[string]string{
+		"schemas.yaml": "Pet: {type: object, description: A pet}\n",
+	})
+	g.commit("Update readme", map[string]string{"README.md": "# Pet APIs\n"})
+	third := g.commit("Release 1.1.0", map[string]string{"pets.yaml": historySpec("1.1.0")})
+
+	registry := discovery.NewRegistry(g.dir, discovery.DiscoverOptions{})
+	if err := registry.Load(t.Context()); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	spec, ok := registry.Lookup("pets")
+	if !ok {
+		t.Fatal("spec pets not discovered")
+	}
+
+	revisions, err := spec.History(t.Context())
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	var subjects []string
+	for _, rev := range revisions {
+		subjects = append(subjects, rev.Subject)
+	}
+	if want := []string{"Release 1.1.0", "Describe pets", "Add pets"}; !slices.Equal(subjects, want) {
+		t.Fatalf("History = %q, want %q", subjects, want)
+	}
+	if oldest := revisions[len(revisions)-1]; oldest.Commit != first.String() ||
+		!slices.Equal(oldest.Tags, []string{"v1.0.0"}) {
+		t.Errorf("first revision = %s %q, want %s tagged v1.0.0", oldest.Commit, oldest.Tags, first)
+	}
+
+	tests := []struct {
+		rev         string
+		wantCommit  plumbing.Hash
+		wantVersion string
+		wantSchemas string
+		wantErr     error
+	}{
+		{rev: "v1.0.0", wantCommit: first, wantVersion: "1.0.0", wantSchemas: "Pet: {type: object}\n"},
+		{
+			rev: second.String(), wantCommit: second, wantVersion: "1.0.0",
+			wantSchemas: "Pet: {type: object, description: A pet}\n",
+		},
+		{
+			rev: "HEAD", wantCommit: third, wantVersion: "1.1.0",
+			wantSchemas: "Pet: {type: object, description: A pet}\n",
+		},
+		{rev: "HEAD~4", wantErr: discovery.ErrUnknownRevision}, // before the spec was added
+		{rev: "no-such-branch", wantErr: discovery.ErrUnknownRevision},
+	}
+	for _, tt := range tests {
+		for _, at := range []func() (discovery.SwaggerSpec, error){
+			func() (discovery.SwaggerSpec, error) { return spec.AtRevision(t.Context(), tt.rev) },
+			func() (discovery.SwaggerSpec, error) { return registry.SpecAt(t.Context(), spec, tt.rev) },
+			func() (discovery.SwaggerSpec, error) { return registry.SpecAt(t.Context(), spec, tt.rev) }, // cached
+		} {
+			got, err := at()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("at %s: err = %v, want %v", tt.rev, err, tt.wantErr)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("at %s: %v", tt.rev, err)
+			}
+			if got.Revision == nil || got.Revision.Commit != tt.wantCommit.String() {
+				t.Errorf("at %s: revision = %+v, want commit %s", tt.rev, got.Revision, tt.wantCommit)
+			}
+			if got.Version != tt.wantVersion || got.Slug != spec.Slug || got.VersionKey != spec.VersionKey {
+				t.Errorf("at %s: version %q, slug %q, key %q; want %q, %q, %q", tt.rev,
+					got.Version, got.Slug, got.VersionKey, tt.wantVersion, spec.Slug, spec.VersionKey)
+			}
+			if data, readErr := got.ReadFile("schemas.yaml"); readErr != nil || string(data) != tt.wantSchemas {
+				t.Errorf("at %s: schemas.yaml = %q, %v; want %q", tt.rev, data, readErr, tt.wantSchemas)
+			}
+		}
+	}
+}
+
+func TestSpecHistoryOutsideGit(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	writeFile(t, root, "pets.yaml", petsSpec)
+	result, err := discovery.Discover(t.Context(), root, discovery.DiscoverOptions{})
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	if len(result.Specs) != 1 {
+		t.Fatalf("found %d specs, want 1", len(result.Specs))
+	}
+	if _, err := result.Specs[0].History(t.Context()); !errors.Is(err, discovery.ErrNoHistory) {
+		t.Errorf("History = %v, want ErrNoHistory", err)
+	}
+	if _, err := result.Specs[0].AtRevision(t.Context(), "HEAD"); !errors.Is(err, discovery.ErrNoHistory) {
+		t.Errorf("AtRevision = %v, want ErrNoHistory", err)
+	}
+}
@@ -0,0 +1,279 @@
+package discovery
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	// GitignoreFile is the name of git's per-directory ignore file, honoured during discovery.
+	GitignoreFile = ".gitignore"
+	// WebswagsignoreFile is a per-directory ignore file read only by WebSwags.
+	// It uses .gitignore syntax and is applied after .gitignore, so it can re-include paths with "!".
+	WebswagsignoreFile = ".webswagsignore"
+)
+
+// ignoreRule is one parsed line of a .gitignore-style file.
+type ignoreRule struct {
+	pattern  string // slash-separated glob
+	base     string // slash-separated directory the rule is relative to ("" for the root)
+	negate   bool   // "!pattern" re-includes a previously ignored path
+	dirOnly  bool   // "pattern/" only matches directories
+	anchored bool   // pattern contains a slash, so it is matched against the path below base
+	text     string // the line as written, for reports
+	source   string // where the rule comes from, e.g. "apis/.gitignore", for reports
+}
+
+// parseIgnoreRule parses a single .gitignore line. It reports false for blank lines and comments.
+func parseIgnoreRule(line, base string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	rule := ignoreRule{base: base, text: line}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	}
+	// A leading backslash escapes "#" or "!".
+	line = strings.TrimPrefix(line, `\`)
+
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	rule.pattern = line
+	return rule, true
+}
+
+// matches reports whether the rule applies to rel, a slash-separated path relative to the root.
+func (r ignoreRule) matches(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+
+	sub := rel
+	if r.base != "" {
+		if !strings.HasPrefix(rel, r.base+"/") {
+			return false
+		}
+		sub = strings.TrimPrefix(rel, r.base+"/")
+	}
+
+	if r.anchored {
+		return matchGlob(r.pattern, sub)
+	}
+	return matchGlob(r.pattern, path.Base(sub))
+}
+
+// ignoreSet evaluates ignore rules for paths below a root.
+//
+// Rules come from, in increasing priority: .gitignore and .webswagsignore files found in the
+// root and every directory on the way down (loaded lazily and cached), then the Exclude
+// patterns from DiscoverOptions. As with git, the last matching rule wins and nothing
+// inside an ignored directory can be re-included.
+type ignoreSet struct {
+	fsys    fs.FS
+	root    string
+	include []string
+	exclude []ignoreRule
+	useVCS  bool
+
+	mu    sync.Mutex
+	cache map[string][]ignoreRule // slash-separated dir relative to root → rules defined there
+}
+
+func newIgnoreSet(fsys fs.FS, root string, opts DiscoverOptions) *ignoreSet {
+	set := &ignoreSet{
+		fsys:    fsys,
+		root:    root,
+		include: opts.Include,
+		useVCS:  !opts.DisableIgnoreFiles,
+		cache:   make(map[string][]ignoreRule),
+	}
+	for _, pattern := range opts.Exclude {
+		if rule, ok := parseIgnoreRule(pattern, ""); ok {
+			rule.source = "exclude patterns"
+			set.exclude = append(set.exclude, rule)
+		}
+	}
+	return set
+}
+
+// relPath converts a path below the root into a slash-separated relative path.
+func (s *ignoreSet) relPath(p string) (string, bool) {
+	rel, err := filepath.Rel(s.root, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// ignored reports whether the file or directory at p (an OS path below the root) is excluded,
+// either by a rule matching it directly or by one matching any of its parent directories.
+// Paths outside the root are never ignored.
+func (s *ignoreSet) ignored(p string, isDir bool) bool {
+	return s.reason(p, isDir) != ""
+}
+
+// reason explains why the file or directory at p is ignored (see ignored), or returns "" if it is not.
+func (s *ignoreSet) reason(p string, isDir bool) string {
+	rel, ok := s.relPath(p)
+	if !ok || rel == "." {
+		return ""
+	}
+
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		dir := strings.Join(parts[:i], "/")
+		if why := s.exclusion(dir, true); why != "" {
+			return "inside ignored directory " + dir + ": " + why
+		}
+	}
+	return s.exclusion(rel, isDir)
+}
+
+// exclusion evaluates the rules for a single entry, assuming its parent directories are not
+// ignored. It returns why the entry is excluded, or "" if it is not.
+func (s *ignoreSet) exclusion(rel string, isDir bool) string {
+	if path.Base(rel) == ".git" && isDir {
+		return "VCS metadata directory" // never holds specs
+	}
+
+	var last *ignoreRule
+	apply := func(rules []ignoreRule) {
+		for i := range rules {
+			if rules[i].matches(rel, isDir) {
+				last = &rules[i]
+			}
+		}
+	}
+
+	if s.useVCS {
+		dir := path.Dir(rel)
+		apply(s.rulesIn(""))
+		if dir != "." {
+			parts := strings.Split(dir, "/")
+			for i := 1; i <= len(parts); i++ {
+				apply(s.rulesIn(strings.Join(parts[:i], "/")))
+			}
+		}
+	}
+	apply(s.exclude)
+
+	if last == nil || last.negate {
+		return ""
+	}
+	return fmt.Sprintf("matches %q in %s", last.text, last.source)
+}
+
+// included reports whether a file passes the Include globs. Directories are never filtered
+// by Include, since a deeper file may still match.
+func (s *ignoreSet) included(p string) bool {
+	if len(s.include) == 0 {
+		return true
+	}
+	rel, ok := s.relPath(p)
+	if !ok {
+		return false
+	}
+	for _, pattern := range s.include {
+		pattern = strings.TrimPrefix(pattern, "/")
+		if strings.Contains(pattern, "/") {
+			if matchGlob(pattern, rel) {
+				return true
+			}
+		} else if matchGlob(pattern, path.Base(rel)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isIgnoreFile reports whether p names one of the ignore files read during discovery.
+func isIgnoreFile(p string) bool {
+	base := filepath.Base(p)
+	return base == GitignoreFile || base == WebswagsignoreFile
+}
+
+// rulesIn returns the rules defined by the ignore files in dir (slash-separated, relative to root).
+func (s *ignoreSet) rulesIn(dir string) []ignoreRule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rules, ok := s.cache[dir]; ok {
+		return rules
+	}
+
+	base := dir
+	if base == "" {
+		dir = "."
+	}
+
+	var rules []ignoreRule
+	for _, name := range []string{GitignoreFile, WebswagsignoreFile} {
+		data, err := fs.ReadFile(s.fsys, filepath.Join(s.root, filepath.FromSlash(dir), name))
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			if rule, ok := parseIgnoreRule(scanner.Text(), base); ok {
+				rule.source = path.Join(base, name)
+				rules = append(rules, rule)
+			}
+		}
+		slog.Debug("Loaded ignore rules", "file", filepath.Join(s.root, filepath.FromSlash(dir), name))
+	}
+
+	s.cache[base] = rules
+	return rules
+}
+
+// matchGlob matches a slash-separated name against a glob pattern.
+// Each segment uses path.Match syntax; a "**" segment matches zero or more whole segments.
+func matchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			if len(rest) == 0 {
+				return true
+			}
+			for i := range len(parts) + 1 {
+				if matchSegments(rest, parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], parts[0]); err != nil || !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
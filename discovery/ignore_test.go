@@ -0,0 +1,120 @@
+package discovery_test
+
+import (
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Hossein-Roshandel/webswags/discovery"
+)
+
+// specFile returns a minimal OpenAPI 3 document with the given title and version.
+func specFile(title, version string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte("openapi: 3.0.3\ninfo: {title: \"" + title + "\", version: \"" + version +
+		"\"}\npaths: {}\n")}
+}
+
+// discoverPaths returns the paths of the specs DiscoverFS finds in fsys, sorted.
+func discoverPaths(t *testing.T, fsys fstest.MapFS, opts discovery.DiscoverOptions) []string {
+	t.Helper()
+	result, err := discovery.DiscoverFS(t.Context(), fsys, ".", opts)
+	if err != nil {
+		t.Fatalf("DiscoverFS: %v", err)
+	}
+	paths := make([]string, 0, len(result.Specs))
+	for _, spec := range result.Specs {
+		paths = append(paths, spec.Path)
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+func TestDiscoverIgnore(t *testing.T) {
+	t.Parallel()
+	tree := func() fstest.MapFS {
+		return fstest.MapFS{
+			"apis/pets.yaml":          specFile("Pets", "1"),
+			"apis/pets.gen.yaml":      specFile("Generated Pets", "1"),
+			"apis/keep.gen.yaml":      specFile("Kept", "1"),
+			"apis/.webswagsignore":    {Data: []byte("!keep.gen.yaml\n")},
+			"build/openapi.yaml":      specFile("Build", "1"),
+			"fixtures/broken.yaml":    specFile("Fixture", "1"),
+			"fixtures/nested/ok.yaml": specFile("Nested", "1"),
+			".gitignore":              {Data: []byte("# generated\nbuild/\n*.gen.yaml\n")},
+		}
+	}
+	tests := []struct {
+		name string
+		opts discovery.DiscoverOptions
+		want []string
+	}{
+		{
+			name: "ignore files",
+			want: []string{"apis/keep.gen.yaml", "apis/pets.yaml", "fixtures/broken.yaml", "fixtures/nested/ok.yaml"},
+		},
+		{
+			name: "exclude",
+			opts: discovery.DiscoverOptions{Exclude: []string{"fixtures/"}},
+			want: []string{"apis/keep.gen.yaml", "apis/pets.yaml"},
+		},
+		{
+			name: "exclude beats negation",
+			opts: discovery.DiscoverOptions{Exclude: []string{"*.gen.yaml"}},
+			want: []string{"apis/pets.yaml", "fixtures/broken.yaml", "fixtures/nested/ok.yaml"},
+		},
+		{
+			name: "include by name",
+			opts: discovery.DiscoverOptions{Include: []string{"pets*.yaml"}},
+			want: []string{"apis/pets.yaml"},
+		},
+		{
+			name: "include by path",
+			opts: discovery.DiscoverOptions{Include: []string{"fixtures/**/*.yaml"}},
+			want: []string{"fixtures/broken.yaml", "fixtures/nested/ok.yaml"},
+		},
+		{
+			name: "ignore files disabled",
+			opts: discovery.DiscoverOptions{DisableIgnoreFiles: true},
+			want: []string{
+				"apis/keep.gen.yaml", "apis/pets.gen.yaml", "apis/pets.yaml", "build/openapi.yaml",
+				"fixtures/broken.yaml", "fixtures/nested/ok.yaml",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := discoverPaths(t, tree(), tt.opts); !slices.Equal(got, tt.want) {
+				t.Errorf("specs = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscoverReportsIgnoredPaths(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"build/openapi.yaml": specFile("Build", "1"),
+		"pets.gen.yaml":      specFile("Generated Pets", "1"),
+		".gitignore":         {Data: []byte("build/\n*.gen.yaml\n")},
+	}
+	result, err := discovery.DiscoverFS(t.Context(), fsys, ".", discovery.DiscoverOptions{})
+	if err != nil {
+		t.Fatalf("DiscoverFS: %v", err)
+	}
+	want := map[string]string{
+		"build":         `matches "build/" in .gitignore`,
+		"pets.gen.yaml": `matches "*.gen.yaml" in .gitignore`,
+	}
+	for _, entry := range result.Report.Entries {
+		if reason, ok := want[entry.Path]; ok {
+			if entry.Status != discovery.FileIgnored || entry.Reason != reason {
+				t.Errorf("%s: %s (%s), want ignored (%s)", entry.Path, entry.Status, entry.Reason, reason)
+			}
+			delete(want, entry.Path)
+		}
+	}
+	for path := range want {
+		t.Errorf("%s is not in the report", path)
+	}
+}
@@ -0,0 +1,157 @@
+package discovery
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// dirMode is the mode of the directories of a mapFS.
+const dirMode = fs.ModeDir | 0o555
+
+// mapFS is a read-only file system held in memory, for the files read from archives, git trees
+// and HTTP sources. Files are keyed by slash-separated name; directories are implied by the
+// files below them.
+type mapFS map[string]*mapFile
+
+// mapFile is a file of a mapFS.
+type mapFile struct {
+	data    []byte
+	modTime time.Time
+}
+
+// Open implements fs.FS.
+func (m mapFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if file, ok := m[name]; ok {
+		return &openMapFile{info: mapInfo{name: path.Base(name), file: file}, Reader: bytes.NewReader(file.data)}, nil
+	}
+	entries := m.entries(name)
+	if len(entries) == 0 && name != "." {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &mapDir{info: mapInfo{name: path.Base(name)}, entries: entries}, nil
+}
+
+// ReadFile implements fs.ReadFileFS.
+func (m mapFS) ReadFile(name string) ([]byte, error) {
+	if file, ok := m[name]; ok && fs.ValidPath(name) {
+		return slices.Clone(file.data), nil
+	}
+	return fs.ReadFile(struct{ fs.FS }{m}, name)
+}
+
+// Stat implements fs.StatFS.
+func (m mapFS) Stat(name string) (fs.FileInfo, error) {
+	if file, ok := m[name]; ok && fs.ValidPath(name) {
+		return mapInfo{name: path.Base(name), file: file}, nil
+	}
+	return fs.Stat(struct{ fs.FS }{m}, name)
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (m mapFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(struct{ fs.FS }{m}, name)
+}
+
+// entries lists the directory dir, sorted by name.
+func (m mapFS) entries(dir string) []fs.DirEntry {
+	prefix := dir + "/"
+	if dir == "." {
+		prefix = ""
+	}
+	seen := make(map[string]bool)
+	var entries []fs.DirEntry
+	for name, file := range m {
+		rest, ok := strings.CutPrefix(name, prefix)
+		if !ok {
+			continue
+		}
+		elem, _, isDir := strings.Cut(rest, "/")
+		if seen[elem] {
+			continue
+		}
+		seen[elem] = true
+		info := mapInfo{name: elem}
+		if !isDir {
+			info.file = file
+		}
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	return entries
+}
+
+// mapInfo describes a file of a mapFS, or a directory if file is nil.
+type mapInfo struct {
+	name string
+	file *mapFile
+}
+
+func (i mapInfo) Name() string { return i.name }
+func (i mapInfo) IsDir() bool  { return i.file == nil }
+func (i mapInfo) Sys() any     { return nil }
+
+func (i mapInfo) Size() int64 {
+	if i.file == nil {
+		return 0
+	}
+	return int64(len(i.file.data))
+}
+
+func (i mapInfo) Mode() fs.FileMode {
+	if i.file == nil {
+		return dirMode
+	}
+	return readOnlyMode
+}
+
+func (i mapInfo) ModTime() time.Time {
+	if i.file == nil {
+		return time.Time{}
+	}
+	return i.file.modTime
+}
+
+// openMapFile is a file of a mapFS being read.
+type openMapFile struct {
+	*bytes.Reader
+
+	info mapInfo
+}
+
+func (f *openMapFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *openMapFile) Close() error               { return nil }
+
+// mapDir is a directory of a mapFS being read.
+type mapDir struct {
+	info    mapInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *mapDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *mapDir) Close() error               { return nil }
+
+func (d *mapDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+
+// ReadDir implements fs.ReadDirFile.
+func (d *mapDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n > 0 && len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > 0 && n < len(rest) {
+		rest = rest[:n]
+	}
+	d.offset += len(rest)
+	return slices.Clone(rest), nil
+}
@@ -0,0 +1,299 @@
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	oas3 "github.com/getkin/kin-openapi/openapi3"
+	"sigs.k8s.io/yaml"
+)
+
+// Dialects whose schemas discovery understands. OpenAPI 3.1 documents default to the first.
+const (
+	dialectOAS31Base = "https://spec.openapis.org/oas/3.1/dialect/base"
+	dialect202012    = "https://json-schema.org/draft/2020-12/schema"
+)
+
+// Webhook is one operation of an OpenAPI 3.1 webhook: a request the API sends to its
+// consumers rather than one it receives.
+type Webhook struct {
+	Name        string   `json:"name"                  yaml:"name"`
+	Method      string   `json:"method"                yaml:"method"`
+	OperationID string   `json:"operationId,omitempty" yaml:"operationId,omitempty"`
+	Summary     string   `json:"summary,omitempty"     yaml:"summary,omitempty"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"        yaml:"tags,omitempty"`
+	Deprecated  bool     `json:"deprecated,omitempty"  yaml:"deprecated,omitempty"`
+}
+
+// WebhookOperations lists the spec's webhook operations, sorted by webhook name and method.
+// It is empty for documents before OpenAPI 3.1.
+func (s SwaggerSpec) WebhookOperations() []Webhook {
+	hooks := make([]Webhook, 0, len(s.WebhooksV3))
+	for _, name := range sortedKeys(s.WebhooksV3) {
+		item := s.WebhooksV3[name]
+		if item == nil {
+			continue
+		}
+		ops := item.Operations()
+		for _, method := range sortedKeys(ops) {
+			op := ops[method]
+			hooks = append(hooks, Webhook{
+				Name:        name,
+				Method:      method,
+				OperationID: op.OperationID,
+				Summary:     firstNonEmpty(op.Summary, item.Summary),
+				Description: firstNonEmpty(op.Description, item.Description),
+				Tags:        op.Tags,
+				Deprecated:  op.Deprecated,
+			})
+		}
+	}
+	return hooks
+}
+
+// isOAS31 reports whether version is an OpenAPI 3.1 version string.
+func isOAS31(version string) bool {
+	return strings.HasPrefix(strings.TrimSpace(version), "3.1")
+}
+
+// declaredOpenAPIVersion returns the top-level "openapi" field of data, or "" if it has none.
+func declaredOpenAPIVersion(data []byte) string {
+	var head struct {
+		OpenAPI string `json:"openapi"`
+	}
+	_ = unmarshalYAMLOrJSON(data, &head) // best effort: a broken file is reported by the loaders
+	return strings.TrimSpace(head.OpenAPI)
+}
+
+// oas31Fields lists the fields OpenAPI 3.1 adds to objects kin-openapi models after 3.0:
+// JSON Schema 2020-12 keywords, info.summary, license.identifier, components.pathItems,
+// the root-level webhooks and jsonSchemaDialect, and the summary/description allowed next
+// to a $ref. They are legal in 3.1 documents, so validation must not flag them.
+func oas31Fields() []string {
+	return []string{
+		"webhooks", "jsonSchemaDialect", "summary", "identifier", "pathItems", "description",
+		"$schema", "$id", "$anchor", "$dynamicAnchor", "$dynamicRef", "$defs", "$comment", "$vocabulary",
+		"const", "examples", "prefixItems", "contains", "minContains", "maxContains",
+		"if", "then", "else", "dependentRequired", "dependentSchemas", "patternProperties",
+		"propertyNames", "unevaluatedItems", "unevaluatedProperties",
+		"contentEncoding", "contentMediaType", "contentSchema",
+	}
+}
+
+// normalizeOAS31 rewrites the OpenAPI 3.1 constructs that kin-openapi cannot decode or
+// validate into their OpenAPI 3.0 equivalents, and returns the document as JSON:
+//   - numeric exclusiveMinimum/exclusiveMaximum become a bound plus a boolean flag;
+//   - the "null" type becomes nullable: true;
+//   - arrays without items get an empty items schema.
+//
+// Other type arrays, const and $defs decode as they are. Literal values (examples, defaults,
+// enums, consts) and extensions are left untouched. Only the typed documents see the result;
+// Raw and everything served keep the original.
+func normalizeOAS31(data []byte) ([]byte, error) {
+	j, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	if err := json.Unmarshal(j, &doc); err != nil {
+		return nil, err
+	}
+	normalizeNode(doc)
+	return json.Marshal(doc)
+}
+
+// normalizeNode applies normalizeOAS31 to node and everything below it, in place.
+func normalizeNode(node any) {
+	switch n := node.(type) {
+	case map[string]any:
+		for _, bound := range [][2]string{{"exclusiveMinimum", "minimum"}, {"exclusiveMaximum", "maximum"}} {
+			if limit, ok := n[bound[0]].(float64); ok {
+				n[bound[1]] = limit
+				n[bound[0]] = true
+			}
+		}
+		normalizeNullType(n)
+		if _, ok := n["items"]; !ok && hasType(n, "array") {
+			n["items"] = map[string]any{} // 3.1 arrays may omit items; 3.0 requires it
+		}
+		for key, value := range n {
+			children, named := value.(map[string]any)
+			switch {
+			case strings.HasPrefix(key, "x-"):
+			case key == "example" || key == "examples" || key == "default" || key == "enum" || key == "const":
+			case named && namesChildren(key):
+				for _, child := range children {
+					normalizeNode(child)
+				}
+			default:
+				normalizeNode(value)
+			}
+		}
+	case []any:
+		for _, value := range n {
+			normalizeNode(value)
+		}
+	}
+}
+
+// namesChildren reports whether the keys of the map under key are names chosen by the author
+// (of properties, schemas, responses and the like) rather than keywords, so a property or
+// response called "default" is normalised like any other.
+func namesChildren(key string) bool {
+	switch key {
+	case "properties", "patternProperties", "dependentSchemas", "$defs", "definitions", "schemas", "responses":
+		return true
+	}
+	return false
+}
+
+// normalizeNullType turns the "null" entry of a schema's type into nullable: true.
+func normalizeNullType(schema map[string]any) {
+	var types []any
+	switch t := schema["type"].(type) {
+	case string:
+		types = []any{t}
+	case []any:
+		types = t
+	default:
+		return
+	}
+
+	kept := make([]any, 0, len(types))
+	for _, t := range types {
+		if t == "null" {
+			schema["nullable"] = true
+		} else {
+			kept = append(kept, t)
+		}
+	}
+	switch {
+	case len(kept) == len(types):
+	case len(kept) == 0:
+		delete(schema, "type")
+	case len(kept) == 1:
+		schema["type"] = kept[0]
+	default:
+		schema["type"] = kept
+	}
+}
+
+// hasType reports whether a schema's type is, or includes, t.
+func hasType(schema map[string]any, t string) bool {
+	switch types := schema["type"].(type) {
+	case string:
+		return types == t
+	case []any:
+		return slices.Contains(types, any(t))
+	}
+	return false
+}
+
+// normalizingReader wraps read so that every external file of a 3.1 spec is normalised too.
+func normalizingReader(read oas3.ReadFromURIFunc) oas3.ReadFromURIFunc {
+	return func(loader *oas3.Loader, location *url.URL) ([]byte, error) {
+		data, err := read(loader, location)
+		if err != nil {
+			return nil, err
+		}
+		if normalized, normErr := normalizeOAS31(data); normErr == nil {
+			return normalized, nil
+		}
+		return data, nil
+	}
+}
+
+// loadOAS31Fields completes a loaded OpenAPI 3.1 spec. The fields kin-openapi keeps as untyped
+// extensions are filled in: jsonSchemaDialect, and the webhooks, which are also parsed into
+// WebhooksV3 with their refs resolved like those of the paths. Unless the spec was bundled,
+// the other top-level sections of OpenAPI3Doc are taken from the file too rather than from
+// the normalised DocV3.
+func loadOAS31Fields(spec *SwaggerSpec, loader *oas3.Loader, location *url.URL) error {
+	var sections struct {
+		Servers           json.RawMessage `json:"servers"`
+		Paths             json.RawMessage `json:"paths"`
+		Components        json.RawMessage `json:"components"`
+		Security          json.RawMessage `json:"security"`
+		Tags              json.RawMessage `json:"tags"`
+		ExternalDocs      json.RawMessage `json:"externalDocs"`
+		JSONSchemaDialect string          `json:"jsonSchemaDialect"`
+		Webhooks          json.RawMessage `json:"webhooks"`
+	}
+	if err := unmarshalYAMLOrJSON(spec.Raw, &sections); err == nil {
+		view := spec.OpenAPI3Doc
+		view.JSONSchemaDialect, view.Webhooks = sections.JSONSchemaDialect, sections.Webhooks
+		if spec.Bundled == nil {
+			view.Servers, view.Paths, view.Components = sections.Servers, sections.Paths, sections.Components
+			view.Security, view.Tags, view.ExternalDocs = sections.Security, sections.Tags, sections.ExternalDocs
+		}
+	}
+
+	doc := spec.DocV3
+	if doc.Paths == nil {
+		doc.Paths = oas3.NewPaths() // optional since 3.1, e.g. in webhook-only APIs
+	}
+	raw, ok := doc.Extensions["webhooks"]
+	if !ok {
+		return nil
+	}
+	var hooks map[string]*oas3.PathItem
+	if err := json.Unmarshal(toRaw(raw), &hooks); err != nil {
+		return fmt.Errorf("invalid webhooks: %w", err)
+	}
+	spec.WebhooksV3 = hooks
+
+	// The loader resolves refs per document, so resolve the webhooks as the paths of a document
+	// that shares the spec's components.
+	paths := oas3.NewPaths()
+	for name, item := range hooks {
+		if item != nil {
+			paths.Set("/"+name, item)
+		}
+	}
+	shadow := &oas3.T{OpenAPI: doc.OpenAPI, Info: doc.Info, Components: doc.Components, Paths: paths}
+	if err := loader.ResolveRefsIn(shadow, location); err != nil {
+		return fmt.Errorf("invalid webhooks: %w", err)
+	}
+	return nil
+}
+
+// dialectWarning explains why a declared jsonSchemaDialect is not honoured, or returns "".
+func dialectWarning(dialect string) string {
+	switch {
+	case dialect == "", dialect == dialectOAS31Base, dialect == dialect202012:
+		return ""
+	case !isAbsoluteURI(dialect):
+		return fmt.Sprintf("jsonSchemaDialect %q must be an absolute URI", dialect)
+	default:
+		return fmt.Sprintf("jsonSchemaDialect %q is not supported; schemas are validated against %s",
+			dialect, dialectOAS31Base)
+	}
+}
+
+// isAbsoluteURI reports whether s parses as a URI with a scheme.
+func isAbsoluteURI(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != ""
+}
+
+// firstNonEmpty returns the first of values that is not empty.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// jsonSchemaDialect returns the spec's declared jsonSchemaDialect, or "".
+func (s SwaggerSpec) jsonSchemaDialect() string {
+	if s.OpenAPI3Doc == nil {
+		return ""
+	}
+	return s.OpenAPI3Doc.JSONSchemaDialect
+}
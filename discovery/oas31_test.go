@@ -0,0 +1,115 @@
+package discovery_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/Hossein-Roshandel/webswags/discovery"
+)
+
+const validOAS31 = `openapi: 3.1.0
+info:
+  title: Pets
+  version: "1"
+  summary: Pets of the store
+paths:
+  /pets:
+    get:
+      responses:
+        default:
+          description: Error
+          content:
+            application/json:
+              schema:
+                type: [object, "null"]
+        "200":
+          description: A pet
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+                description: The pet
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        id: {type: integer, exclusiveMinimum: 0}
+        default: {type: [string, "null"]}
+        enum: {type: array}
+        kind: {const: dog}
+`
+
+const brokenOAS31 = `openapi: 3.1.0
+paths:
+  /pets:
+    get:
+      responses:
+        "200":
+          description: A pet
+          content:
+            application/json:
+              schema: {type: animal}
+`
+
+func parse(t *testing.T, data string) discovery.SwaggerSpec {
+	t.Helper()
+	spec, err := discovery.ParseFS(fstest.MapFS{"openapi.yaml": {Data: []byte(data)}}, "openapi.yaml")
+	if err != nil {
+		t.Fatalf("ParseFS: %v", err)
+	}
+	return spec
+}
+
+func TestOAS31Health(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		data   string
+		health string
+	}{
+		{name: "valid", data: validOAS31, health: discovery.HealthValid},
+		{name: "broken", data: brokenOAS31, health: discovery.HealthErrors},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			spec := parse(t, tt.data)
+			if got := spec.Health(); got != tt.health {
+				t.Errorf("Health() = %q, want %q; diagnostics: %+v", got, tt.health, spec.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestOAS31BrokenKeepsStructuralErrors(t *testing.T) {
+	t.Parallel()
+	spec := parse(t, brokenOAS31)
+	want := map[string]bool{"": false, "/paths/~1pets/get": false} // missing info, unknown type
+	for _, d := range spec.Diagnostics {
+		if _, ok := want[d.Pointer]; ok && d.Severity == discovery.SeverityError {
+			want[d.Pointer] = true
+		}
+	}
+	for pointer, found := range want {
+		if !found {
+			t.Errorf("no error at %q; diagnostics: %+v", pointer, spec.Diagnostics)
+		}
+	}
+}
+
+func TestOAS31NormalizesPropertiesNamedAsKeywords(t *testing.T) {
+	t.Parallel()
+	spec := parse(t, validOAS31)
+	pet := spec.DocV3.Components.Schemas["Pet"].Value
+	if def := pet.Properties["default"].Value; !def.Nullable || !def.Type.Is("string") {
+		t.Errorf(`property "default" = %v nullable %t, want a nullable string`, def.Type, def.Nullable)
+	}
+	if enum := pet.Properties["enum"].Value; enum.Items == nil {
+		t.Error(`property "enum" is an array without items`)
+	}
+	errResponse := spec.DocV3.Paths.Value("/pets").Get.Responses.Default().Value
+	if schema := errResponse.Content.Get("application/json").Schema.Value; !schema.Nullable {
+		t.Error("schema of the default response is not nullable")
+	}
+}
@@ -0,0 +1,93 @@
+package discovery
+
+import (
+	"slices"
+	"strings"
+
+	oas3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+// Operation is one entry of a spec's operation index: an operation of its paths, flattened
+// with what a catalog needs to find and filter it.
+type Operation struct {
+	Method      string   `json:"method"                yaml:"method"`
+	Path        string   `json:"path"                  yaml:"path"`
+	OperationID string   `json:"operationId,omitempty" yaml:"operationId,omitempty"`
+	Summary     string   `json:"summary,omitempty"     yaml:"summary,omitempty"`
+	Tags        []string `json:"tags,omitempty"        yaml:"tags,omitempty"`
+	Deprecated  bool     `json:"deprecated,omitempty"  yaml:"deprecated,omitempty"`
+	// Names of the security schemes the operation accepts, its own or the spec's global ones;
+	// empty when it requires none.
+	Security []string `json:"security" yaml:"security"`
+}
+
+// Stats counts the main parts of a spec.
+type Stats struct {
+	Paths      int `json:"paths"      yaml:"paths"`
+	Operations int `json:"operations" yaml:"operations"`
+	Schemas    int `json:"schemas"    yaml:"schemas"`  // named schemas in components (definitions in Swagger 2.0)
+	Webhooks   int `json:"webhooks"   yaml:"webhooks"` // OpenAPI 3.1 webhooks, by name
+}
+
+// indexOperations lists the operations of doc, sorted by path and method, and counts its parts.
+// It returns a nil index and nil stats without a document.
+func indexOperations(doc *oas3.T, webhooks map[string]*oas3.PathItem) ([]Operation, *Stats) {
+	if doc == nil {
+		return nil, nil
+	}
+	stats := &Stats{Webhooks: len(webhooks)}
+	if doc.Components != nil {
+		stats.Schemas = len(doc.Components.Schemas)
+	}
+	if doc.Paths == nil {
+		return []Operation{}, stats
+	}
+
+	paths := doc.Paths.Map()
+	index := make([]Operation, 0, len(paths))
+	for _, path := range sortedKeys(paths) {
+		item := paths[path]
+		if item == nil {
+			continue
+		}
+		stats.Paths++
+		ops := item.Operations()
+		for _, method := range sortedKeys(ops) {
+			op := ops[method]
+			requirements := doc.Security
+			if op.Security != nil {
+				requirements = *op.Security
+			}
+			index = append(index, Operation{
+				Method:      method,
+				Path:        path,
+				OperationID: op.OperationID,
+				Summary:     firstNonEmpty(op.Summary, item.Summary),
+				Tags:        op.Tags,
+				Deprecated:  op.Deprecated,
+				Security:    schemeNames(requirements),
+			})
+		}
+	}
+	stats.Operations = len(index)
+	return index, stats
+}
+
+// schemeNames returns the sorted names of the schemes in a list of security requirements.
+func schemeNames(requirements oas3.SecurityRequirements) []string {
+	names := []string{}
+	for _, requirement := range requirements {
+		for name := range requirement {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// HasTag reports whether op is tagged tag, ignoring case.
+func (op Operation) HasTag(tag string) bool {
+	return slices.ContainsFunc(op.Tags, func(t string) bool { return strings.EqualFold(t, tag) })
+}
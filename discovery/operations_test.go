@@ -0,0 +1,71 @@
+package discovery_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Hossein-Roshandel/webswags/discovery"
+)
+
+const indexedSpec = `openapi: 3.1.0
+info: {title: Pets, version: "1"}
+security: [{apiKey: []}]
+paths:
+  /pets:
+    summary: Every pet
+    get:
+      operationId: listPets
+      tags: [pets]
+    post:
+      operationId: createPet
+      summary: Add a pet
+      tags: [pets, admin]
+      security: [{oauth: [write]}, {apiKey: []}]
+  /pets/{id}:
+    delete:
+      deprecated: true
+      security: []
+webhooks:
+  newPet:
+    post: {}
+components:
+  securitySchemes:
+    apiKey: {type: apiKey, in: header, name: X-Key}
+    oauth:
+      type: oauth2
+      flows: {clientCredentials: {tokenUrl: "https://auth.example.com/token", scopes: {write: Write}}}
+  schemas:
+    Pet: {type: object}
+    Error: {type: object}
+`
+
+func TestIndexOperations(t *testing.T) {
+	t.Parallel()
+	spec := parse(t, indexedSpec)
+
+	want := []discovery.Operation{
+		{Method: "GET", Path: "/pets", OperationID: "listPets", Summary: "Every pet", Tags: []string{"pets"},
+			Security: []string{"apiKey"}},
+		{Method: "POST", Path: "/pets", OperationID: "createPet", Summary: "Add a pet", Tags: []string{"pets", "admin"},
+			Security: []string{"apiKey", "oauth"}},
+		{Method: "DELETE", Path: "/pets/{id}", Deprecated: true, Security: []string{}},
+	}
+	if len(spec.Operations) != len(want) {
+		t.Fatalf("Operations = %+v, want %d", spec.Operations, len(want))
+	}
+	for i, op := range spec.Operations {
+		w := want[i]
+		if op.Method != w.Method || op.Path != w.Path || op.OperationID != w.OperationID || op.Summary != w.Summary ||
+			!slices.Equal(op.Tags, w.Tags) || op.Deprecated != w.Deprecated || !slices.Equal(op.Security, w.Security) {
+			t.Errorf("operation %d = %+v, want %+v", i, op, w)
+		}
+	}
+	if !spec.Operations[1].HasTag("ADMIN") || spec.Operations[0].HasTag("admin") {
+		t.Error("HasTag does not match tags ignoring case")
+	}
+
+	wantStats := discovery.Stats{Paths: 2, Operations: 3, Schemas: 2, Webhooks: 1}
+	if spec.Stats == nil || *spec.Stats != wantStats {
+		t.Errorf("Stats = %+v, want %+v", spec.Stats, wantStats)
+	}
+}
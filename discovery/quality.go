@@ -0,0 +1,141 @@
+package discovery
+
+import (
+	"strings"
+
+	oas3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+// Quality measures how completely a spec documents its operations. Every count is a number of
+// operations, out of Operations.
+type Quality struct {
+	Operations int `json:"operations" yaml:"operations"`
+	// Described counts operations with a description (a summary alone does not count).
+	Described int `json:"described" yaml:"described"`
+	// WithExamples counts operations with an example of a parameter, request or response.
+	WithExamples int `json:"withExamples" yaml:"withExamples"`
+	// WithErrorResponse counts operations documenting a 4xx, 5xx or default response.
+	WithErrorResponse int `json:"withErrorResponses" yaml:"withErrorResponses"`
+	// Secured counts operations with security requirements, or explicitly public.
+	Secured    int `json:"secured"    yaml:"secured"`
+	Deprecated int `json:"deprecated" yaml:"deprecated"`
+
+	// Each operation's own measurements, sorted by path and method.
+	OperationDetails []OperationQuality `json:"-" yaml:"-"`
+}
+
+// OperationQuality is the measurements of one operation (see Quality).
+type OperationQuality struct {
+	Operation        string `json:"operation"` // e.g. "GET /pets/{id}"
+	Pointer          string `json:"pointer"`   // JSON pointer to the operation in the OpenAPI 3 document
+	Described        bool   `json:"described"`
+	HasExamples      bool   `json:"hasExamples"`
+	HasErrorResponse bool   `json:"hasErrorResponses"`
+	Secured          bool   `json:"secured"`
+	Deprecated       bool   `json:"deprecated"`
+}
+
+// measureQuality measures the operations of doc; it returns nil without a document.
+func measureQuality(doc *oas3.T) *Quality {
+	if doc == nil {
+		return nil
+	}
+	q := &Quality{}
+	if doc.Paths == nil {
+		return q
+	}
+	paths := doc.Paths.Map()
+	for _, path := range sortedKeys(paths) {
+		item := paths[path]
+		if item == nil {
+			continue
+		}
+		ops := item.Operations()
+		for _, method := range sortedKeys(ops) {
+			op := ops[method]
+			q.add(OperationQuality{
+				Operation:        method + " " + path,
+				Pointer:          "/paths/" + escapePointer(path) + "/" + strings.ToLower(method),
+				Described:        strings.TrimSpace(op.Description) != "",
+				HasExamples:      hasExamples(item, op),
+				HasErrorResponse: hasErrorResponse(op),
+				Secured:          op.Security != nil || len(doc.Security) > 0,
+				Deprecated:       op.Deprecated,
+			})
+		}
+	}
+	return q
+}
+
+// add counts the measurements of one more operation.
+func (q *Quality) add(m OperationQuality) {
+	q.Operations++
+	q.OperationDetails = append(q.OperationDetails, m)
+	for _, counted := range []struct {
+		ok    bool
+		count *int
+	}{
+		{m.Described, &q.Described},
+		{m.HasExamples, &q.WithExamples},
+		{m.HasErrorResponse, &q.WithErrorResponse},
+		{m.Secured, &q.Secured},
+		{m.Deprecated, &q.Deprecated},
+	} {
+		if counted.ok {
+			*counted.count++
+		}
+	}
+}
+
+// hasExamples reports whether any parameter, request body or response of op carries an example,
+// on the media type or on the top-level schema.
+func hasExamples(item *oas3.PathItem, op *oas3.Operation) bool {
+	for _, params := range []oas3.Parameters{item.Parameters, op.Parameters} {
+		for _, param := range params {
+			if param == nil || param.Value == nil {
+				continue
+			}
+			p := param.Value
+			if p.Example != nil || len(p.Examples) > 0 || schemaHasExample(p.Schema) || contentHasExamples(p.Content) {
+				return true
+			}
+		}
+	}
+	if op.RequestBody != nil && op.RequestBody.Value != nil && contentHasExamples(op.RequestBody.Value.Content) {
+		return true
+	}
+	if op.Responses != nil {
+		for _, resp := range op.Responses.Map() {
+			if resp != nil && resp.Value != nil && contentHasExamples(resp.Value.Content) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func contentHasExamples(content oas3.Content) bool {
+	for _, media := range content {
+		if media != nil && (media.Example != nil || len(media.Examples) > 0 || schemaHasExample(media.Schema)) {
+			return true
+		}
+	}
+	return false
+}
+
+func schemaHasExample(schema *oas3.SchemaRef) bool {
+	return schema != nil && schema.Value != nil && schema.Value.Example != nil
+}
+
+// hasErrorResponse reports whether op documents a 4xx, 5xx (or 4XX, 5XX) or default response.
+func hasErrorResponse(op *oas3.Operation) bool {
+	if op.Responses == nil {
+		return false
+	}
+	for code := range op.Responses.Map() {
+		if code == "default" || strings.HasPrefix(code, "4") || strings.HasPrefix(code, "5") {
+			return true
+		}
+	}
+	return false
+}
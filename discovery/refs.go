@@ -0,0 +1,134 @@
+package discovery
+
+import (
+	"encoding/json"
+	"io/fs"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	oas3 "github.com/getkin/kin-openapi/openapi3"
+	"sigs.k8s.io/yaml"
+)
+
+// componentNameUnsafe matches runs of characters not allowed in an OpenAPI component name.
+var componentNameUnsafe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// externalRefs returns every file of fsys that the document at path (with content data) pulls
+// in through "$ref", directly or via the files it references, sorted and without duplicates.
+//
+// Only relative and file-system refs are followed: in-document refs ("#/components/...") and
+// remote ones ("https://...") are not files of this spec. Referenced files that do not exist
+// (yet) are still listed, so that creating them triggers a re-parse.
+func externalRefs(fsys fs.FS, path string, data []byte) []string {
+	seen := map[string]bool{filepath.Clean(path): true}
+	var deps []string
+
+	queue := refTargets(path, data)
+	for len(queue) > 0 {
+		dep := queue[0]
+		queue = queue[1:]
+		if seen[dep] {
+			continue
+		}
+		seen[dep] = true
+		deps = append(deps, dep)
+
+		depData, err := fs.ReadFile(fsys, dep)
+		if err != nil {
+			continue
+		}
+		queue = append(queue, refTargets(dep, depData)...)
+	}
+
+	sort.Strings(deps)
+	return deps
+}
+
+// refTargets returns the files named by the external "$ref"s in a single document.
+func refTargets(path string, data []byte) []string {
+	j, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil
+	}
+	var doc any
+	if err := json.Unmarshal(j, &doc); err != nil {
+		return nil
+	}
+
+	var targets []string
+	var visit func(node any)
+	visit = func(node any) {
+		switch n := node.(type) {
+		case map[string]any:
+			for key, value := range n {
+				if ref, ok := value.(string); ok && key == "$ref" {
+					if target, isFile := refFile(path, ref); isFile {
+						targets = append(targets, target)
+					}
+					continue
+				}
+				visit(value)
+			}
+		case []any:
+			for _, value := range n {
+				visit(value)
+			}
+		}
+	}
+	visit(doc)
+	return targets
+}
+
+// refFile resolves a "$ref" found in the file at path to the file it points to.
+// It reports false for in-document and remote refs, and for absolute ones ("/etc/app.yaml",
+// "file:///etc/app.yaml"), which are not followed: a spec only pulls in files relative to it.
+func refFile(path, ref string) (string, bool) {
+	location, _, _ := strings.Cut(ref, "#")
+	if location == "" {
+		return "", false
+	}
+	u, err := url.Parse(location)
+	if err != nil || (u.Scheme != "" && u.Scheme != "file") || u.Host != "" {
+		return "", false
+	}
+
+	target := filepath.FromSlash(u.Path)
+	if target == "" || strings.HasPrefix(u.Path, "/") || filepath.IsAbs(target) || filepath.VolumeName(target) != "" {
+		return "", false
+	}
+	return filepath.Join(filepath.Dir(path), target), true
+}
+
+// componentNamer returns the kin-openapi RefNameResolver used when bundling the spec at specPath.
+// An external component is named after its file relative to the spec's directory (without
+// extension) and the pointer inside it, e.g. "./schemas/order.yaml" becomes "schemas_order" and
+// "definitions.yaml#/Pet" becomes "definitions_Pet". Components of the root document keep their name.
+func componentNamer(specPath string) oas3.RefNameResolver {
+	dir := filepath.Dir(specPath)
+	return func(doc *oas3.T, ref oas3.ComponentRef) string {
+		if name, found := oas3.ReferencesComponentInRootDocument(doc, ref); found {
+			return path.Base(name)
+		}
+
+		location := ref.RefPath()
+		file := location.Path
+		if rel, err := filepath.Rel(dir, filepath.FromSlash(file)); err == nil {
+			file = filepath.ToSlash(rel)
+		}
+		file = strings.TrimSuffix(file, path.Ext(file))
+
+		pointer := strings.Trim(location.Fragment, "/")
+		pointer = strings.TrimPrefix(pointer, "components/"+ref.CollectionName()+"/")
+		pointer = strings.TrimPrefix(pointer, "definitions/")
+
+		name := file
+		if pointer != "" {
+			name += "_" + pointer
+		}
+		return strings.Trim(componentNameUnsafe.ReplaceAllString(name, "_"), "_.")
+	}
+}
@@ -0,0 +1,147 @@
+package discovery_test
+
+import (
+	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Hossein-Roshandel/webswags/discovery"
+)
+
+// refSpec is an OpenAPI document whose only schema is the $ref ref.
+func refSpec(title, ref string) string {
+	return `openapi: 3.0.3
+info: {title: ` + title + `, version: "1.0.0"}
+paths: {}
+components:
+  schemas:
+    Item: {$ref: "` + ref + `"}
+`
+}
+
+func TestSpecFilesStayUnderTheSourceRoot(t *testing.T) {
+	t.Parallel()
+	base := t.TempDir()
+	root := filepath.Join(base, "apis")
+	secret := filepath.Join(base, "secret", "secret.yaml")
+	writeFile(t, base, "secret/secret.yaml", "Secret: {type: string, description: token}\n")
+	writeFile(t, root, "schemas.yaml", "Pet: {type: object}\n")
+	writeFile(t, root, "pets.yaml", refSpec("Pets", "./schemas.yaml#/Pet"))
+	writeFile(t, root, "linked.yaml", refSpec("Linked", "./link.yaml#/Secret"))
+	writeFile(t, root, "absolute.yaml", refSpec("Absolute", secret+"#/Secret"))
+	writeFile(t, root, "file-url.yaml", refSpec("FileURL", "file://"+filepath.ToSlash(secret)+"#/Secret"))
+	if err := os.Symlink(secret, filepath.Join(root, "link.yaml")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	result, err := discovery.Discover(t.Context(), root, discovery.DiscoverOptions{})
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	specs := make(map[string]discovery.SwaggerSpec)
+	for _, spec := range result.Specs {
+		specs[spec.Title] = spec
+	}
+
+	tests := []struct {
+		title    string
+		file     string // served relative to the root
+		wantFile bool
+	}{
+		{title: "Pets", file: "schemas.yaml", wantFile: true},
+		{title: "Pets", file: "pets.yaml", wantFile: true},
+		{title: "Linked", file: "link.yaml"},
+	}
+	for _, tt := range tests {
+		spec, ok := specs[tt.title]
+		if !ok {
+			t.Fatalf("spec %s not discovered", tt.title)
+		}
+		if _, got := spec.FileAt(tt.file); got != tt.wantFile {
+			t.Errorf("%s: FileAt(%s) = %t, want %t", tt.title, tt.file, got, tt.wantFile)
+		}
+	}
+
+	for _, title := range []string{"Absolute", "FileURL"} {
+		spec := specs[title]
+		if slices.Contains(spec.Files(), secret) {
+			t.Errorf("%s: files %q include the absolute ref", title, spec.Files())
+		}
+		if spec.DocV3 != nil {
+			t.Errorf("%s: loaded the absolutely referenced file", title)
+		}
+	}
+}
+
+// splitSpec is a spec split across files: an order schema that references a shared definition.
+var splitSpec = fstest.MapFS{
+	"apis/orders/openapi.yaml": {Data: []byte(`openapi: 3.0.3
+info: {title: Orders, version: "1.0.0"}
+paths:
+  /orders:
+    get:
+      responses:
+        "200":
+          description: Orders
+          content:
+            application/json:
+              schema: {$ref: "./schemas/order.yaml"}
+`)},
+	"apis/orders/schemas/order.yaml": {Data: []byte(`type: object
+properties:
+  pet: {$ref: "../definitions.yaml#/Pet"}
+`)},
+	"apis/orders/definitions.yaml": {Data: []byte("Pet: {type: object}\n")},
+	"apis/orders/unused.yaml":      {Data: []byte("Unused: {type: object}\n")},
+}
+
+func TestDiscoverBundlesExternalRefs(t *testing.T) {
+	t.Parallel()
+	result, err := discovery.DiscoverFS(t.Context(), splitSpec, ".", discovery.DiscoverOptions{})
+	if err != nil {
+		t.Fatalf("DiscoverFS: %v", err)
+	}
+	if len(result.Specs) != 1 {
+		t.Fatalf("found %d specs, want 1", len(result.Specs))
+	}
+	spec := result.Specs[0]
+
+	want := []string{"apis/orders/definitions.yaml", "apis/orders/schemas/order.yaml"}
+	if !slices.Equal(spec.Dependencies, want) {
+		t.Errorf("Dependencies = %q, want %q", spec.Dependencies, want)
+	}
+	for rel, wantFile := range map[string]bool{
+		"apis/orders/openapi.yaml":       true,
+		"apis/orders/schemas/order.yaml": true,
+		"apis/orders/unused.yaml":        false,
+	} {
+		if _, ok := spec.FileAt(rel); ok != wantFile {
+			t.Errorf("FileAt(%s) = %t, want %t", rel, ok, wantFile)
+		}
+	}
+
+	if spec.Bundled == nil {
+		t.Fatal("spec is not bundled")
+	}
+	var bundled struct {
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(spec.Bundled, &bundled); err != nil {
+		t.Fatalf("bundle is not JSON: %v", err)
+	}
+	for _, name := range []string{"schemas_order", "definitions_Pet"} {
+		if _, ok := bundled.Components.Schemas[name]; !ok {
+			t.Errorf("bundle has no component %s, has %q", name, slices.Sorted(maps.Keys(bundled.Components.Schemas)))
+		}
+	}
+	if strings.Contains(string(spec.Bundled), ".yaml") {
+		t.Errorf("bundle still references files: %s", spec.Bundled)
+	}
+}
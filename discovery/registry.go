@@ -0,0 +1,569 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// watchDebounce is how long the watcher waits for a burst of filesystem events
+	// (editor save dances, git checkouts) to settle before re-parsing.
+	watchDebounce = 250 * time.Millisecond
+	// revisionCacheSize bounds how many specs parsed at past revisions SpecAt keeps.
+	revisionCacheSize = 32
+)
+
+// Registry is a concurrency-safe, live catalog of the specs found under a root directory and
+// in any additional sources.
+//
+// Load performs the initial scan; Watch keeps the catalog in sync with the root directory by
+// incrementally re-parsing, adding and removing specs as files change. Additional sources
+// are not watched: they are read again on every Load. Readers always get a consistent
+// snapshot via Specs.
+type Registry struct {
+	root    string
+	opts    DiscoverOptions
+	sources []Source
+
+	mu       sync.RWMutex
+	byPath   map[string]SwaggerSpec // specs of the root directory, keyed by spec file path
+	extra    []SwaggerSpec          // specs of the additional sources, variants not yet merged
+	specs    []SwaggerSpec          // sorted snapshot handed out to readers
+	services []Service              // specs grouped by API, rebuilt with the snapshot
+	search   *searchIndex           // full-text index of the services, rebuilt with the snapshot
+	entries  map[string]ReportEntry // report entries of the root directory, keyed by path
+	extraRep map[string]ReportEntry // report entries of the additional sources, keyed by entryKey
+	ignore   *ignoreSet             // rebuilt on every Load so edited ignore files take effect
+	hooks    []func([]Service)      // called with every new catalog (see OnRebuild)
+
+	hookMu sync.Mutex // serialises the calls of the hooks (see notify)
+
+	revisions *revisionCache // specs parsed at past commits (see SpecAt)
+}
+
+// NewRegistry returns an empty registry for the given project root, plus any additional
+// sources whose specs are merged into the same catalog. root may be "" to use sources only.
+// Call Load to populate it and Watch to keep it up to date.
+func NewRegistry(root string, opts DiscoverOptions, sources ...Source) *Registry {
+	return &Registry{
+		root:      root,
+		opts:      opts,
+		sources:   sources,
+		byPath:    make(map[string]SwaggerSpec),
+		entries:   make(map[string]ReportEntry),
+		extraRep:  make(map[string]ReportEntry),
+		ignore:    newIgnoreSet(osFS{}, root, opts),
+		revisions: newRevisionCache(revisionCacheSize),
+	}
+}
+
+// Root returns the directory the registry scans.
+func (r *Registry) Root() string {
+	return r.root
+}
+
+// Load (re)scans the whole root and every additional source, and replaces the registry contents.
+// It fails if the root cannot be walked; a source that cannot be read is logged and recorded
+// in the report as rejected, and contributes no specs.
+func (r *Registry) Load(ctx context.Context) error {
+	start := time.Now()
+	found := scan{entries: make(map[string]ReportEntry)}
+	if r.root != "" {
+		var err error
+		if found, err = discoverFiles(ctx, osFS{}, r.root, r.opts); err != nil {
+			return err
+		}
+		found.tag(r.origin(), r.root)
+	}
+
+	var extra []SwaggerSpec
+	extraRep := make(map[string]ReportEntry)
+	for _, src := range r.sources {
+		fromSource, err := scanSource(ctx, src, r.opts)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			slog.Warn("Failed to read spec source", "origin", src.Origin(), "error", err)
+			entry := ReportEntry{
+				Path: src.Origin(), Origin: src.Origin(),
+				Status: FileRejected, Reason: reasonUnread, Error: err.Error(),
+			}
+			extraRep[entryKey(entry)] = entry
+			continue
+		}
+		extra = append(extra, fromSource.files...)
+		for _, entry := range fromSource.entries {
+			extraRep[entryKey(entry)] = entry
+		}
+	}
+	slog.Debug("Discovery finished",
+		"candidates", len(found.timings), "sources", len(r.sources), "elapsed", time.Since(start))
+
+	byPath := make(map[string]SwaggerSpec, len(found.files))
+	for _, spec := range found.files {
+		byPath[spec.Path] = spec
+	}
+
+	r.mu.Lock()
+	r.byPath = byPath
+	r.entries = found.entries
+	r.extra = extra
+	r.extraRep = extraRep
+	r.ignore = newIgnoreSet(osFS{}, r.root, r.opts)
+	r.rebuildLocked()
+	r.mu.Unlock()
+	r.notify()
+	return nil
+}
+
+// origin is the Origin of specs found in the root directory.
+func (r *Registry) origin() string {
+	return DirSource{Path: r.root}.Origin()
+}
+
+// OnRebuild registers fn to be called with the services of every new catalog, from Load and
+// from the watcher, so what is derived from the specs is computed once per change rather than
+// on every request. fn runs once the registry is unlocked, so readers are not held up while it
+// works, and never concurrently with itself; call OnRebuild before Load.
+func (r *Registry) OnRebuild(fn func(services []Service)) {
+	r.mu.Lock()
+	r.hooks = append(r.hooks, fn)
+	r.mu.Unlock()
+}
+
+// Specs returns a snapshot of all known specs, sorted by service name.
+// The returned slice is owned by the caller.
+func (r *Registry) Specs() []SwaggerSpec {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]SwaggerSpec, len(r.specs))
+	copy(out, r.specs)
+	return out
+}
+
+// Services returns a snapshot of all known services, sorted by name.
+// The returned slice is owned by the caller.
+func (r *Registry) Services() []Service {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]Service, len(r.services))
+	copy(out, r.services)
+	return out
+}
+
+// Search finds services, operations and named schemas of the default version of every service
+// whose text matches every word of query, as a word or a word prefix, best first. It returns at
+// most limit hits; limit <= 0 means no limit.
+func (r *Registry) Search(query string, limit int) []SearchHit {
+	r.mu.RLock()
+	index := r.search
+	r.mu.RUnlock()
+	return index.search(query, limit)
+}
+
+// Report returns the discovery report for the current state of the tree: every candidate
+// file and skipped directory, with what discovery did with it and why.
+func (r *Registry) Report() DiscoveryReport {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := make(map[string]ReportEntry, len(r.entries)+len(r.extraRep))
+	for _, entry := range r.entries {
+		entries[entryKey(entry)] = entry
+	}
+	for key, entry := range r.extraRep {
+		entries[key] = entry
+	}
+	return buildReport(r.root, entries, r.specs)
+}
+
+// SpecAt returns spec as it was at rev (see SwaggerSpec.AtRevision). Commits never change, so
+// the specs of the most recently requested revisions are kept parsed.
+func (r *Registry) SpecAt(ctx context.Context, spec SwaggerSpec, rev string) (SwaggerSpec, error) {
+	sr, err := openSpecRepo(spec)
+	if err != nil {
+		return SwaggerSpec{}, err
+	}
+	commit, err := sr.resolve(rev)
+	if err != nil {
+		return SwaggerSpec{}, err
+	}
+
+	key := strings.Join([]string{commit.Hash.String(), spec.Origin, spec.Path, spec.Slug, spec.VersionKey}, "\x00")
+	if at, ok := r.revisions.get(key); ok {
+		return at, nil
+	}
+
+	at, err := sr.specAt(ctx, spec, commit)
+	if err != nil {
+		return SwaggerSpec{}, err
+	}
+	r.revisions.add(key, at)
+	return at, nil
+}
+
+// Service returns the service with the given slug.
+func (r *Registry) Service(slug string) (Service, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, svc := range r.services {
+		if svc.Slug == slug {
+			return svc, true
+		}
+	}
+	return Service{}, false
+}
+
+// Lookup returns the spec with the given slug.
+func (r *Registry) Lookup(slug string) (SwaggerSpec, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, spec := range r.specs {
+		if spec.Slug == slug {
+			return spec, true
+		}
+	}
+	return SwaggerSpec{}, false
+}
+
+// LookupByName returns the first spec whose display name (Service) matches name.
+// It exists to resolve URLs minted before slugs were introduced.
+func (r *Registry) LookupByName(name string) (SwaggerSpec, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, spec := range r.specs {
+		if spec.Service == name {
+			return spec, true
+		}
+	}
+	return SwaggerSpec{}, false
+}
+
+// Watch monitors the root directory tree and keeps the registry in sync until ctx is cancelled.
+// New directories are watched as they appear; events are debounced so that a burst of changes,
+// such as a git checkout, re-parses each file once and rebuilds the catalog once.
+func (r *Registry) Watch(ctx context.Context) error {
+	if r.root == "" {
+		return nil
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create filesystem watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	if addErr := r.addWatchTree(watcher, r.root); addErr != nil {
+		return addErr
+	}
+	slog.Info("Watching for spec changes", "root", r.root)
+
+	pending := make(map[string]struct{})
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			pending[event.Name] = struct{}{}
+			timer.Reset(watchDebounce)
+
+		case watchErr, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			slog.Warn("Filesystem watcher error", "error", watchErr)
+
+		case <-timer.C:
+			paths := make([]string, 0, len(pending))
+			for path := range pending {
+				paths = append(paths, path)
+			}
+			clear(pending)
+			sort.Strings(paths)
+
+			if slices.ContainsFunc(paths, isIgnoreFile) {
+				// Ignore rules changed: anything may have appeared or disappeared, so rescan.
+				r.reload(ctx, watcher)
+				continue
+			}
+			r.apply(watcher, paths)
+		}
+	}
+}
+
+// apply reconciles a burst of changed paths with the registry, then rebuilds the catalog once if
+// any spec changed, so a checkout touching many files does not rebuild it for each.
+func (r *Registry) apply(watcher *fsnotify.Watcher, paths []string) {
+	changed := false
+	var dependents []string
+	for _, path := range paths {
+		changed = r.refresh(watcher, path) || changed
+		dependents = append(dependents, r.dependents(path)...)
+	}
+	slices.Sort(dependents)
+	for _, specPath := range slices.Compact(dependents) {
+		if _, done := slices.BinarySearch(paths, specPath); !done {
+			changed = r.refreshFile(specPath) || changed
+		}
+	}
+	if changed {
+		r.rebuild()
+	}
+}
+
+// reload rescans the whole tree and makes sure every non-ignored directory is watched.
+func (r *Registry) reload(ctx context.Context, watcher *fsnotify.Watcher) {
+	slog.Info("Ignore rules changed, rescanning", "root", r.root)
+	if err := r.Load(ctx); err != nil {
+		slog.Warn("Failed to rescan specs", "root", r.root, "error", err)
+		return
+	}
+	if err := r.addWatchTree(watcher, r.root); err != nil {
+		slog.Warn("Failed to watch directory tree", "root", r.root, "error", err)
+	}
+}
+
+// refresh reconciles a single changed path with the registry, leaving the catalog to be rebuilt
+// by the caller. It reports whether any spec changed.
+func (r *Registry) refresh(watcher *fsnotify.Watcher, path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		// Removed or renamed away: drop the spec itself and anything that lived below it.
+		return r.removeTree(path)
+	}
+	if why := r.ignoreSet().reason(path, info.IsDir()); why != "" {
+		removed := r.removeTree(path)
+		r.recordSkip(path, info.IsDir(), why)
+		return removed
+	}
+
+	if info.IsDir() {
+		if addErr := r.addWatchTree(watcher, path); addErr != nil {
+			slog.Warn("Failed to watch new directory", "path", path, "error", addErr)
+		}
+		return r.scanTree(path)
+	}
+	return r.refreshFile(path)
+}
+
+// dependents lists the specs that pull in the file at path through an external $ref.
+func (r *Registry) dependents(path string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var dependents []string
+	for specPath, spec := range r.byPath {
+		if slices.Contains(spec.Dependencies, path) {
+			dependents = append(dependents, specPath)
+		}
+	}
+	return dependents
+}
+
+// refreshFile re-parses one file and adds, replaces or removes its spec accordingly, leaving the
+// catalog to be rebuilt by the caller. It reports whether any spec changed.
+func (r *Registry) refreshFile(path string) bool {
+	info, statErr := os.Stat(path)
+	if statErr != nil || !isCandidateFile(path) {
+		return false
+	}
+	why := skipReason(r.ignoreSet(), r.opts, path, info.Size())
+	if why == "" && !r.opts.withinDepth(r.depthOf(filepath.Dir(path))) {
+		why = depthReason(r.opts)
+	}
+	if why != "" {
+		removed := r.removeTree(path)
+		r.recordSkip(path, false, why)
+		return removed
+	}
+
+	start := time.Now()
+	spec, err := parseSwaggerSpec(osFS{}, path)
+	spec.Origin, spec.sourceRoot = r.origin(), r.root
+	entry := parsedEntry(candidate{path: path, size: info.Size()}, time.Since(start), err)
+	entry.Origin = r.origin()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries[path] = entry
+	_, existed := r.byPath[path]
+	if err != nil {
+		if existed {
+			delete(r.byPath, path)
+			slog.Info("Removed OpenAPI spec (no longer valid)", "path", path, "error", err)
+		}
+		return existed
+	}
+
+	r.byPath[path] = spec
+	if existed {
+		slog.Info("Reloaded OpenAPI spec", "service", spec.Service, "version", spec.Version, "path", path)
+	} else {
+		slog.Info("Discovered OpenAPI spec", "service", spec.Service, "version", spec.Version, "path", path)
+	}
+	logDiagnostics(spec)
+	return true
+}
+
+// recordSkip records in the report that path was passed over. Files are only recorded if they
+// could hold a spec.
+func (r *Registry) recordSkip(path string, isDir bool, reason string) {
+	if !isDir && !isCandidateFile(path) {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[path] = ReportEntry{Path: path, Origin: r.origin(), Dir: isDir, Status: FileIgnored, Reason: reason}
+}
+
+// scanTree parses every candidate file below dir, e.g. after a directory was created or moved in.
+// It reports whether any spec changed.
+func (r *Registry) scanTree(dir string) bool {
+	ignore := r.ignoreSet()
+	changed := false
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return nil //nolint:nilerr // Unreadable entries are skipped, as in DiscoverSwaggerSpecs.
+		}
+		if why := ignore.reason(path, d.IsDir()); why != "" {
+			r.recordSkip(path, d.IsDir(), why)
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			changed = r.refreshFile(path) || changed
+		}
+		return nil
+	})
+	return changed
+}
+
+// removeTree drops the spec at path and every spec located below it, with their report entries.
+// It reports whether any spec was dropped.
+func (r *Registry) removeTree(path string) bool {
+	prefix := path + string(filepath.Separator)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for entryPath := range r.entries {
+		if entryPath == path || strings.HasPrefix(entryPath, prefix) {
+			delete(r.entries, entryPath)
+		}
+	}
+
+	removed := 0
+	for specPath := range r.byPath {
+		if specPath == path || strings.HasPrefix(specPath, prefix) {
+			delete(r.byPath, specPath)
+			removed++
+			slog.Info("Removed OpenAPI spec", "path", specPath)
+		}
+	}
+	return removed > 0
+}
+
+// addWatchTree registers dir and all of its non-ignored subdirectories with the watcher.
+func (r *Registry) addWatchTree(watcher *fsnotify.Watcher, dir string) error {
+	ignore := r.ignoreSet()
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			if path == dir {
+				return fmt.Errorf("failed to watch %q: %w", dir, walkErr)
+			}
+			slog.Debug("Skipping path due to access error", "path", path, "error", walkErr)
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if !r.opts.withinDepth(r.depthOf(path)) || ignore.ignored(path, true) {
+			return filepath.SkipDir
+		}
+		if addErr := watcher.Add(path); addErr != nil {
+			if errors.Is(addErr, fs.ErrNotExist) {
+				return filepath.SkipDir
+			}
+			return fmt.Errorf("failed to watch %q: %w", path, addErr)
+		}
+		return nil
+	})
+}
+
+// ignoreSet returns the ignore rules currently in effect.
+func (r *Registry) ignoreSet() *ignoreSet {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.ignore
+}
+
+// depthOf returns how many directory levels dir lies below the root (the root itself is 0).
+func (r *Registry) depthOf(dir string) int {
+	rel, err := filepath.Rel(r.root, dir)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return len(strings.Split(rel, string(filepath.Separator)))
+}
+
+// rebuild refreshes the sorted snapshot and tells the hooks about it.
+func (r *Registry) rebuild() {
+	r.mu.Lock()
+	r.rebuildLocked()
+	r.mu.Unlock()
+	r.notify()
+}
+
+// rebuildLocked refreshes the sorted snapshot. The caller must hold r.mu for writing, and call
+// notify once it is released.
+func (r *Registry) rebuildLocked() {
+	files := make([]SwaggerSpec, 0, len(r.byPath)+len(r.extra))
+	for _, spec := range r.byPath {
+		files = append(files, spec)
+	}
+	files = append(files, r.extra...)
+	r.specs, r.services = buildCatalog(files)
+	r.search = buildSearchIndex(r.services)
+}
+
+// notify calls the hooks (see OnRebuild) with the current catalog. Calls are serialised and each
+// reads the catalog once it has its turn, so the last one always sees the latest catalog even
+// when rebuilds race.
+func (r *Registry) notify() {
+	r.hookMu.Lock()
+	defer r.hookMu.Unlock()
+
+	r.mu.RLock()
+	hooks, services := r.hooks, r.services
+	r.mu.RUnlock()
+	for _, hook := range hooks {
+		hook(slices.Clone(services))
+	}
+}
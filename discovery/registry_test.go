@@ -0,0 +1,159 @@
+package discovery_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Hossein-Roshandel/webswags/discovery"
+)
+
+func TestRegistryOnRebuild(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "pets.yaml"), []byte(petsSpec), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	registry := discovery.NewRegistry(root, discovery.DiscoverOptions{})
+	var rebuilds [][]discovery.Service
+	registry.OnRebuild(func(services []discovery.Service) {
+		rebuilds = append(rebuilds, services)
+	})
+
+	if err := registry.Load(t.Context()); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	store := "openapi: 3.0.3\ninfo: {title: Store, version: \"1\"}\npaths: {}\n"
+	if err := os.WriteFile(filepath.Join(root, "store.yaml"), []byte(store), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Load(t.Context()); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if len(rebuilds) != 2 {
+		t.Fatalf("hook called %d times, want 2", len(rebuilds))
+	}
+	for i, want := range []int{1, 2} {
+		if got := len(rebuilds[i]); got != want {
+			t.Errorf("rebuild %d had %d services, want %d", i+1, got, want)
+		}
+	}
+}
+
+// writeFile writes data to name below root, creating its directory.
+func writeFile(t *testing.T, root, name, data string) {
+	t.Helper()
+	path := filepath.Join(root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// eventually fails the test unless done returns true within a few seconds.
+func eventually(t *testing.T, what string, done func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); !done(); time.Sleep(20 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+	}
+}
+
+// settle waits until count has not changed for a while, so no debounced change is pending.
+func settle(count *atomic.Int32) int32 {
+	last := count.Load()
+	for {
+		time.Sleep(600 * time.Millisecond)
+		if now := count.Load(); now != last {
+			last = now
+			continue
+		}
+		return last
+	}
+}
+
+// titles returns the titles of the specs of registry, sorted.
+func titles(registry *discovery.Registry) []string {
+	var out []string
+	for _, spec := range registry.Specs() {
+		out = append(out, spec.Title)
+	}
+	slices.Sort(out)
+	return out
+}
+
+func TestRegistryWatch(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	writeFile(t, root, "pets.yaml", petsSpec)
+	writeFile(t, root, "store.yaml", "openapi: 3.0.3\ninfo: {title: Store, version: \"1\"}\npaths: {}\n")
+	writeFile(t, root, "users.yaml", `openapi: 3.0.3
+info: {title: Users, version: "1"}
+paths: {}
+components:
+  schemas:
+    User: {$ref: "./schemas.yaml#/User"}
+`)
+	writeFile(t, root, "schemas.yaml", "User: {type: object}\n")
+
+	registry := discovery.NewRegistry(root, discovery.DiscoverOptions{})
+	var rebuilds atomic.Int32
+	registry.OnRebuild(func([]discovery.Service) { rebuilds.Add(1) })
+	if err := registry.Load(t.Context()); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error)
+	go func() { stopped <- registry.Watch(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-stopped; err != nil {
+			t.Errorf("Watch: %v", err)
+		}
+	})
+
+	// The watcher starts asynchronously: write a spec until it is picked up, giving each write
+	// time to settle.
+	probes := 0
+	eventually(t, "the watcher to start", func() bool {
+		if probes++; probes%25 == 1 {
+			writeFile(t, root, "probe/probe.yaml", "openapi: 3.0.3\ninfo: {title: Probe, version: \"1\"}\npaths: {}\n")
+		}
+		return slices.Contains(titles(registry), "Probe")
+	})
+	before := settle(&rebuilds)
+
+	// A burst of changes is applied at once.
+	writeFile(t, root, "pets.yaml", "openapi: 3.0.3\ninfo: {title: Cats, version: \"1\"}\npaths: {}\n")
+	writeFile(t, root, "orders/orders.yaml", "openapi: 3.0.3\ninfo: {title: Orders, version: \"1\"}\npaths: {}\n")
+	if err := os.Remove(filepath.Join(root, "store.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(filepath.Join(root, "probe")); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Cats", "Orders", "Users"}
+	eventually(t, "the burst to be applied", func() bool { return slices.Equal(titles(registry), want) })
+	if got := settle(&rebuilds) - before; got != 1 {
+		t.Errorf("the burst rebuilt the catalog %d times, want once", got)
+	}
+
+	// Specs are re-parsed when a file they reference changes.
+	writeFile(t, root, "schemas.yaml", "User: {type: object, description: A user}\n")
+	eventually(t, "the referencing spec to be re-parsed", func() bool {
+		spec, ok := registry.LookupByName("Users")
+		if !ok || spec.DocV3 == nil {
+			return false
+		}
+		user := spec.DocV3.Components.Schemas["User"]
+		return user != nil && user.Value != nil && user.Value.Description == "A user"
+	})
+}
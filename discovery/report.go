@@ -0,0 +1,127 @@
+package discovery
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// FileStatus is what discovery did with a file or directory.
+type FileStatus string
+
+const (
+	// FileAccepted marks a file that was parsed into a spec (possibly one with errors, see Health).
+	FileAccepted FileStatus = "accepted"
+	// FileIgnored marks a path that was never parsed: excluded by ignore rules or options.
+	FileIgnored FileStatus = "ignored"
+	// FileRejected marks a file that was read but is not an OpenAPI/Swagger spec, or could not be read.
+	FileRejected FileStatus = "rejected"
+)
+
+// ReportEntry explains what discovery did with one candidate file, or with a whole directory
+// it did not descend into.
+type ReportEntry struct {
+	Path     string        `json:"path"`
+	Origin   string        `json:"origin,omitempty"` // Source the path belongs to (see Source)
+	Dir      bool          `json:"dir,omitempty"`
+	Status   FileStatus    `json:"status"`
+	Reason   string        `json:"reason,omitempty"`   // why the path was ignored or rejected
+	Error    string        `json:"error,omitempty"`    // parse error, or the first error of an accepted spec
+	Size     int64         `json:"size,omitempty"`     // bytes, for files
+	Duration time.Duration `json:"duration,omitempty"` // time spent reading and parsing
+	Slug     string        `json:"slug,omitempty"`     // spec the file belongs to (accepted files only)
+	Health   string        `json:"health,omitempty"`   // health of that spec (accepted files only)
+}
+
+// DiscoveryReport lists every candidate file (.yaml, .yml, .json) and every skipped directory
+// below the root, sorted by path, with what discovery did with it and why. Reports spanning
+// several sources have no Root; their entries are sorted by origin first.
+type DiscoveryReport struct {
+	Root     string        `json:"root"`
+	Accepted int           `json:"accepted"`
+	Ignored  int           `json:"ignored"`
+	Rejected int           `json:"rejected"`
+	Entries  []ReportEntry `json:"entries"`
+}
+
+// Reasons recorded for ignored and rejected paths, besides the ignore rule descriptions.
+const (
+	reasonNotSpec   = "not an OpenAPI/Swagger spec"
+	reasonUnread    = "could not be read"
+	reasonSymlink   = "symlink not followed (enable FollowSymlinks)"
+	reasonNoInclude = "does not match any include glob"
+)
+
+// skipReason explains why a candidate file of the given size must not be parsed, or returns "".
+func skipReason(ignore *ignoreSet, opts DiscoverOptions, path string, size int64) string {
+	if !ignore.included(path) {
+		return reasonNoInclude
+	}
+	if !opts.withinSize(size) {
+		return fmt.Sprintf("larger than the maximum file size (%d bytes)", opts.MaxFileSize)
+	}
+	return ""
+}
+
+// depthReason is the reason recorded for a directory below MaxDepth.
+func depthReason(opts DiscoverOptions) string {
+	return fmt.Sprintf("deeper than the maximum depth (%d)", opts.MaxDepth)
+}
+
+// parsedEntry records the outcome of parsing one candidate file.
+func parsedEntry(c candidate, elapsed time.Duration, err error) ReportEntry {
+	entry := ReportEntry{Path: c.path, Status: FileAccepted, Size: c.size, Duration: elapsed}
+	if err != nil {
+		entry.Status = FileRejected
+		entry.Reason = reasonNotSpec
+		entry.Error = err.Error()
+	}
+	return entry
+}
+
+// buildReport completes entries with the spec each accepted file ended up in, and returns
+// them as a report sorted by origin and path.
+func buildReport(root string, entries map[string]ReportEntry, specs []SwaggerSpec) DiscoveryReport {
+	owner := make(map[string]SwaggerSpec, len(specs))
+	for _, spec := range specs {
+		for _, v := range spec.Variants {
+			owner[entryKey(ReportEntry{Origin: spec.Origin, Path: v.Path})] = spec
+		}
+	}
+
+	report := DiscoveryReport{Root: root, Entries: make([]ReportEntry, 0, len(entries))}
+	for _, entry := range entries {
+		switch entry.Status {
+		case FileAccepted:
+			report.Accepted++
+			if spec, ok := owner[entryKey(entry)]; ok {
+				entry.Slug = spec.Slug
+				entry.Health = spec.Health()
+				entry.Error = firstError(spec)
+			}
+		case FileIgnored:
+			report.Ignored++
+		case FileRejected:
+			report.Rejected++
+		}
+		report.Entries = append(report.Entries, entry)
+	}
+
+	sort.Slice(report.Entries, func(i, j int) bool {
+		if report.Entries[i].Origin != report.Entries[j].Origin {
+			return report.Entries[i].Origin < report.Entries[j].Origin
+		}
+		return report.Entries[i].Path < report.Entries[j].Path
+	})
+	return report
+}
+
+// firstError returns the message of the spec's first error diagnostic, or "".
+func firstError(spec SwaggerSpec) string {
+	for _, d := range spec.Diagnostics {
+		if d.Severity == SeverityError {
+			return d.Message
+		}
+	}
+	return ""
+}
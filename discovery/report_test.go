@@ -0,0 +1,63 @@
+package discovery_test
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Hossein-Roshandel/webswags/discovery"
+)
+
+func TestDiscoverReport(t *testing.T) {
+	t.Parallel()
+	big := specFile("Big", "1")
+	big.Data = append(big.Data, strings.Repeat("# padding\n", 100)...)
+	fsys := fstest.MapFS{
+		"pets.yaml":             specFile("Pets", "1"),
+		"store.yaml":            {Data: []byte("openapi: 3.0.3\ninfo: {title: Store}\npaths: {}\n")},
+		"package.json":          {Data: []byte(`{"name": "apis"}`)},
+		"big.yaml":              big,
+		"fixtures/orders.yaml":  specFile("Orders", "1"),
+		"deep/nested/old.yaml":  specFile("Old", "1"),
+		"deep/nested/notes.txt": {Data: []byte("not a candidate")},
+	}
+	opts := discovery.DiscoverOptions{MaxFileSize: 200, MaxDepth: 1, Exclude: []string{"fixtures/"}}
+	result, err := discovery.DiscoverFS(t.Context(), fsys, ".", opts)
+	if err != nil {
+		t.Fatalf("DiscoverFS: %v", err)
+	}
+	report := result.Report
+
+	tests := []struct {
+		path       string
+		wantStatus discovery.FileStatus
+		wantReason string // substring
+		wantSlug   string
+		wantHealth string
+	}{
+		{path: "big.yaml", wantStatus: discovery.FileIgnored, wantReason: "larger than the maximum file size"},
+		{path: "deep/nested", wantStatus: discovery.FileIgnored, wantReason: "deeper than the maximum depth (1)"},
+		{path: "fixtures", wantStatus: discovery.FileIgnored, wantReason: `matches "fixtures/" in exclude patterns`},
+		{path: "package.json", wantStatus: discovery.FileRejected, wantReason: "not an OpenAPI/Swagger spec"},
+		{path: "pets.yaml", wantStatus: discovery.FileAccepted, wantSlug: "pets", wantHealth: discovery.HealthValid},
+		{path: "store.yaml", wantStatus: discovery.FileAccepted, wantSlug: "store", wantHealth: discovery.HealthErrors},
+	}
+	if len(report.Entries) != len(tests) {
+		t.Fatalf("report has %d entries (%+v), want %d", len(report.Entries), report.Entries, len(tests))
+	}
+	for i, tt := range tests {
+		entry := report.Entries[i]
+		if entry.Path != tt.path || entry.Status != tt.wantStatus || !strings.Contains(entry.Reason, tt.wantReason) ||
+			entry.Slug != tt.wantSlug || entry.Health != tt.wantHealth {
+			t.Errorf("entry %d = %+v, want %s %s (%s) slug %q health %q",
+				i, entry, tt.path, tt.wantStatus, tt.wantReason, tt.wantSlug, tt.wantHealth)
+		}
+	}
+	if got := report.Entries[len(report.Entries)-1].Error; !strings.Contains(got, "version") {
+		t.Errorf("error of store.yaml = %q, want its first diagnostic", got)
+	}
+	if report.Accepted != 2 || report.Ignored != 3 || report.Rejected != 1 {
+		t.Errorf("report counts %d accepted, %d ignored, %d rejected; want 2, 3, 1",
+			report.Accepted, report.Ignored, report.Rejected)
+	}
+}
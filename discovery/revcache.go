@@ -0,0 +1,55 @@
+package discovery
+
+import (
+	"container/list"
+	"sync"
+)
+
+// revisionCache keeps the specs parsed at past revisions (see Registry.SpecAt), evicting the
+// least recently used one once it holds size of them.
+type revisionCache struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List // of *revisionEntry, most recently used first
+	byKey map[string]*list.Element
+}
+
+type revisionEntry struct {
+	key  string
+	spec SwaggerSpec
+}
+
+func newRevisionCache(size int) *revisionCache {
+	return &revisionCache{size: size, order: list.New(), byKey: make(map[string]*list.Element)}
+}
+
+// get returns the spec cached under key, marking it as the most recently used.
+func (c *revisionCache) get(key string) (SwaggerSpec, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.byKey[key]
+	if !ok {
+		return SwaggerSpec{}, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*revisionEntry).spec, true //nolint:errcheck // order holds nothing else
+}
+
+// add caches spec under key, evicting the least recently used spec if the cache is full.
+func (c *revisionCache) add(key string, spec SwaggerSpec) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.byKey[key]; ok {
+		elem.Value.(*revisionEntry).spec = spec //nolint:errcheck // order holds nothing else
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.byKey[key] = c.order.PushFront(&revisionEntry{key: key, spec: spec})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.byKey, oldest.Value.(*revisionEntry).key) //nolint:errcheck // order holds nothing else
+	}
+}
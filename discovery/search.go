@@ -0,0 +1,181 @@
+package discovery
+
+import (
+	"strings"
+
+	oas3 "github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/Hossein-Roshandel/webswags/search"
+)
+
+// Kinds of SearchHit.
+const (
+	HitService   = "service"
+	HitOperation = "operation"
+	HitSchema    = "schema"
+)
+
+// maxSnippet is the longest description a SearchHit carries, in runes.
+const maxSnippet = 160
+
+// SearchHit is a result of Registry.Search: a service, an operation or a named schema of the
+// default version of a service.
+type SearchHit struct {
+	Kind        string `json:"kind"`
+	Service     string `json:"service"`
+	ServiceSlug string `json:"serviceSlug"`
+	Slug        string `json:"slug"` // of the spec
+	Version     string `json:"version"`
+	Title       string `json:"title"`                 // service title, "METHOD /path" or schema name
+	Snippet     string `json:"snippet,omitempty"`     // summary or description, shortened
+	Method      string `json:"method,omitempty"`      // operations only
+	Path        string `json:"path,omitempty"`        // operations only
+	OperationID string `json:"operationId,omitempty"` // operations only
+	Schema      string `json:"schema,omitempty"`      // schemas only
+	Score       int    `json:"score"`
+}
+
+// searchIndex is the full-text index of a catalog, with the hit each document stands for.
+type searchIndex struct {
+	index *search.Index
+	hits  []SearchHit
+}
+
+// buildSearchIndex indexes the title and description of the default version of every service,
+// its operations (path, operationId, tags, summary, description, parameter and request body
+// property names) and its named schemas (name, description and property names).
+func buildSearchIndex(services []Service) *searchIndex {
+	builder := search.NewBuilder()
+	var hits []SearchHit
+	add := func(hit SearchHit, fields ...search.Field) {
+		builder.Add(fields...)
+		hits = append(hits, hit)
+	}
+
+	for _, svc := range services {
+		spec := svc.Latest()
+		base := SearchHit{Service: svc.Name, ServiceSlug: svc.Slug, Slug: spec.Slug, Version: spec.VersionKey}
+
+		hit := base
+		hit.Kind, hit.Title, hit.Snippet = HitService, firstNonEmpty(spec.Title, svc.Name), snippet(spec.Description)
+		add(hit,
+			search.Field{Text: spec.Title + " " + svc.Name, Weight: search.WeightTitle},
+			search.Field{Text: spec.Description, Weight: search.WeightText})
+
+		doc := spec.DocV3
+		if doc == nil {
+			continue
+		}
+		if doc.Paths != nil {
+			paths := doc.Paths.Map()
+			for _, path := range sortedKeys(paths) {
+				item := paths[path]
+				if item == nil {
+					continue
+				}
+				ops := item.Operations()
+				for _, method := range sortedKeys(ops) {
+					op := ops[method]
+					hit := base
+					hit.Kind, hit.Title = HitOperation, method+" "+path
+					hit.Method, hit.Path, hit.OperationID = method, path, op.OperationID
+					hit.Snippet = snippet(firstNonEmpty(op.Summary, item.Summary, op.Description, item.Description))
+					add(hit,
+						search.Field{Text: path + " " + op.OperationID, Weight: search.WeightTitle},
+						search.Field{Text: strings.Join(operationTerms(item, op), " "), Weight: search.WeightTerm},
+						search.Field{
+							Text: strings.Join(
+								[]string{op.Summary, op.Description, item.Summary, item.Description}, " "),
+							Weight: search.WeightText,
+						})
+				}
+			}
+		}
+		if doc.Components == nil {
+			continue
+		}
+		for _, name := range sortedKeys(doc.Components.Schemas) {
+			ref := doc.Components.Schemas[name]
+			if ref == nil || ref.Value == nil {
+				continue
+			}
+			hit := base
+			hit.Kind, hit.Title, hit.Schema = HitSchema, name, name
+			hit.Snippet = snippet(ref.Value.Description)
+			properties, descriptions := schemaTerms(ref.Value)
+			add(hit,
+				search.Field{Text: name, Weight: search.WeightTitle},
+				search.Field{Text: strings.Join(properties, " "), Weight: search.WeightTerm},
+				search.Field{
+					Text:   ref.Value.Description + " " + strings.Join(descriptions, " "),
+					Weight: search.WeightText,
+				})
+		}
+	}
+	return &searchIndex{index: builder.Build(), hits: hits}
+}
+
+// operationTerms lists the tags of op, the names of its parameters and the top-level property
+// names of its request bodies.
+func operationTerms(item *oas3.PathItem, op *oas3.Operation) []string {
+	terms := append([]string{}, op.Tags...)
+	for _, params := range []oas3.Parameters{item.Parameters, op.Parameters} {
+		for _, param := range params {
+			if param != nil && param.Value != nil {
+				terms = append(terms, param.Value.Name)
+			}
+		}
+	}
+	if op.RequestBody != nil && op.RequestBody.Value != nil {
+		for _, media := range op.RequestBody.Value.Content {
+			if media != nil && media.Schema != nil && media.Schema.Value != nil {
+				properties, _ := schemaTerms(media.Schema.Value)
+				terms = append(terms, properties...)
+			}
+		}
+	}
+	return terms
+}
+
+// schemaTerms lists the property names of schema, and those of the members of its allOf, with
+// their descriptions.
+func schemaTerms(schema *oas3.Schema) (properties, descriptions []string) {
+	for _, s := range append([]*oas3.SchemaRef{{Value: schema}}, schema.AllOf...) {
+		if s == nil || s.Value == nil {
+			continue
+		}
+		for _, name := range sortedKeys(s.Value.Properties) {
+			properties = append(properties, name)
+			if prop := s.Value.Properties[name]; prop != nil && prop.Value != nil && prop.Value.Description != "" {
+				descriptions = append(descriptions, prop.Value.Description)
+			}
+		}
+	}
+	return properties, descriptions
+}
+
+// snippet shortens a description to its first line, at most maxSnippet runes long.
+func snippet(text string) string {
+	text, _, _ = strings.Cut(strings.TrimSpace(text), "\n")
+	if runes := []rune(text); len(runes) > maxSnippet {
+		return strings.TrimSpace(string(runes[:maxSnippet])) + "…"
+	}
+	return text
+}
+
+// search returns at most limit hits for query, best first; limit <= 0 means no limit.
+func (s *searchIndex) search(query string, limit int) []SearchHit {
+	hits := []SearchHit{}
+	if s == nil {
+		return hits
+	}
+	for _, match := range s.index.Search(query) {
+		if limit > 0 && len(hits) == limit {
+			break
+		}
+		hit := s.hits[match.Doc]
+		hit.Score = match.Score
+		hits = append(hits, hit)
+	}
+	return hits
+}
@@ -0,0 +1,167 @@
+package discovery
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// fallbackSlug is used when a service name contains no URL-safe characters at all.
+const fallbackSlug = "api"
+
+// Slugify turns a display name into a lowercase, URL-safe identifier.
+// Runs of characters outside [a-z0-9] collapse into a single hyphen.
+//
+// Examples:
+//   - "User Service"   => "user-service"
+//   - "Orders API v2"  => "orders-api-v2"
+//   - "  ¿Qué?  "      => "qu"
+func Slugify(name string) string {
+	if slug := slugTokens(name); slug != "" {
+		return slug
+	}
+	return fallbackSlug
+}
+
+// slugTokens is Slugify without the fallback: it returns "" when name has no URL-safe characters.
+func slugTokens(name string) string {
+	var b strings.Builder
+	pendingHyphen := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if pendingHyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			pendingHyphen = false
+			b.WriteRune(r)
+			continue
+		}
+		pendingHyphen = true
+	}
+	return b.String()
+}
+
+// assignSlugs gives every spec a unique Slug derived from its service name.
+//
+// Specs whose names slugify to the same value are disambiguated deterministically by the
+// nearest directory segments that tell them apart (generic names like "spec" or "docs" and
+// segments repeating the service name are skipped), then by file name, and as a last resort
+// by a numeric suffix. specs must already be sorted (see sortSpecs) for the result to be stable.
+func assignSlugs(specs []SwaggerSpec) {
+	groups := make(map[string][]int)
+	var order []string
+	for i := range specs {
+		base := Slugify(specs[i].Service)
+		if _, seen := groups[base]; !seen {
+			order = append(order, base)
+		}
+		groups[base] = append(groups[base], i)
+	}
+
+	// Disambiguated slugs are settled first so that a lone spec can never steal one of them.
+	taken := make(map[string]bool, len(specs))
+	for _, base := range order {
+		members := groups[base]
+		if len(members) == 1 {
+			continue
+		}
+		for j, candidate := range disambiguate(specs, members, base) {
+			specs[members[j]].Slug = uniqueSlug(candidate, taken)
+		}
+	}
+	for _, base := range order {
+		if members := groups[base]; len(members) == 1 {
+			specs[members[0]].Slug = uniqueSlug(base, taken)
+		}
+	}
+}
+
+// disambiguate returns distinct slugs for specs[members], which all share base, in member order.
+func disambiguate(specs []SwaggerSpec, members []int, base string) []string {
+	tokens := make([][]string, len(members))
+	longest := 0
+	for j, i := range members {
+		tokens[j] = pathTokens(specs[i].Path, base)
+		longest = max(longest, len(tokens[j]))
+	}
+
+	for k := 1; k <= longest; k++ {
+		candidates := make([]string, len(members))
+		seen := make(map[string]bool, len(members))
+		unique := true
+		for j := range members {
+			candidates[j] = joinSlug(base, tokens[j][:min(k, len(tokens[j]))])
+			if seen[candidates[j]] {
+				unique = false
+			}
+			seen[candidates[j]] = true
+		}
+		if unique {
+			return candidates
+		}
+	}
+
+	// Nothing in the paths tells them apart; number them in sorted order.
+	out := make([]string, len(members))
+	for j := range members {
+		out[j] = base
+		if j > 0 {
+			out[j] = base + "-" + strconv.Itoa(j+1)
+		}
+	}
+	return out
+}
+
+// pathTokens returns the slugified segments that can distinguish a spec at path, nearest
+// directory first and the file name last.
+func pathTokens(path, base string) []string {
+	cleanPath := filepath.Clean(path)
+	dir := filepath.Dir(cleanPath)
+
+	var tokens []string
+	for _, segment := range reversed(strings.Split(dir, string(filepath.Separator))) {
+		if isGenericDir(segment) {
+			continue
+		}
+		if token := slugTokens(segment); token != "" && token != base {
+			tokens = append(tokens, token)
+		}
+	}
+	return append(tokens, Slugify(filepath.Base(cleanPath)))
+}
+
+// isGenericDir reports whether a directory name says nothing about which service lives in it.
+func isGenericDir(name string) bool {
+	switch strings.ToLower(name) {
+	case "", ".", "..", "docs", "doc", "documentation", "specifications",
+		"spec", "specs", "api", "apis", "swagger", "openapi", "oas":
+		return true
+	default:
+		return false
+	}
+}
+
+// joinSlug appends tokens (nearest first) to base, farthest first, so the result reads like a path.
+func joinSlug(base string, tokens []string) string {
+	parts := append([]string{base}, reversed(tokens)...)
+	return strings.Join(parts, "-")
+}
+
+// uniqueSlug returns slug, or slug with the smallest numeric suffix that is not yet taken,
+// and marks the result as taken.
+func uniqueSlug(slug string, taken map[string]bool) string {
+	candidate := slug
+	for n := 2; taken[candidate]; n++ {
+		candidate = slug + "-" + strconv.Itoa(n)
+	}
+	taken[candidate] = true
+	return candidate
+}
+
+func reversed(in []string) []string {
+	out := make([]string, len(in))
+	for i, s := range in {
+		out[len(in)-1-i] = s
+	}
+	return out
+}
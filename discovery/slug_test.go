@@ -0,0 +1,57 @@
+package discovery_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/Hossein-Roshandel/webswags/discovery"
+)
+
+func TestSlugify(t *testing.T) {
+	t.Parallel()
+	tests := map[string]string{
+		"User Service":     "user-service",
+		"Orders API v2":    "orders-api-v2",
+		"  ¿Qué?  ":        "qu",
+		"payments__Core--": "payments-core",
+		"日本語":              "api",
+		"":                 "api",
+	}
+	for name, want := range tests {
+		if got := discovery.Slugify(name); got != want {
+			t.Errorf("Slugify(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestDiscoverAssignsUniqueSlugs(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"auth/openapi.yaml":           specFile("Users", "1"),
+		"billing/users/openapi.yaml":  specFile("Users", "1"),
+		"legacy/docs/users/spec.yaml": specFile("Users", "1"),
+		"pets/openapi.yaml":           specFile("Pets", "1"),
+		"store/openapi.yaml":          specFile("Store", "1"),
+		"store/spec/openapi.yaml":     specFile("Store", "1"),
+	}
+	result, err := discovery.DiscoverFS(t.Context(), fsys, ".", discovery.DiscoverOptions{})
+	if err != nil {
+		t.Fatalf("DiscoverFS: %v", err)
+	}
+	want := map[string]string{
+		"auth/openapi.yaml":           "users-auth",
+		"billing/users/openapi.yaml":  "users-billing",
+		"legacy/docs/users/spec.yaml": "users-legacy",
+		"pets/openapi.yaml":           "pets",
+		"store/openapi.yaml":          "store",
+		"store/spec/openapi.yaml":     "store-2",
+	}
+	for _, spec := range result.Specs {
+		if spec.Slug != want[spec.Path] {
+			t.Errorf("slug of %s = %q, want %q", spec.Path, spec.Slug, want[spec.Path])
+		}
+	}
+	if len(result.Specs) != len(want) {
+		t.Errorf("found %d specs, want %d", len(result.Specs), len(want))
+	}
+}
@@ -0,0 +1,563 @@
+package discovery
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// maxSourceDownload is the largest document an HTTPSource reads.
+const maxSourceDownload = 64 << 20 // 64 MiB
+
+// defaultSourceTimeout is how long an HTTPSource waits for its document unless told otherwise.
+const defaultSourceTimeout = 30 * time.Second
+
+// readOnlyMode is the permission of files read from archives, git trees and HTTP sources.
+const readOnlyMode fs.FileMode = 0o444
+
+// Source is a place specs are discovered from: a directory, an archive, a git revision or an
+// HTTP endpoint. Every spec and report entry found in a source is tagged with its Origin.
+type Source interface {
+	// Origin identifies the source, e.g. "dir:apis" or "git:.@v1.2.0".
+	Origin() string
+	// Open returns the source's files. Discovery walks all of it, so it should be cheap to
+	// read; the file system must stay usable for as long as the specs found in it are served.
+	Open(ctx context.Context) (fs.FS, error)
+}
+
+// DirSource is a directory on the local file system. Specs found in it keep native paths,
+// like those of Discover, and can $ref files outside the directory.
+type DirSource struct {
+	Path string
+}
+
+// Origin implements Source.
+func (s DirSource) Origin() string { return "dir:" + s.Path }
+
+// Open implements Source.
+func (s DirSource) Open(context.Context) (fs.FS, error) {
+	if _, err := os.Stat(s.Path); err != nil {
+		return nil, err
+	}
+	return os.DirFS(s.Path), nil
+}
+
+// ArchiveSource is a .zip, .tar.gz (.tgz) or .tar bundle of specs. The members discovery may
+// read are loaded into memory once, so later changes to the file are not picked up until the
+// next Load.
+type ArchiveSource struct {
+	Path string
+	// MaxFileSize skips members larger than this many bytes rather than decompressing them into
+	// memory. Zero means the MaxFileSize of the discovery, or else DefaultMaxFileSize; a negative
+	// value disables the limit.
+	MaxFileSize int64
+}
+
+// Origin implements Source.
+func (s ArchiveSource) Origin() string { return "archive:" + s.Path }
+
+// Open implements Source. The archive is streamed from its file, so only the members loaded
+// are held in memory.
+func (s ArchiveSource) Open(context.Context) (fs.FS, error) {
+	name := strings.ToLower(s.Path)
+	if !isArchive(name) {
+		return nil, fmt.Errorf("unsupported archive %q (want .zip, .tar.gz, .tgz or .tar)", s.Path)
+	}
+	f, err := os.Open(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		info, statErr := f.Stat()
+		if statErr != nil {
+			return nil, statErr
+		}
+		zr, zipErr := zip.NewReader(f, info.Size())
+		if zipErr != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", s.Path, zipErr)
+		}
+		return readZip(zr, s.Path, s.MaxFileSize)
+	case strings.HasSuffix(name, ".tar"):
+		return readTar(f, s.Path, s.MaxFileSize)
+	default:
+		gz, gzErr := gzip.NewReader(f)
+		if gzErr != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", s.Path, gzErr)
+		}
+		defer gz.Close()
+		return readTar(gz, s.Path, s.MaxFileSize)
+	}
+}
+
+// readZip loads the files discovery may read from a zip archive into memory, skipping those
+// larger than maxSize (see DiscoverOptions.MaxFileSize) by their header. label names the
+// archive in errors.
+func readZip(zr *zip.Reader, label string, maxSize int64) (fs.FS, error) {
+	files := mapFS{}
+	for _, member := range zr.File {
+		name := path.Clean(strings.TrimPrefix(member.Name, "./"))
+		if !member.Mode().IsRegular() || !fs.ValidPath(name) || !sourceFileWanted(name) {
+			continue
+		}
+		size := member.UncompressedSize64
+		if size > math.MaxInt64 || !(DiscoverOptions{MaxFileSize: maxSize}).withinSize(int64(size)) {
+			slog.Debug("Skipping large file", "path", name, "archive", label, "size", size)
+			continue
+		}
+		data, err := readZipMember(member)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from %s: %w", name, label, err)
+		}
+		files[name] = &mapFile{data: data, modTime: member.Modified}
+	}
+	return files, nil
+}
+
+// readZipMember reads the contents of member, which the zip reader holds to the size its header
+// declares.
+func readZipMember(member *zip.File) ([]byte, error) {
+	r, err := member.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// readTar loads the files discovery may read from a tar stream into memory, skipping those
+// larger than maxSize (see DiscoverOptions.MaxFileSize) by their header. label names the
+// archive in errors.
+func readTar(r io.Reader, label string, maxSize int64) (fs.FS, error) {
+	files := mapFS{}
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", label, err)
+		}
+
+		name := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		if header.Typeflag != tar.TypeReg || !fs.ValidPath(name) || !sourceFileWanted(name) {
+			continue
+		}
+		if !(DiscoverOptions{MaxFileSize: maxSize}).withinSize(header.Size) {
+			slog.Debug("Skipping large file", "path", name, "archive", label, "size", header.Size)
+			continue
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, header.Size))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from %s: %w", name, label, err)
+		}
+		files[name] = &mapFile{data: data, modTime: header.ModTime}
+	}
+}
+
+// GitSource is a revision of a local git repository, read straight from its object database,
+// so neither the working tree nor the checked-out branch matter. Repo may be any directory
+// inside the repository; only the part of the tree below it is discovered.
+type GitSource struct {
+	Repo string
+	Ref  string // branch, tag, commit or any revision git understands; "" means HEAD
+	// MaxFileSize skips files larger than this many bytes rather than reading them into
+	// memory, like ArchiveSource.MaxFileSize.
+	MaxFileSize int64
+}
+
+// Origin implements Source.
+func (s GitSource) Origin() string { return "git:" + s.Repo + "@" + s.ref() }
+
+func (s GitSource) ref() string {
+	if s.Ref == "" {
+		return "HEAD"
+	}
+	return s.Ref
+}
+
+// Open implements Source.
+func (s GitSource) Open(context.Context) (fs.FS, error) {
+	repo, err := git.PlainOpenWithOptions(s.Repo, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open git repository %s: %w", s.Repo, err)
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(s.ref()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %q in %s: %w", s.ref(), s.Repo, err)
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit %s: %w", hash, err)
+	}
+	files, err := treeFiles(commit, s.MaxFileSize)
+	if err != nil {
+		return nil, err
+	}
+
+	sub, err := repoSubdir(repo, s.Repo)
+	if err != nil || sub == "." {
+		return files, nil //nolint:nilerr // Without a working tree (bare repository), all of it is discovered.
+	}
+	return fs.Sub(files, sub)
+}
+
+// treeFiles loads the files discovery may read from a commit's tree into memory, skipping
+// those larger than maxSize (see DiscoverOptions.MaxFileSize) by the size of their blob.
+func treeFiles(commit *object.Commit, maxSize int64) (fs.FS, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tree of %s: %w", commit.Hash, err)
+	}
+
+	files := mapFS{}
+	err = tree.Files().ForEach(func(f *object.File) error {
+		if (f.Mode != filemode.Regular && f.Mode != filemode.Executable) || !sourceFileWanted(f.Name) {
+			return nil
+		}
+		if !(DiscoverOptions{MaxFileSize: maxSize}).withinSize(f.Size) {
+			slog.Debug("Skipping large file", "path", f.Name, "commit", commit.Hash, "size", f.Size)
+			return nil
+		}
+		data, readErr := readBlob(f)
+		if readErr != nil {
+			return fmt.Errorf("failed to read %s at %s: %w", f.Name, commit.Hash, readErr)
+		}
+		files[f.Name] = &mapFile{data: data, modTime: commit.Committer.When}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// readBlob reads the contents of f, no more than the size its blob declares.
+func readBlob(f *object.File) ([]byte, error) {
+	r, err := f.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(io.LimitReader(r, f.Size))
+}
+
+// repoSubdir returns where dir lies in the working tree of repo, slash-separated.
+func repoSubdir(repo *git.Repository, dir string) (string, error) {
+	wt, err := repo.Worktree()
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(wt.Filesystem.Root(), abs)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// HTTPSource is a spec served over HTTP, such as a framework's generated /openapi.json. It is
+// a single document: relative $refs in it are not fetched.
+type HTTPSource struct {
+	URL     string
+	Client  *http.Client  // nil means http.DefaultClient
+	Timeout time.Duration // how long fetching the document may take; zero means 30 seconds
+}
+
+// Origin implements Source.
+func (s HTTPSource) Origin() string { return s.URL }
+
+// Open implements Source. The document is fetched once and presented as a single file named
+// after the URL's last path segment, or "openapi.json"/"openapi.yaml" when that segment does
+// not have a spec extension (e.g. "/v3/api-docs").
+func (s HTTPSource) Open(ctx context.Context) (fs.FS, error) {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q: %w", s.URL, err)
+	}
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = defaultSourceTimeout
+	}
+	// The deadline also covers reading the body, so a server that stalls mid-document cannot
+	// hold up Load or a reload of the registry.
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json, application/yaml;q=0.9, */*;q=0.5")
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", s.URL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: %s", s.URL, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSourceDownload+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", s.URL, err)
+	}
+	if len(data) > maxSourceDownload {
+		return nil, fmt.Errorf("%s is larger than %d bytes", s.URL, maxSourceDownload)
+	}
+
+	modTime := time.Now()
+	if lastModified, parseErr := http.ParseTime(resp.Header.Get("Last-Modified")); parseErr == nil {
+		modTime = lastModified
+	}
+	name := httpFileName(u, resp.Header.Get("Content-Type"))
+	return mapFS{name: {data: data, modTime: modTime}}, nil
+}
+
+// httpFileName names the file holding a document fetched from u with the given Content-Type.
+func httpFileName(u *url.URL, contentType string) string {
+	if base := path.Base(u.Path); isCandidateFile(base) {
+		return base
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if strings.Contains(mediaType, "yaml") {
+		return "openapi.yaml"
+	}
+	return "openapi.json"
+}
+
+// ParseSource parses a source given on the command line:
+//
+//   - "http://..." or "https://..." is an HTTPSource;
+//   - "git:PATH@REF" (or "git:PATH" for HEAD) is a GitSource;
+//   - a path ending in .zip, .tar.gz, .tgz or .tar (optionally "archive:PATH") is an ArchiveSource;
+//   - anything else (optionally "dir:PATH") is a DirSource.
+func ParseSource(s string) (Source, error) {
+	switch {
+	case s == "":
+		return nil, errors.New("empty source")
+	case strings.HasPrefix(s, "http://"), strings.HasPrefix(s, "https://"):
+		if _, err := url.Parse(s); err != nil {
+			return nil, fmt.Errorf("invalid source URL %q: %w", s, err)
+		}
+		return HTTPSource{URL: s}, nil
+	case strings.HasPrefix(s, "git:"):
+		repo, ref := strings.TrimPrefix(s, "git:"), ""
+		if at := strings.LastIndex(repo, "@"); at >= 0 {
+			repo, ref = repo[:at], repo[at+1:]
+		}
+		if repo == "" {
+			repo = "."
+		}
+		return GitSource{Repo: repo, Ref: ref}, nil
+	case strings.HasPrefix(s, "archive:"):
+		return ArchiveSource{Path: strings.TrimPrefix(s, "archive:")}, nil
+	case strings.HasPrefix(s, "dir:"):
+		return DirSource{Path: strings.TrimPrefix(s, "dir:")}, nil
+	case isArchive(s):
+		return ArchiveSource{Path: s}, nil
+	default:
+		return DirSource{Path: s}, nil
+	}
+}
+
+// isArchive reports whether p has the extension of an archive ArchiveSource reads.
+func isArchive(p string) bool {
+	p = strings.ToLower(p)
+	for _, ext := range []string{".zip", ".tar.gz", ".tgz", ".tar"} {
+		if strings.HasSuffix(p, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceFileWanted reports whether a file of an archive or git tree may be read by discovery:
+// a candidate spec (or a file one $refs) or an ignore file. Other files are not loaded.
+func sourceFileWanted(name string) bool {
+	return isCandidateFile(name) || isIgnoreFile(name)
+}
+
+// DiscoverSources discovers the specs of every source and merges them into one catalog, as if
+// they had been found in one tree. Specs and report entries carry the Origin of their source.
+// It fails if any source cannot be opened or walked.
+func DiscoverSources(ctx context.Context, sources []Source, opts DiscoverOptions) (DiscoverResult, error) {
+	start := time.Now()
+
+	var (
+		files   []SwaggerSpec
+		timings []FileTiming
+		entries = make(map[string]ReportEntry)
+	)
+	for _, src := range sources {
+		found, err := scanSource(ctx, src, opts)
+		if err != nil {
+			return DiscoverResult{}, fmt.Errorf("source %s: %w", src.Origin(), err)
+		}
+		files = append(files, found.files...)
+		timings = append(timings, found.timings...)
+		for _, entry := range found.entries {
+			entries[entryKey(entry)] = entry
+		}
+	}
+
+	result := DiscoverResult{Timings: timings}
+	result.Specs, result.Services = buildCatalog(files)
+	result.Report = buildReport("", entries, result.Specs)
+	result.Elapsed = time.Since(start)
+	return result, nil
+}
+
+// scanSource walks and parses one source, tagging what it finds with the source's origin.
+// Directories are read through the OS file system, so their specs keep native paths.
+func scanSource(ctx context.Context, src Source, opts DiscoverOptions) (scan, error) {
+	var (
+		fsys fs.FS
+		root = "."
+	)
+	if dir, ok := src.(DirSource); ok {
+		fsys, root = osFS{}, dir.Path
+	} else {
+		opened, err := withMaxFileSize(src, opts.MaxFileSize).Open(ctx)
+		if err != nil {
+			return scan{}, err
+		}
+		fsys = slashFS{fsys: opened}
+	}
+
+	found, err := discoverFiles(ctx, fsys, root, opts)
+	if err != nil {
+		return scan{}, err
+	}
+	found.tag(src.Origin(), root)
+	slog.Debug("Scanned source", "origin", src.Origin(), "specs", len(found.files))
+	return found, nil
+}
+
+// withMaxFileSize returns src with the file size limit of the discovery, for sources that read
+// their files into memory and have no limit of their own, so large files are skipped before
+// they are loaded rather than after.
+func withMaxFileSize(src Source, maxSize int64) Source {
+	switch s := src.(type) {
+	case ArchiveSource:
+		if s.MaxFileSize == 0 {
+			s.MaxFileSize = maxSize
+		}
+		return s
+	case GitSource:
+		if s.MaxFileSize == 0 {
+			s.MaxFileSize = maxSize
+		}
+		return s
+	}
+	return src
+}
+
+// entryKey identifies a report entry across sources, whose paths may coincide.
+func entryKey(entry ReportEntry) string {
+	return entry.Origin + "\x00" + entry.Path
+}
+
+// Files returns the paths of every file that makes up the spec: its variants (or just Path for
+// specs parsed on their own, which have none), then the files it pulls in through external $refs.
+func (s SwaggerSpec) Files() []string {
+	files := make([]string, 0, len(s.Variants)+len(s.Dependencies)+1)
+	for _, v := range s.Variants {
+		files = append(files, v.Path)
+	}
+	if len(s.Variants) == 0 && s.Path != "" {
+		files = append(files, s.Path)
+	}
+	return append(files, s.Dependencies...)
+}
+
+// ReadFile reads one of the spec's Files from the source it was discovered in, which need not
+// be the local file system. Other paths are reported as fs.ErrNotExist.
+func (s SwaggerSpec) ReadFile(p string) ([]byte, error) {
+	if s.fsys == nil || !slices.Contains(s.Files(), p) {
+		return nil, &fs.PathError{Op: "read", Path: p, Err: fs.ErrNotExist}
+	}
+	return fs.ReadFile(s.fsys, p)
+}
+
+// RelPath returns p, one of the spec's Files, relative to the root of its source and
+// slash-separated. It reports false for files outside the root.
+func (s SwaggerSpec) RelPath(p string) (string, bool) {
+	return relPath(s.root(), p)
+}
+
+// FileAt returns the spec file found at rel (see RelPath), if there is one. A file that is a
+// symlink, or lies below one, is only returned if its target is under the root of the source too.
+func (s SwaggerSpec) FileAt(rel string) (string, bool) {
+	for _, p := range s.Files() {
+		if r, ok := s.RelPath(p); ok && r == rel {
+			return p, s.underRoot(p)
+		}
+	}
+	return "", false
+}
+
+// root returns the directory the spec's files are served relative to.
+func (s SwaggerSpec) root() string {
+	if s.sourceRoot == "" {
+		return filepath.Dir(s.Path)
+	}
+	return s.sourceRoot
+}
+
+// underRoot reports whether p, with every symlink resolved, lies under the root of the spec.
+func (s SwaggerSpec) underRoot(p string) bool {
+	if s.fsys == nil {
+		return false
+	}
+	root, err := realPath(s.fsys, s.root())
+	if err != nil {
+		return false
+	}
+	target, err := realPath(s.fsys, p)
+	if err != nil {
+		return false
+	}
+	_, ok := relPath(root, target)
+	return ok
+}
+
+// relPath returns p relative to root and slash-separated, reporting false if p is not below root.
+func relPath(root, p string) (string, bool) {
+	rel, err := filepath.Rel(root, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
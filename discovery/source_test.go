@@ -0,0 +1,178 @@
+package discovery_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/Hossein-Roshandel/webswags/discovery"
+)
+
+const petsSpec = `openapi: 3.0.3
+info: {title: Pets, version: "1"}
+paths: {}
+`
+
+func TestHTTPSourceOpen(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/yaml")
+		_, _ = w.Write([]byte(petsSpec))
+	}))
+	t.Cleanup(server.Close)
+
+	fsys, err := discovery.HTTPSource{URL: server.URL + "/v3/api-docs"}.Open(t.Context())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	data, err := fs.ReadFile(fsys, "openapi.yaml")
+	if err != nil || string(data) != petsSpec {
+		t.Errorf("openapi.yaml = %q, %v; want the served document", data, err)
+	}
+}
+
+func TestHTTPSourceTimeout(t *testing.T) {
+	t.Parallel()
+	stalled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		select { // never finish the body
+		case <-stalled:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(func() { close(stalled); server.Close() })
+
+	start := time.Now()
+	_, err := discovery.HTTPSource{URL: server.URL, Timeout: 100 * time.Millisecond}.Open(context.Background())
+	if err == nil {
+		t.Fatal("Open of a stalled server succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Open gave up after %s, want about 100ms", elapsed)
+	}
+}
+
+func TestHTTPSourceStatus(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(server.Close)
+
+	if _, err := (discovery.HTTPSource{URL: server.URL}).Open(t.Context()); err == nil {
+		t.Error("Open of a 404 succeeded")
+	}
+}
+
+// archiveMembers are the files of the archives of TestArchiveSource.
+var archiveMembers = map[string]string{ //nolint:gochecknoglobals // Test fixture.
+	"apis/pets.yaml":  petsSpec,
+	"apis/large.yaml": petsSpec + "# " + strings.Repeat("x", 1024) + "\n",
+	"README.md":       "not loaded",
+}
+
+// writeArchive writes archiveMembers to an archive named name in a temporary directory.
+func writeArchive(t *testing.T, name string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		zw := zip.NewWriter(&buf)
+		for member, body := range archiveMembers {
+			w, err := zw.Create(member)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write([]byte(body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+	default:
+		var w io.Writer = &buf
+		gz := gzip.NewWriter(&buf)
+		if strings.HasSuffix(name, ".tgz") {
+			w = gz
+		}
+		tw := tar.NewWriter(w)
+		for member, body := range archiveMembers {
+			if err := tw.WriteHeader(&tar.Header{Name: member, Mode: 0o644, Size: int64(len(body))}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tw.Write([]byte(body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := gz.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	archive := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(archive, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return archive
+}
+
+func TestArchiveSource(t *testing.T) {
+	t.Parallel()
+	for _, name := range []string{"specs.tar", "specs.tgz", "specs.zip"} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			fsys, err := discovery.ArchiveSource{Path: writeArchive(t, name), MaxFileSize: 512}.Open(t.Context())
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			if err := fstest.TestFS(fsys, "apis/pets.yaml"); err != nil {
+				t.Error(err)
+			}
+			if data, err := fs.ReadFile(fsys, "apis/pets.yaml"); err != nil || string(data) != petsSpec {
+				t.Errorf("apis/pets.yaml = %q, %v; want the member", data, err)
+			}
+			for _, skipped := range []string{"apis/large.yaml", "README.md"} {
+				if _, err := fs.Stat(fsys, skipped); err == nil {
+					t.Errorf("%s was loaded", skipped)
+				}
+			}
+		})
+	}
+}
+
+func TestParseSource(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in   string
+		want discovery.Source
+	}{
+		{in: "https://example.com/openapi.json", want: discovery.HTTPSource{URL: "https://example.com/openapi.json"}},
+		{in: "git:.@v1.2.0", want: discovery.GitSource{Repo: ".", Ref: "v1.2.0"}},
+		{in: "git:", want: discovery.GitSource{Repo: "."}},
+		{in: "specs.tgz", want: discovery.ArchiveSource{Path: "specs.tgz"}},
+		{in: "archive:bundle", want: discovery.ArchiveSource{Path: "bundle"}},
+		{in: "apis", want: discovery.DirSource{Path: "apis"}},
+		{in: "dir:specs.zip", want: discovery.DirSource{Path: "specs.zip"}},
+	}
+	for _, tt := range tests {
+		got, err := discovery.ParseSource(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseSource(%q) = %#v, %v; want %#v", tt.in, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package discovery
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// errFileTooLarge is returned for files larger than the maximum file size.
+var errFileTooLarge = errors.New("file is larger than the maximum file size")
+
+// treeFS is a read-only file system over the tree of a commit. Unlike the mapFS filled by
+// treeFiles, it reads a blob only when its file is opened, so parsing a spec at a past revision
+// reads the spec and the files it references rather than the whole tree. Symlinks and
+// submodules are left out, as by treeFiles.
+type treeFS struct {
+	mu      sync.Mutex // go-git trees index their entries on first use
+	tree    *object.Tree
+	modTime time.Time
+	maxSize int64 // see DiscoverOptions.MaxFileSize
+}
+
+// newTreeFS returns the file system of commit's tree.
+func newTreeFS(commit *object.Commit, maxSize int64) (*treeFS, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tree of %s: %w", commit.Hash, err)
+	}
+	return &treeFS{tree: tree, modTime: commit.Committer.When, maxSize: maxSize}, nil
+}
+
+// Open implements fs.FS.
+func (t *treeFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if name == "." {
+		return t.openDir(name, t.tree)
+	}
+	entry, err := t.tree.FindEntry(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	switch entry.Mode {
+	case filemode.Dir:
+		sub, subErr := t.tree.Tree(name)
+		if subErr != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: subErr}
+		}
+		return t.openDir(name, sub)
+	case filemode.Regular, filemode.Executable:
+		return t.openFile(name)
+	default:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+}
+
+// openFile reads the blob of the file name, unless it is larger than maxSize.
+func (t *treeFS) openFile(name string) (fs.File, error) {
+	f, err := t.tree.File(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	if !(DiscoverOptions{MaxFileSize: t.maxSize}).withinSize(f.Size) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: errFileTooLarge}
+	}
+	data, err := readBlob(f)
+	if err != nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+	}
+	info := mapInfo{name: path.Base(name), file: &mapFile{data: data, modTime: t.modTime}}
+	return &openMapFile{info: info, Reader: bytes.NewReader(data)}, nil
+}
+
+// openDir lists tree, the directory name, taking the sizes of its files from their blob headers.
+func (t *treeFS) openDir(name string, tree *object.Tree) (fs.File, error) {
+	entries := make([]fs.DirEntry, 0, len(tree.Entries))
+	for _, entry := range tree.Entries {
+		info := treeInfo{name: entry.Name}
+		switch entry.Mode {
+		case filemode.Dir:
+			info.dir = true
+		case filemode.Regular, filemode.Executable:
+			size, err := tree.Size(entry.Name)
+			if err != nil {
+				return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+			}
+			info.size, info.modTime = size, t.modTime
+		default:
+			continue
+		}
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+	// Git orders a directory as if its name ended in "/"; fs.ReadDir orders by name alone.
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	return &mapDir{info: mapInfo{name: path.Base(name)}, entries: entries}, nil
+}
+
+// treeInfo describes an entry of a treeFS directory without reading its blob.
+type treeInfo struct {
+	name    string
+	dir     bool
+	size    int64
+	modTime time.Time
+}
+
+func (i treeInfo) Name() string       { return i.name }
+func (i treeInfo) IsDir() bool        { return i.dir }
+func (i treeInfo) Size() int64        { return i.size }
+func (i treeInfo) ModTime() time.Time { return i.modTime }
+func (i treeInfo) Sys() any           { return nil }
+
+func (i treeInfo) Mode() fs.FileMode {
+	if i.dir {
+		return dirMode
+	}
+	return readOnlyMode
+}
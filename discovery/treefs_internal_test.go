@@ -0,0 +1,99 @@
+package discovery
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestTreeFS(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"openapi.yaml":         "openapi: 3.0.3\n",
+		"schemas/pet.yaml":     "Pet: {type: object}\n",
+		"schemas-v2/pet.yaml":  "Pet: {type: object}\n",
+		"schemas/big/all.yaml": strings.Repeat("# padding\n", 10),
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := wt.Add(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hash, err := wt.Commit("Add specs", &git.CommitOptions{
+		Author: &object.Signature{Name: "Tester", Email: "tester@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unlimited, err := newTreeFS(commit, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fstest.TestFS(unlimited, "openapi.yaml", "schemas/pet.yaml", "schemas-v2/pet.yaml"); err != nil {
+		t.Error(err)
+	}
+
+	limited, err := newTreeFS(commit, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := fs.ReadFile(limited, "schemas/pet.yaml"); err != nil || string(data) != files["schemas/pet.yaml"] {
+		t.Errorf("ReadFile(schemas/pet.yaml) = %q, %v", data, err)
+	}
+	if _, err := fs.ReadFile(limited, "schemas/big/all.yaml"); !errors.Is(err, errFileTooLarge) {
+		t.Errorf("ReadFile of a file over the limit = %v, want errFileTooLarge", err)
+	}
+	if _, err := limited.Open("missing.yaml"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Open(missing.yaml) = %v, want ErrNotExist", err)
+	}
+}
+
+func TestRevisionCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	t.Parallel()
+	cache := newRevisionCache(2)
+	cache.add("a", SwaggerSpec{Title: "A"})
+	cache.add("b", SwaggerSpec{Title: "B"})
+	if _, ok := cache.get("a"); !ok {
+		t.Fatal("a not cached")
+	}
+	cache.add("c", SwaggerSpec{Title: "C"})
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok := cache.get(key); ok != want {
+			t.Errorf("get(%s) found = %t, want %t", key, ok, want)
+		}
+	}
+	cache.add("c", SwaggerSpec{Title: "C2"})
+	if spec, _ := cache.get("c"); spec.Title != "C2" {
+		t.Errorf("get(c) = %q, want the replaced spec C2", spec.Title)
+	}
+}
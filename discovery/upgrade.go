@@ -0,0 +1,61 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	oas3 "github.com/getkin/kin-openapi/openapi3"
+	"sigs.k8s.io/yaml"
+)
+
+// upgradeSwagger2 converts the Swagger 2.0 document at path (with content data) to OpenAPI 3.0.
+// Refs are resolved against the file's location; external ones are then moved into the
+// document's components, so the result stands alone like a bundled spec.
+func upgradeSwagger2(fsys fs.FS, path string, data []byte) (*oas3.T, error) {
+	j, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Swagger 2.0 document %q: %w", path, err)
+	}
+	var doc2 openapi2.T
+	if err := json.Unmarshal(j, &doc2); err != nil {
+		return nil, fmt.Errorf("failed to read Swagger 2.0 document %q: %w", path, err)
+	}
+
+	doc3, err := openapi2conv.ToV3WithLoader(&doc2, newLoader(fsys), fileLocation(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %q to OpenAPI 3: %w", path, err)
+	}
+	if doc2.Host == "" && strings.Trim(doc2.BasePath, "/") != "" {
+		// Without a host the converter drops basePath; a relative server URL keeps it.
+		doc3.Servers = oas3.Servers{{URL: doc2.BasePath}}
+	}
+	doc3.InternalizeRefs(context.Background(), componentNamer(path))
+	return doc3, nil
+}
+
+// Upgraded reports whether DocV3 was converted from a Swagger 2.0 document rather than parsed.
+func (s SwaggerSpec) Upgraded() bool {
+	return s.DocV2 != nil && s.DocV3 != nil
+}
+
+// OpenAPI3 returns the spec as an OpenAPI 3 document encoded in format ("json" or "yaml").
+// OpenAPI 3 specs are encoded as by Convert; Swagger 2.0 specs are upgraded to OpenAPI 3.0.
+func (s SwaggerSpec) OpenAPI3(format string) ([]byte, error) {
+	if !s.Upgraded() {
+		if s.DocV3 == nil {
+			return nil, fmt.Errorf("spec %q has no OpenAPI 3 document", s.Path)
+		}
+		return s.Convert(format)
+	}
+
+	j, err := json.MarshalIndent(s.DocV3, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode OpenAPI 3 document for %q: %w", s.Path, err)
+	}
+	return encodeDocument(j, jsonFormat, format, s.Path)
+}
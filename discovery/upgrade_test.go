@@ -0,0 +1,102 @@
+package discovery_test
+
+import (
+	"encoding/json"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Hossein-Roshandel/webswags/discovery"
+)
+
+// swagger2Spec is a Swagger 2.0 document with the given host line (or none) and basePath /v1.
+func swagger2Spec(host string) string {
+	return `swagger: "2.0"
+info: {title: Pets, version: "1.0.0"}
+` + host + `basePath: /v1
+schemes: [https]
+consumes: [application/json]
+produces: [application/json]
+paths:
+  /pets:
+    post:
+      parameters:
+        - {in: body, name: pet, required: true, schema: {$ref: "#/definitions/Pet"}}
+      responses:
+        "201": {description: Created, schema: {$ref: "#/definitions/Pet"}}
+definitions:
+  Pet: {type: object, properties: {name: {type: string}}}
+`
+}
+
+func TestUpgradeSwagger2(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		host       string
+		wantServer string
+	}{
+		{name: "with host", host: "host: api.example.com\n", wantServer: "https://api.example.com/v1"},
+		{name: "without host", wantServer: "/v1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fsys := fstest.MapFS{"swagger.yaml": {Data: []byte(swagger2Spec(tt.host))}}
+			spec, err := discovery.ParseFS(fsys, "swagger.yaml")
+			if err != nil {
+				t.Fatalf("ParseFS: %v", err)
+			}
+			if !spec.Upgraded() || spec.SwaggerVersion != "2.0" {
+				t.Fatalf("Upgraded = %t for Swagger %q, want an upgraded 2.0 spec",
+					spec.Upgraded(), spec.SwaggerVersion)
+			}
+
+			data, err := spec.OpenAPI3("json")
+			if err != nil {
+				t.Fatalf("OpenAPI3: %v", err)
+			}
+			var doc struct {
+				OpenAPI string `json:"openapi"`
+				Servers []struct {
+					URL string `json:"url"`
+				} `json:"servers"`
+				Paths map[string]map[string]struct {
+					RequestBody json.RawMessage `json:"requestBody"`
+				} `json:"paths"`
+				Components struct {
+					Schemas map[string]json.RawMessage `json:"schemas"`
+				} `json:"components"`
+			}
+			if err := json.Unmarshal(data, &doc); err != nil {
+				t.Fatalf("OpenAPI3 is not JSON: %v", err)
+			}
+			if doc.OpenAPI != "3.0.3" {
+				t.Errorf("openapi = %q, want 3.0.3", doc.OpenAPI)
+			}
+			if len(doc.Servers) != 1 || doc.Servers[0].URL != tt.wantServer {
+				t.Errorf("servers = %+v, want %s", doc.Servers, tt.wantServer)
+			}
+			if doc.Paths["/pets"]["post"].RequestBody == nil {
+				t.Error("the body parameter did not become a request body")
+			}
+			if _, ok := doc.Components.Schemas["Pet"]; !ok {
+				t.Error("definitions did not become component schemas")
+			}
+		})
+	}
+}
+
+func TestOpenAPI3OfOpenAPI3Spec(t *testing.T) {
+	t.Parallel()
+	spec, err := discovery.ParseFS(fstest.MapFS{"pets.yaml": {Data: []byte(petsYAML)}}, "pets.yaml")
+	if err != nil {
+		t.Fatalf("ParseFS: %v", err)
+	}
+	if spec.Upgraded() {
+		t.Error("an OpenAPI 3 spec reports being upgraded")
+	}
+	data, err := spec.OpenAPI3("yaml")
+	if err != nil || string(data) != petsYAML {
+		t.Errorf("OpenAPI3(yaml) = %q, %v; want the file as written", data, err)
+	}
+}
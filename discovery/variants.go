@@ -0,0 +1,190 @@
+package discovery
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// SpecVariant is one file on disk holding (a copy of) a spec document.
+type SpecVariant struct {
+	Path        string `json:"path"        yaml:"path"`
+	FileName    string `json:"fileName"    yaml:"fileName"`
+	Format      string `json:"format"      yaml:"format"` // "yaml" or "json"
+	ContentHash string `json:"contentHash" yaml:"contentHash"`
+}
+
+// contentHash returns the SHA-256 of data after normalisation: YAML is converted to JSON and
+// the result is re-encoded with sorted keys, so formatting, key order and the choice of YAML
+// or JSON do not affect the hash.
+func contentHash(data []byte) string {
+	j, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		j = data
+	}
+
+	var doc any
+	if err := json.Unmarshal(j, &doc); err == nil {
+		if normalised, marshalErr := json.Marshal(doc); marshalErr == nil {
+			j = normalised
+		}
+	}
+
+	sum := sha256.Sum256(j)
+	return hex.EncodeToString(sum[:])
+}
+
+// mergeVariants folds specs that are copies of the same document into one logical spec.
+//
+// Within a directory of a source, files are considered the same document when their normalised content
+// hashes are equal (e.g. openapi.yaml and a generated openapi.json), or when they share a file
+// stem but differ in extension. The latter are merged even if their contents differ, and the
+// merged spec is flagged with Drift so the portal can warn that the copies have diverged.
+//
+// The YAML variant is the primary one (JSON copies are usually generated), falling back to the
+// first file by path. Every returned spec lists its Variants and Formats, merged or not.
+func mergeVariants(specs []SwaggerSpec) []SwaggerSpec {
+	sorted := slices.Clone(specs)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Path < sorted[j].Path
+	})
+
+	// Union-find over indices into sorted.
+	parent := make([]int, len(sorted))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	union := func(i, j int) {
+		if ri, rj := find(i), find(j); ri != rj {
+			parent[max(ri, rj)] = min(ri, rj)
+		}
+	}
+
+	first := make(map[string]int)
+	for i, spec := range sorted {
+		dir := spec.Origin + "\x00" + filepath.Dir(spec.Path)
+		stem := strings.TrimSuffix(spec.FileName, filepath.Ext(spec.FileName))
+		for _, key := range []string{"hash\x00" + dir + "\x00" + spec.ContentHash, "stem\x00" + dir + "\x00" + stem} {
+			if j, ok := first[key]; ok {
+				union(i, j)
+			} else {
+				first[key] = i
+			}
+		}
+	}
+
+	components := make(map[int][]int)
+	var roots []int
+	for i := range sorted {
+		root := find(i)
+		if _, ok := components[root]; !ok {
+			roots = append(roots, root)
+		}
+		components[root] = append(components[root], i)
+	}
+
+	merged := make([]SwaggerSpec, 0, len(roots))
+	for _, root := range roots {
+		merged = append(merged, mergeComponent(sorted, components[root]))
+	}
+	return merged
+}
+
+// mergeComponent builds the logical spec for a set of variants (indices into specs, in path order).
+func mergeComponent(specs []SwaggerSpec, members []int) SwaggerSpec {
+	primary := members[0]
+	for _, i := range members {
+		if specs[i].Format == yamlFormat {
+			primary = i
+			break
+		}
+	}
+
+	out := specs[primary]
+	out.Variants = make([]SpecVariant, 0, len(members))
+	out.Formats = nil
+	out.Drift = false
+	for _, i := range members {
+		out.Variants = append(out.Variants, SpecVariant{
+			Path:        specs[i].Path,
+			FileName:    specs[i].FileName,
+			Format:      specs[i].Format,
+			ContentHash: specs[i].ContentHash,
+		})
+		if !slices.Contains(out.Formats, specs[i].Format) {
+			out.Formats = append(out.Formats, specs[i].Format)
+		}
+		if specs[i].ContentHash != out.ContentHash {
+			out.Drift = true
+		}
+	}
+	sort.Strings(out.Formats)
+	return out
+}
+
+// Variant returns the file holding this spec in the given format, if there is one.
+func (s SwaggerSpec) Variant(format string) (SpecVariant, bool) {
+	for _, v := range s.Variants {
+		if v.Format == format {
+			return v, true
+		}
+	}
+	return SpecVariant{}, false
+}
+
+// Convert returns the spec document encoded in format ("json" or "yaml").
+// The document is the primary file, or the bundle if the spec has one (see Bundled). It is
+// returned as-is when it already has that format; otherwise it is parsed and re-encoded, so
+// every construct in the file survives, including ones the typed documents (DocV2/DocV3) do
+// not model. JSON output is indented.
+func (s SwaggerSpec) Convert(format string) ([]byte, error) {
+	if s.Bundled != nil {
+		return encodeDocument(s.Bundled, jsonFormat, format, s.Path)
+	}
+	return encodeDocument(s.Raw, s.Format, format, s.Path)
+}
+
+// encodeDocument re-encodes data, a document in format own, in format. path only labels errors.
+// Data already in the requested format is returned unchanged.
+func encodeDocument(data []byte, own, format, path string) ([]byte, error) {
+	if format == own {
+		return data, nil
+	}
+
+	switch format {
+	case jsonFormat:
+		j, err := yaml.YAMLToJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert %q to JSON: %w", path, err)
+		}
+		var out bytes.Buffer
+		if err := json.Indent(&out, j, "", "  "); err != nil {
+			return nil, fmt.Errorf("failed to format JSON for %q: %w", path, err)
+		}
+		out.WriteByte('\n')
+		return out.Bytes(), nil
+	case yamlFormat:
+		y, err := yaml.JSONToYAML(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert %q to YAML: %w", path, err)
+		}
+		return y, nil
+	default:
+		return nil, fmt.Errorf("unsupported spec format %q", format)
+	}
+}
@@ -0,0 +1,130 @@
+package discovery_test
+
+import (
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Hossein-Roshandel/webswags/discovery"
+)
+
+const (
+	petsYAML  = "openapi: 3.0.3\ninfo:\n  title: Pets\n  version: \"1\"\npaths: {}\n"
+	petsJSON  = `{"paths": {}, "info": {"version": "1", "title": "Pets"}, "openapi": "3.0.3"}`
+	pets2JSON = `{"openapi": "3.0.3", "info": {"title": "Pets", "version": "2"}, "paths": {}}`
+	storeJSON = `{"openapi": "3.0.3", "info": {"title": "Store", "version": "1"}, "paths": {}}`
+)
+
+func TestDiscoverMergesVariants(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		fsys      fstest.MapFS
+		wantSpecs int
+		wantPath  string // of the first spec
+		wantForms []string
+		wantDrift bool
+	}{
+		{
+			name: "same document, same stem",
+			fsys: fstest.MapFS{
+				"openapi.json": {Data: []byte(petsJSON)},
+				"openapi.yaml": {Data: []byte(petsYAML)},
+			},
+			wantSpecs: 1, wantPath: "openapi.yaml", wantForms: []string{"json", "yaml"},
+		},
+		{
+			name: "same document, other stem",
+			fsys: fstest.MapFS{
+				"generated.json": {Data: []byte(petsJSON)},
+				"openapi.yaml":   {Data: []byte(petsYAML)},
+			},
+			wantSpecs: 1, wantPath: "openapi.yaml", wantForms: []string{"json", "yaml"},
+		},
+		{
+			name: "drifted copies",
+			fsys: fstest.MapFS{
+				"openapi.json": {Data: []byte(pets2JSON)},
+				"openapi.yaml": {Data: []byte(petsYAML)},
+			},
+			wantSpecs: 1, wantPath: "openapi.yaml", wantForms: []string{"json", "yaml"}, wantDrift: true,
+		},
+		{
+			name: "same document in two directories",
+			fsys: fstest.MapFS{
+				"a/openapi.yaml": {Data: []byte(petsYAML)},
+				"b/openapi.json": {Data: []byte(petsJSON)},
+			},
+			wantSpecs: 2, wantPath: "a/openapi.yaml", wantForms: []string{"yaml"},
+		},
+		{
+			name: "unrelated documents",
+			fsys: fstest.MapFS{
+				"pets.yaml":  {Data: []byte(petsYAML)},
+				"store.json": {Data: []byte(storeJSON)},
+			},
+			wantSpecs: 2, wantPath: "pets.yaml", wantForms: []string{"yaml"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			result, err := discovery.DiscoverFS(t.Context(), tt.fsys, ".", discovery.DiscoverOptions{})
+			if err != nil {
+				t.Fatalf("DiscoverFS: %v", err)
+			}
+			if len(result.Specs) != tt.wantSpecs {
+				t.Fatalf("found %d specs, want %d", len(result.Specs), tt.wantSpecs)
+			}
+			spec := result.Specs[0]
+			if spec.Path != tt.wantPath || !slices.Equal(spec.Formats, tt.wantForms) || spec.Drift != tt.wantDrift {
+				t.Errorf("spec %s with formats %q, drift %t; want %s with %q, drift %t",
+					spec.Path, spec.Formats, spec.Drift, tt.wantPath, tt.wantForms, tt.wantDrift)
+			}
+			for _, format := range tt.wantForms {
+				if _, ok := spec.Variant(format); !ok {
+					t.Errorf("spec %s has no %s variant", spec.Path, format)
+				}
+			}
+		})
+	}
+}
+
+// petsSortedYAML and petsIndentedJSON are the pets spec converted, with sorted keys.
+const (
+	petsSortedYAML   = "info:\n  title: Pets\n  version: \"1\"\nopenapi: 3.0.3\npaths: {}\n"
+	petsIndentedJSON = `{
+  "info": {
+    "title": "Pets",
+    "version": "1"
+  },
+  "openapi": "3.0.3",
+  "paths": {}
+}
+`
+)
+
+func TestSpecConvert(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		file   string
+		data   string
+		format string
+		want   string
+	}{
+		{file: "pets.yaml", data: petsYAML, format: "yaml", want: petsYAML},
+		{file: "pets.json", data: petsJSON, format: "json", want: petsJSON},
+		{file: "pets.json", data: petsJSON, format: "yaml", want: petsSortedYAML},
+		{file: "pets.yaml", data: petsYAML, format: "json", want: petsIndentedJSON},
+	}
+	for _, tt := range tests {
+		spec, err := discovery.ParseFS(fstest.MapFS{tt.file: {Data: []byte(tt.data)}}, tt.file)
+		if err != nil {
+			t.Fatalf("ParseFS(%s): %v", tt.file, err)
+		}
+		got, err := spec.Convert(tt.format)
+		if err != nil || string(got) != tt.want {
+			t.Errorf("Convert(%s) of %s = %q, %v; want %q", tt.format, tt.file, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package discovery
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// Service groups every discovered version of one API.
+//
+// Versions are ordered newest first. The default version, served when no version is
+// requested, is the highest stable semver (or the highest prerelease if there is no stable
+// one); non-semver version labels sort after all semver ones.
+type Service struct {
+	Slug        string        `json:"slug"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Default     string        `json:"default"` // VersionKey of the default version
+	Versions    []SwaggerSpec `json:"-"`
+}
+
+// Latest returns the default version of the service.
+func (s Service) Latest() SwaggerSpec {
+	for _, spec := range s.Versions {
+		if spec.VersionKey == s.Default {
+			return spec
+		}
+	}
+	return s.Versions[0]
+}
+
+// Version returns the version with the given key.
+func (s Service) Version(key string) (SwaggerSpec, bool) {
+	for _, spec := range s.Versions {
+		if spec.VersionKey == key {
+			return spec, true
+		}
+	}
+	return SwaggerSpec{}, false
+}
+
+var (
+	// titleVersionPattern matches a trailing version in a title, e.g. "Orders API v2" or "Orders (1.0)".
+	titleVersionPattern = regexp.MustCompile(`(?i)[\s\-_(]+v?\d+(\.\d+)*\)?$`)
+	// pathVersionPattern matches a directory or file-name segment that names a major version, e.g. "v2".
+	pathVersionPattern = regexp.MustCompile(`(?i)^v\d+(\.\d+)*$`)
+	// unsafeVersionPattern matches runs of characters that are not unreserved in a URL.
+	unsafeVersionPattern = regexp.MustCompile(`[^A-Za-z0-9._~-]+`)
+)
+
+// groupServices groups specs (already slugged, see assignSlugs) into services and fills in
+// each spec's ServiceSlug and VersionKey.
+//
+// Specs belong to the same service when their titles match after removing any trailing
+// version ("Orders API v1" and "Orders API v2" are one service). Each is keyed by info.version,
+// falling back to a version segment in its path ("apis/orders/v2/openapi.yaml" → "v2"), and
+// made safe to use as a URL path segment ("2024/01" → "2024-01"); Version keeps the raw label.
+// When two specs of a group still end up with the same key they are not versions of one API
+// but unrelated specs that share a name, so the group is split back into single-spec services.
+func groupServices(specs []SwaggerSpec) []Service {
+	groups := make(map[string][]int)
+	var order []string
+	for i := range specs {
+		key := Slugify(titleVersionPattern.ReplaceAllString(specs[i].Service, ""))
+		if _, seen := groups[key]; !seen {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], i)
+	}
+
+	// Every spec slug is reserved up front, so a group slug never shadows an existing spec URL.
+	taken := make(map[string]bool, len(specs))
+	for i := range specs {
+		taken[specs[i].Slug] = true
+	}
+
+	services := make([]Service, 0, len(order))
+	for _, key := range order {
+		members := groups[key]
+		if len(members) > 1 && assignVersionKeys(specs, members) {
+			slug := key
+			if taken[slug] && !memberHasSlug(specs, members, slug) {
+				slug = uniqueSlug(key, taken)
+			}
+			taken[slug] = true
+			services = append(services, newService(specs, members, slug))
+			continue
+		}
+
+		// Single spec, or specs that merely share a title: one service per spec.
+		for _, i := range members {
+			specs[i].VersionKey = versionKey(specs[i])
+			services = append(services, newService(specs, []int{i}, specs[i].Slug))
+		}
+	}
+
+	sort.SliceStable(services, func(i, j int) bool {
+		return services[i].Name < services[j].Name
+	})
+	return services
+}
+
+// assignVersionKeys gives each member a distinct VersionKey and reports whether that was possible.
+func assignVersionKeys(specs []SwaggerSpec, members []int) bool {
+	keys := make([]string, len(members))
+	seen := make(map[string]bool, len(members))
+	for j, i := range members {
+		keys[j] = versionKey(specs[i])
+		if seen[keys[j]] {
+			// Same info.version: let the path decide (e.g. v1/ and v2/ both saying "1.0.0").
+			clear(seen)
+			for jj, ii := range members {
+				keys[jj] = pathVersion(specs[ii].Path)
+				if keys[jj] == "" || seen[keys[jj]] {
+					return false
+				}
+				seen[keys[jj]] = true
+			}
+			break
+		}
+		seen[keys[j]] = true
+	}
+
+	for j, i := range members {
+		specs[i].VersionKey = keys[j]
+	}
+	return true
+}
+
+func newService(specs []SwaggerSpec, members []int, slug string) Service {
+	svc := Service{Slug: slug}
+	for _, i := range members {
+		specs[i].ServiceSlug = slug
+		svc.Versions = append(svc.Versions, specs[i])
+	}
+
+	sort.SliceStable(svc.Versions, func(i, j int) bool {
+		return compareVersions(svc.Versions[i].VersionKey, svc.Versions[j].VersionKey) > 0
+	})
+
+	svc.Default = svc.Versions[0].VersionKey
+	for _, spec := range svc.Versions {
+		if v := canonicalSemver(spec.VersionKey); v != "" && semver.Prerelease(v) == "" {
+			svc.Default = spec.VersionKey
+			break
+		}
+	}
+
+	latest := svc.Latest()
+	svc.Name = titleVersionPattern.ReplaceAllString(latest.Service, "")
+	if len(members) == 1 || svc.Name == "" {
+		svc.Name = latest.Service
+	}
+	svc.Description = latest.Description
+	return svc
+}
+
+// versionKey returns the label identifying a spec among the versions of its service.
+func versionKey(spec SwaggerSpec) string {
+	if v := versionToken(spec.Version); v != "" {
+		return v
+	}
+	if v := pathVersion(spec.Path); v != "" {
+		return v
+	}
+	return spec.Slug
+}
+
+// versionToken turns a version label into a URL path segment: runs of characters other than
+// letters, digits, '.', '_', '~' and '-' become '-' ("1.0@beta" → "1.0-beta"), so a key never
+// contains '/' or '@', the separators of service, version and revision in a spec reference.
+// Leading and trailing dots and dashes are trimmed, so "." and ".." give "".
+func versionToken(version string) string {
+	return strings.Trim(unsafeVersionPattern.ReplaceAllString(version, "-"), ".-")
+}
+
+// pathVersion returns the nearest version-like segment of path ("v2"), checking the file
+// name ("orders-v2.yaml") before the directories. It returns "" if there is none.
+func pathVersion(path string) string {
+	cleanPath := filepath.Clean(path)
+	stem := strings.TrimSuffix(filepath.Base(cleanPath), filepath.Ext(cleanPath))
+	for _, part := range reversed(strings.FieldsFunc(stem, func(r rune) bool {
+		return r == '-' || r == '_' || r == '.'
+	})) {
+		if pathVersionPattern.MatchString(part) {
+			return strings.ToLower(part)
+		}
+	}
+
+	for _, segment := range reversed(strings.Split(filepath.Dir(cleanPath), string(filepath.Separator))) {
+		if pathVersionPattern.MatchString(segment) {
+			return strings.ToLower(segment)
+		}
+	}
+	return ""
+}
+
+// canonicalSemver returns v in golang.org/x/mod/semver form ("1.2" → "v1.2"), or "" if it is not semver.
+func canonicalSemver(v string) string {
+	if !strings.HasPrefix(v, "v") && !strings.HasPrefix(v, "V") {
+		v = "v" + v
+	}
+	v = "v" + v[1:]
+	if !semver.IsValid(v) {
+		return ""
+	}
+	return v
+}
+
+// compareVersions orders version labels: semver ones by precedence, above any non-semver
+// label; non-semver labels lexically. It returns -1, 0 or +1 like strings.Compare.
+func compareVersions(a, b string) int {
+	va, vb := canonicalSemver(a), canonicalSemver(b)
+	switch {
+	case va != "" && vb != "":
+		if c := semver.Compare(va, vb); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	case va != "":
+		return 1
+	case vb != "":
+		return -1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func memberHasSlug(specs []SwaggerSpec, members []int, slug string) bool {
+	for _, i := range members {
+		if specs[i].Slug == slug {
+			return true
+		}
+	}
+	return false
+}
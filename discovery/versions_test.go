@@ -0,0 +1,151 @@
+package discovery_test
+
+import (
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Hossein-Roshandel/webswags/discovery"
+)
+
+// versionKeys returns the keys of the versions of a service, in order.
+func versionKeys(service discovery.Service) []string {
+	keys := make([]string, 0, len(service.Versions))
+	for _, spec := range service.Versions {
+		keys = append(keys, spec.VersionKey)
+	}
+	return keys
+}
+
+func TestDiscoverGroupsVersions(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		fsys        fstest.MapFS
+		service     string
+		wantKeys    []string
+		wantDefault string
+	}{
+		{
+			name: "stable before prerelease",
+			fsys: fstest.MapFS{
+				"orders/v1/openapi.yaml": specFile("Orders API v1", "1.0.0"),
+				"orders/v2/openapi.yaml": specFile("Orders API v2", "2.0.0"),
+				"orders/v3/openapi.yaml": specFile("Orders API", "3.0.0-beta.1"),
+			},
+			service:     "orders-api",
+			wantKeys:    []string{"3.0.0-beta.1", "2.0.0", "1.0.0"},
+			wantDefault: "2.0.0",
+		},
+		{
+			name: "prerelease only",
+			fsys: fstest.MapFS{
+				"a.yaml": specFile("Pets", "1.0.0-rc.1"),
+				"b.yaml": specFile("Pets", "1.0.0-alpha"),
+			},
+			service:     "pets",
+			wantKeys:    []string{"1.0.0-rc.1", "1.0.0-alpha"},
+			wantDefault: "1.0.0-rc.1",
+		},
+		{
+			name: "labels after semver",
+			fsys: fstest.MapFS{
+				"a.yaml": specFile("Store", "latest"),
+				"b.yaml": specFile("Store", "v1.2"),
+			},
+			service:     "store",
+			wantKeys:    []string{"v1.2", "latest"},
+			wantDefault: "v1.2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			result, err := discovery.DiscoverFS(t.Context(), tt.fsys, ".", discovery.DiscoverOptions{})
+			if err != nil {
+				t.Fatalf("DiscoverFS: %v", err)
+			}
+			if len(result.Services) != 1 {
+				t.Fatalf("found %d services, want 1", len(result.Services))
+			}
+			service := result.Services[0]
+			if service.Slug != tt.service {
+				t.Errorf("service = %q, want %q", service.Slug, tt.service)
+			}
+			if got := versionKeys(service); !slices.Equal(got, tt.wantKeys) {
+				t.Errorf("versions = %q, want %q", got, tt.wantKeys)
+			}
+			if service.Default != tt.wantDefault || service.Latest().VersionKey != tt.wantDefault {
+				t.Errorf("default = %q, want %q", service.Default, tt.wantDefault)
+			}
+		})
+	}
+}
+
+func TestDiscoverSplitsUnrelatedSpecsSharingAName(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"auth/openapi.yaml":    specFile("Users", "1.0.0"),
+		"billing/openapi.yaml": specFile("Users", "1.0.0"),
+	}
+	result, err := discovery.DiscoverFS(t.Context(), fsys, ".", discovery.DiscoverOptions{})
+	if err != nil {
+		t.Fatalf("DiscoverFS: %v", err)
+	}
+	if len(result.Services) != 2 {
+		t.Fatalf("found %d services, want 2", len(result.Services))
+	}
+	for _, service := range result.Services {
+		if len(service.Versions) != 1 {
+			t.Errorf("service %s has %d versions, want 1", service.Slug, len(service.Versions))
+		}
+		if _, ok := service.Version("1.0.0"); !ok {
+			t.Errorf("service %s has no version 1.0.0", service.Slug)
+		}
+	}
+}
+
+func TestDiscoverKeysVersionsByPath(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"pets/v1/openapi.yaml": specFile("Pets", ""),
+		"pets/v2/openapi.yaml": specFile("Pets", ""),
+	}
+	result, err := discovery.DiscoverFS(t.Context(), fsys, ".", discovery.DiscoverOptions{})
+	if err != nil {
+		t.Fatalf("DiscoverFS: %v", err)
+	}
+	if len(result.Services) != 1 {
+		t.Fatalf("found %d services, want 1", len(result.Services))
+	}
+	if got := versionKeys(result.Services[0]); !slices.Equal(got, []string{"v2", "v1"}) {
+		t.Errorf("versions = %q, want [v2 v1]", got)
+	}
+}
+
+func TestDiscoverKeysVersionsByURLSafeToken(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"a.yaml": specFile("Ledger", "2024/01"),
+		"b.yaml": specFile("Ledger", "1.0@beta"),
+		"c.yaml": specFile("Ledger", "v1 (draft)"),
+		"d.yaml": specFile("Ledger", "2.0.0"),
+		"e.yaml": specFile("Ledger", "../.."),
+	}
+	result, err := discovery.DiscoverFS(t.Context(), fsys, ".", discovery.DiscoverOptions{})
+	if err != nil {
+		t.Fatalf("DiscoverFS: %v", err)
+	}
+	if len(result.Services) != 1 {
+		t.Fatalf("found %d services, want 1", len(result.Services))
+	}
+	service := result.Services[0]
+	want := []string{"2.0.0", "v1-draft", "ledger-e-yaml", "2024-01", "1.0-beta"}
+	if got := versionKeys(service); !slices.Equal(got, want) {
+		t.Errorf("versions = %q, want %q", got, want)
+	}
+	spec, ok := service.Version("1.0-beta")
+	if !ok || spec.Version != "1.0@beta" {
+		t.Errorf("Version(1.0-beta) = %q, %t; want the raw version 1.0@beta", spec.Version, ok)
+	}
+}
@@ -0,0 +1,154 @@
+package discovery
+
+import (
+	"context"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"sort"
+)
+
+// candidate is a file the walker handed over to the parser pool.
+type candidate struct {
+	path string
+	size int64
+}
+
+// walker enumerates candidate spec files below a root of fsys, honouring DiscoverOptions.
+type walker struct {
+	fsys   fs.FS
+	root   string
+	opts   DiscoverOptions
+	ignore *ignoreSet
+
+	// visited holds the resolved paths of directories already walked, so that
+	// symlink cycles are not followed forever.
+	visited map[string]bool
+
+	// skipped records the candidate files and directories the walk passed over, for the report.
+	skipped []ReportEntry
+}
+
+func newWalker(fsys fs.FS, root string, opts DiscoverOptions) *walker {
+	return &walker{
+		fsys:    fsys,
+		root:    root,
+		opts:    opts,
+		ignore:  newIgnoreSet(fsys, root, opts),
+		visited: make(map[string]bool),
+	}
+}
+
+// walk sends every candidate file to out in lexical order and returns ctx.Err() if cancelled.
+// The root itself must be readable; errors below it are logged and skipped.
+func (w *walker) walk(ctx context.Context, out chan<- candidate) error {
+	info, err := fs.Stat(w.fsys, w.root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		w.visitFile(ctx, w.root, info, out)
+		return ctx.Err()
+	}
+	return w.walkDir(ctx, w.root, 0, out)
+}
+
+func (w *walker) walkDir(ctx context.Context, dir string, depth int, out chan<- candidate) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if real, err := realPath(w.fsys, dir); err == nil {
+		if w.visited[real] {
+			slog.Debug("Skipping already visited directory", "path", dir)
+			return nil
+		}
+		w.visited[real] = true
+	}
+
+	entries, err := fs.ReadDir(w.fsys, dir)
+	if err != nil {
+		// Skip this directory if there's an error accessing it
+		slog.Debug("Skipping path due to access error", "path", dir, "error", err)
+		w.skip(dir, true, FileRejected, reasonUnread+": "+err.Error())
+		return nil
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+
+		if entry.Type()&fs.ModeSymlink != 0 && !w.opts.FollowSymlinks {
+			if info, statErr := fs.Stat(w.fsys, path); statErr == nil {
+				w.skip(path, info.IsDir(), FileIgnored, reasonSymlink)
+			}
+			continue
+		}
+
+		info, statErr := statEntry(w.fsys, path, entry)
+		if statErr != nil {
+			slog.Debug("Skipping path due to access error", "path", path, "error", statErr)
+			w.skip(path, entry.IsDir(), FileRejected, reasonUnread+": "+statErr.Error())
+			continue
+		}
+
+		if rel, ok := w.ignore.relPath(path); ok {
+			if why := w.ignore.exclusion(rel, info.IsDir()); why != "" {
+				w.skip(path, info.IsDir(), FileIgnored, why)
+				continue
+			}
+		}
+
+		if info.IsDir() {
+			if !w.opts.withinDepth(depth + 1) {
+				w.skip(path, true, FileIgnored, depthReason(w.opts))
+				continue
+			}
+			if walkErr := w.walkDir(ctx, path, depth+1, out); walkErr != nil {
+				return walkErr
+			}
+			continue
+		}
+
+		w.visitFile(ctx, path, info, out)
+	}
+	return ctx.Err()
+}
+
+// statEntry returns the FileInfo for an entry, resolving the target of symlinks.
+func statEntry(fsys fs.FS, path string, entry fs.DirEntry) (fs.FileInfo, error) {
+	if entry.Type()&fs.ModeSymlink != 0 {
+		return fs.Stat(fsys, path)
+	}
+	return entry.Info()
+}
+
+func (w *walker) visitFile(ctx context.Context, path string, info fs.FileInfo, out chan<- candidate) {
+	if !info.Mode().IsRegular() || !isCandidateFile(path) {
+		return
+	}
+	if why := skipReason(w.ignore, w.opts, path, info.Size()); why != "" {
+		w.skip(path, false, FileIgnored, why)
+		return
+	}
+
+	select {
+	case out <- candidate{path: path, size: info.Size()}:
+	case <-ctx.Done():
+	}
+}
+
+// skip records a path the walk passes over. Files are only recorded if they could hold a spec.
+func (w *walker) skip(path string, isDir bool, status FileStatus, reason string) {
+	if !isDir && !isCandidateFile(path) {
+		return
+	}
+	slog.Debug("Skipping path", "path", path, "status", status, "reason", reason)
+	w.skipped = append(w.skipped, ReportEntry{Path: path, Dir: isDir, Status: status, Reason: reason})
+}
+
+// sortTimings orders timings by path for deterministic output.
+func sortTimings(timings []FileTiming) {
+	sort.Slice(timings, func(i, j int) bool {
+		return timings[i].Path < timings[j].Path
+	})
+}
@@ -0,0 +1,164 @@
+// Package egress guards the CORS proxy against server-side request forgery: it decides which URLs
+// the proxy may fetch (those below a server a discovered spec declares, and those an explicit
+// allowlist names) and refuses, when connecting, addresses on private, loopback and link-local
+// networks unless the allowlist lets them through, so the proxy cannot reach cloud metadata or
+// internal admin endpoints on behalf of whoever calls it.
+package egress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// Errors of Permit and of connections the proxy may not make.
+var (
+	ErrNotAllowed     = errors.New("target is not allowed")
+	ErrPrivateAddress = errors.New("address is private")
+)
+
+// Connection settings of Transport.
+const (
+	dialTimeout   = 10 * time.Second
+	dialKeepAlive = 30 * time.Second
+)
+
+// Policy is what the proxy may fetch.
+type Policy struct {
+	rules []Rule
+}
+
+// NewPolicy returns the Policy of an allowlist. With no rules, the proxy fetches URLs of
+// discovered servers on public addresses only.
+func NewPolicy(rules []Rule) *Policy {
+	return &Policy{rules: rules}
+}
+
+// Grant is what lets the proxy fetch a URL: a server of a service, or a rule of the allowlist.
+type Grant struct {
+	Service string // service of the server the URL is below, "" if a rule matched
+	rule    *Rule  // the rule that matched, nil for servers
+}
+
+// grantKey is the context key of the Grant of a request being sent, for the dial it makes.
+type grantKey struct{}
+
+// Permit decides whether the proxy may fetch u: it must be an http(s) URL at or below one of
+// servers, or one a rule of the allowlist matches. The Grant names the service of the first
+// server u is below, or else the rule that matched.
+func (p *Policy) Permit(u *url.URL, servers []Server) (Grant, error) {
+	if !webScheme(u.Scheme) || u.Hostname() == "" {
+		return Grant{}, fmt.Errorf("%w: %s is not an http or https URL", ErrNotAllowed, u.Redacted())
+	}
+	for _, server := range servers {
+		if server.under(u) {
+			return Grant{Service: server.Service}, nil
+		}
+	}
+	for i := range p.rules {
+		if p.rules[i].matches(u) {
+			return Grant{rule: &p.rules[i]}, nil
+		}
+	}
+	return Grant{}, fmt.Errorf("%w: %s is not below a server of a discovered spec or in the allowlist",
+		ErrNotAllowed, u.Redacted())
+}
+
+// Transport returns an HTTP transport that sends only the requests Permit lets through, with
+// the servers returns at the time, redirects included. It connects to public addresses, and to
+// a private one only if a network of the allowlist holds it or the rule that let the request
+// through names it (see Rule.reaches). The address is checked as the connection is made, after
+// name resolution, so a name cannot be re-pointed at a private address once Permit has let its
+// URL through. Proxies from the environment are not used, as they would connect to the target
+// themselves.
+func (p *Policy) Transport(servers func() []Server) http.RoundTripper {
+	transport := &http.Transport{}
+	if defaults, ok := http.DefaultTransport.(*http.Transport); ok {
+		transport = defaults.Clone()
+	}
+	transport.Proxy = nil
+	transport.DialContext = p.dialContext
+	return &guard{policy: p, servers: servers, next: transport}
+}
+
+// guard is the transport of a Policy.
+type guard struct {
+	policy  *Policy
+	servers func() []Server
+	next    http.RoundTripper
+}
+
+// RoundTrip sends req if the policy permits it, with its Grant in the context of the dial.
+func (g *guard) RoundTrip(req *http.Request) (*http.Response, error) {
+	grant, err := g.policy.Permit(req.URL, g.servers())
+	if err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, err
+	}
+	return g.next.RoundTrip(req.WithContext(context.WithValue(req.Context(), grantKey{}, grant)))
+}
+
+// dialContext connects to addr, a host and port, unless the host resolves to an address the
+// Grant of the request being sent does not let the proxy reach.
+func (p *Policy) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	grant, _ := ctx.Value(grantKey{}).(Grant)
+	dialer := &net.Dialer{
+		Timeout:   dialTimeout,
+		KeepAlive: dialKeepAlive,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			return p.checkAddress(grant, address)
+		},
+	}
+	return dialer.DialContext(ctx, network, addr)
+}
+
+// checkAddress returns an error for address, a resolved IP and port, if it is private and
+// neither a network of the allowlist nor the rule of grant lets the proxy reach it.
+func (p *Policy) checkAddress(grant Grant, address string) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: cannot parse %s: %w", ErrPrivateAddress, address, err)
+	}
+	addr := addrPort.Addr().Unmap()
+	if !Private(addr) {
+		return nil
+	}
+	for _, rule := range p.rules {
+		if rule.kind == ruleNetwork && rule.network.Contains(addr) {
+			return nil
+		}
+	}
+	if grant.rule != nil && grant.rule.reaches(addr) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s (allow it with -proxy-allow)", ErrPrivateAddress, addr)
+}
+
+// Private reports whether addr is not a public unicast address: loopback, private (RFC 1918 and
+// unique local), link-local (which holds the cloud metadata endpoint 169.254.169.254), shared
+// (RFC 6598, used by some clouds for metadata too), "this network" (0.0.0.0/8), NAT64 (RFC 6052
+// and 8215, which translate to any IPv4 address, private ones included), unspecified or
+// multicast. IPv4-mapped IPv6 addresses are checked as the IPv4 addresses they are.
+func Private(addr netip.Addr) bool {
+	if addr.Is4In6() {
+		addr = addr.Unmap()
+	}
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsMulticast() ||
+		addr.IsUnspecified() {
+		return true
+	}
+	for _, network := range []string{"0.0.0.0/8", "100.64.0.0/10", "64:ff9b::/96", "64:ff9b:1::/48"} {
+		if netip.MustParsePrefix(network).Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,258 @@
+package egress_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	oas3 "github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/Hossein-Roshandel/webswags/egress"
+)
+
+const petsSpec = `openapi: 3.0.3
+info: {title: Pets, version: "1"}
+servers:
+  - url: https://api.example.com/{version}
+    variables:
+      version: {default: v1, enum: [v1, v2]}
+  - url: /relative
+paths: {}
+components:
+  securitySchemes:
+    oauth:
+      type: oauth2
+      flows:
+        clientCredentials:
+          tokenUrl: https://auth.example.com/token
+          scopes: {}
+`
+
+func load(t *testing.T, data string) *oas3.T {
+	t.Helper()
+	doc, err := oas3.NewLoader().LoadFromData([]byte(data))
+	if err != nil {
+		t.Fatalf("LoadFromData: %v", err)
+	}
+	return doc
+}
+
+// serverURLs returns the URLs of doc as strings.
+func serverURLs(doc *oas3.T) []string {
+	var urls []string
+	for _, u := range egress.ServerURLs(doc) {
+		urls = append(urls, u.String())
+	}
+	return urls
+}
+
+func TestServerURLs(t *testing.T) {
+	t.Parallel()
+	want := []string{"https://api.example.com/v1", "https://api.example.com/v2", "https://auth.example.com/token"}
+	if got := serverURLs(load(t, petsSpec)); !slices.Equal(got, want) {
+		t.Errorf("ServerURLs = %q, want %q", got, want)
+	}
+}
+
+func TestServerURLsCapIsDeterministic(t *testing.T) {
+	t.Parallel()
+	values := make([]string, 10)
+	for i := range values {
+		values[i] = strconv.Quote(strconv.Itoa(i))
+	}
+	enum := "[" + strings.Join(values, ", ") + "]"
+	spec := `openapi: 3.0.3
+info: {title: Regions, version: "1"}
+servers:
+  - url: https://{z}.{a}.example.com
+    variables:
+      z: {default: "0", enum: ` + enum + `}
+      a: {default: "0", enum: ` + enum + `}
+paths: {}
+`
+	first := serverURLs(load(t, spec))
+	if len(first) != 64 || first[0] != "https://0.0.example.com" || first[63] != "https://3.6.example.com" {
+		t.Fatalf("ServerURLs = %d URLs from %q to %q, want 64 from a=0 z=0 to a=6 z=3",
+			len(first), first[0], first[len(first)-1])
+	}
+	for range 20 {
+		if got := serverURLs(load(t, spec)); !slices.Equal(got, first) {
+			t.Fatalf("ServerURLs changed between calls: %q, then %q", first, got)
+		}
+	}
+}
+
+func TestPermit(t *testing.T) {
+	t.Parallel()
+	var servers []egress.Server
+	for _, u := range egress.ServerURLs(load(t, petsSpec)) {
+		servers = append(servers, egress.Server{Service: "pets", URL: u})
+	}
+	var rules []egress.Rule
+	for _, raw := range []string{
+		"*.internal.example.com", "localhost:8080", "https://docs.example.com/api", "10.0.0.0/8",
+	} {
+		rule, err := egress.ParseRule(raw)
+		if err != nil {
+			t.Fatalf("ParseRule(%q): %v", raw, err)
+		}
+		rules = append(rules, rule)
+	}
+	policy := egress.NewPolicy(rules)
+
+	tests := []struct {
+		url         string
+		wantService string
+		wantErr     bool
+	}{
+		{url: "https://api.example.com/v1", wantService: "pets"},
+		{url: "https://api.example.com/v2/pets/1?limit=5", wantService: "pets"},
+		{url: "https://API.example.com:443/v1/pets/", wantService: "pets"},
+		{url: "https://auth.example.com/token", wantService: "pets"},
+		{url: "https://billing.internal.example.com/invoices"},
+		{url: "http://localhost:8080/health"},
+		{url: "https://docs.example.com/api/openapi.json"},
+		{url: "http://10.1.2.3/status"},
+		{url: "https://api.example.com/v10/pets", wantErr: true},
+		{url: "https://api.example.com/v1/../admin", wantErr: true},
+		{url: "https://api.example.com/v1/%2e%2e/admin", wantErr: true},
+		{url: "https://api.example.com/v1/%2E%2E%2Fadmin", wantErr: true},
+		{url: "https://api.example.com/v1/./pets", wantErr: true},
+		{url: "https://api.example.com/v1//pets", wantErr: true},
+		{url: "https://docs.example.com/api/..%2f..%2fadmin", wantErr: true},
+		{url: "http://api.example.com/v1/pets", wantErr: true},
+		{url: "https://api.example.com:8443/v1/pets", wantErr: true},
+		{url: "https://docs.example.com/apis", wantErr: true},
+		{url: "http://localhost:9090/health", wantErr: true},
+		{url: "ftp://api.example.com/v1", wantErr: true},
+		{url: "https://example.org/", wantErr: true},
+	}
+	for _, tt := range tests {
+		u, err := url.Parse(tt.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		grant, err := policy.Permit(u, servers)
+		switch {
+		case tt.wantErr && !errors.Is(err, egress.ErrNotAllowed):
+			t.Errorf("Permit(%s) = %q, %v; want ErrNotAllowed", tt.url, grant.Service, err)
+		case !tt.wantErr && (err != nil || grant.Service != tt.wantService):
+			t.Errorf("Permit(%s) = %q, %v; want %q", tt.url, grant.Service, err, tt.wantService)
+		}
+	}
+}
+
+func TestParseRuleRejects(t *testing.T) {
+	t.Parallel()
+	for _, raw := range []string{"", " ", "ftp://files.example.com", "https://", "10.0.0.0/33", "api.*.example.com"} {
+		if _, err := egress.ParseRule(raw); err == nil {
+			t.Errorf("ParseRule(%q) succeeded", raw)
+		}
+	}
+}
+
+func TestPrivate(t *testing.T) {
+	t.Parallel()
+	tests := map[string]bool{
+		"127.0.0.1":            true,
+		"10.1.2.3":             true,
+		"192.168.1.1":          true,
+		"169.254.169.254":      true,
+		"100.64.0.1":           true,
+		"0.1.2.3":              true,
+		"224.0.0.1":            true,
+		"::":                   true,
+		"::1":                  true,
+		"fd00::1":              true,
+		"fe80::1":              true,
+		"::ffff:10.0.0.1":      true,
+		"::ffff:127.0.0.1":     true,
+		"64:ff9b::a9fe:a9fe":   true, // 169.254.169.254 through NAT64
+		"64:ff9b::808:808":     true,
+		"64:ff9b:1::1":         true,
+		"8.8.8.8":              false,
+		"::ffff:8.8.8.8":       false,
+		"2001:4860:4860::8888": false,
+	}
+	for raw, want := range tests {
+		if got := egress.Private(netip.MustParseAddr(raw)); got != want {
+			t.Errorf("Private(%s) = %t, want %t", raw, got, want)
+		}
+	}
+}
+
+func TestTransportReachesPrivateAddressesThroughTheirRule(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+	port := strings.TrimPrefix(server.URL, "http://127.0.0.1:")
+	local := "http://localhost:" + port
+
+	tests := []struct {
+		name    string
+		rules   []string
+		servers []string // of the catalog
+		url     string
+		wantErr error
+	}{
+		{name: "not permitted", url: server.URL, wantErr: egress.ErrNotAllowed},
+		{
+			name: "server of the catalog", servers: []string{server.URL},
+			url: server.URL, wantErr: egress.ErrPrivateAddress,
+		},
+		{name: "network", rules: []string{"127.0.0.0/8"}, url: server.URL},
+		{name: "address", rules: []string{"127.0.0.1:" + port}, url: server.URL},
+		{name: "localhost", rules: []string{local + "/v1"}, url: local + "/v1/pets"},
+		{name: "any host", rules: []string{"*"}, url: local, wantErr: egress.ErrPrivateAddress},
+		{
+			name: "other path of a named host", rules: []string{local + "/v1"}, servers: []string{local},
+			url: local + "/admin", wantErr: egress.ErrPrivateAddress,
+		},
+		{
+			name: "other port of a named host", rules: []string{local + "/v1"},
+			url: "http://localhost:6379/v1", wantErr: egress.ErrNotAllowed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var rules []egress.Rule
+			for _, raw := range tt.rules {
+				rule, err := egress.ParseRule(raw)
+				if err != nil {
+					t.Fatal(err)
+				}
+				rules = append(rules, rule)
+			}
+			var servers []egress.Server
+			for _, raw := range tt.servers {
+				u, err := url.Parse(raw)
+				if err != nil {
+					t.Fatal(err)
+				}
+				servers = append(servers, egress.Server{Service: "local", URL: u})
+			}
+			transport := egress.NewPolicy(rules).Transport(func() []egress.Server { return servers })
+			client := &http.Client{Transport: transport}
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.Do(req)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Do = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
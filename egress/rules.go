@@ -0,0 +1,151 @@
+package egress
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Kinds of allowlist rules.
+const (
+	ruleHost    = iota // a host, with an optional port: "api.example.com", "*.example.com:8443", "*"
+	ruleURL            // a URL prefix: "https://api.example.com/v1"
+	ruleNetwork        // an address or network: "10.1.2.3", "10.0.0.0/8"
+)
+
+// Rule is an entry of the allowlist of the proxy (-proxy-allow).
+type Rule struct {
+	raw     string
+	kind    int
+	scheme  string // for URL rules
+	host    string // lower case; "*.example.com" matches subdomains, "*" any host
+	port    string // "" matches any port, except for URL rules, where it is the scheme's default
+	path    string // for URL rules, without a trailing slash
+	network netip.Prefix
+}
+
+// ParseRule parses an allowlist rule: a URL prefix ("https://api.example.com/v1"), a host with
+// an optional port ("api.example.com", "localhost:8080", "*.example.com", "*"), or an IP address
+// or network ("10.0.0.0/8").
+func ParseRule(raw string) (Rule, error) {
+	raw = strings.TrimSpace(raw)
+	rule := Rule{raw: raw}
+	switch {
+	case raw == "":
+		return Rule{}, errors.New("empty rule")
+	case strings.Contains(raw, "://"):
+		u, err := url.Parse(raw)
+		if err != nil {
+			return Rule{}, fmt.Errorf("rule %q: %w", raw, err)
+		}
+		if !webScheme(u.Scheme) || u.Hostname() == "" {
+			return Rule{}, fmt.Errorf("rule %q: want an http or https URL with a host", raw)
+		}
+		rule.kind, rule.scheme, rule.host = ruleURL, strings.ToLower(u.Scheme), strings.ToLower(u.Hostname())
+		rule.port, rule.path = portOf(u), strings.TrimRight(u.Path, "/")
+	case strings.Contains(raw, "/"):
+		network, err := netip.ParsePrefix(raw)
+		if err != nil {
+			return Rule{}, fmt.Errorf("rule %q: %w", raw, err)
+		}
+		rule.kind, rule.network = ruleNetwork, network.Masked()
+	default:
+		if addr, err := netip.ParseAddr(raw); err == nil {
+			rule.kind, rule.network = ruleNetwork, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen())
+			return rule, nil
+		}
+		rule.kind, rule.host = ruleHost, strings.ToLower(raw)
+		if host, port, err := net.SplitHostPort(raw); err == nil {
+			rule.host, rule.port = strings.ToLower(host), port
+		}
+		if rule.host == "" || (rule.host != "*" && strings.Contains(strings.TrimPrefix(rule.host, "*."), "*")) {
+			return Rule{}, fmt.Errorf("rule %q: want a host such as api.example.com or *.example.com", raw)
+		}
+	}
+	return rule, nil
+}
+
+// String returns the rule as it was written.
+func (r Rule) String() string {
+	return r.raw
+}
+
+// matches reports whether the rule allows fetching u.
+func (r Rule) matches(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	switch r.kind {
+	case ruleURL:
+		return r.scheme == strings.ToLower(u.Scheme) && r.host == host && r.port == portOf(u) &&
+			underPath(u.Path, r.path)
+	case ruleNetwork:
+		addr, err := netip.ParseAddr(host)
+		return err == nil && r.network.Contains(addr.Unmap())
+	default:
+		return r.matchesHost(host) && (r.port == "" || r.port == portOf(u))
+	}
+}
+
+// matchesHost reports whether the host of a host rule is host.
+func (r Rule) matchesHost(host string) bool {
+	switch {
+	case r.host == "*":
+		return true
+	case strings.HasPrefix(r.host, "*."):
+		return strings.HasSuffix(host, r.host[1:])
+	default:
+		return r.host == host
+	}
+}
+
+// reaches reports whether the rule lets the proxy connect to addr, a private address: a network
+// rule must hold it, and a host or URL rule name it, as an IP address or, for loopback addresses,
+// as localhost. Other names only reach public addresses, whatever they resolve to.
+func (r Rule) reaches(addr netip.Addr) bool {
+	switch {
+	case r.kind == ruleNetwork:
+		return r.network.Contains(addr)
+	case r.host == "localhost":
+		return addr.IsLoopback()
+	}
+	named, err := netip.ParseAddr(r.host)
+	return err == nil && named.Unmap() == addr
+}
+
+// webScheme reports whether the proxy fetches URLs of scheme.
+func webScheme(scheme string) bool {
+	scheme = strings.ToLower(scheme)
+	return scheme == "http" || scheme == "https"
+}
+
+// portOf returns the port of u, or the default port of its scheme.
+func portOf(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	if strings.EqualFold(u.Scheme, "https") {
+		return "443"
+	}
+	return "80"
+}
+
+// underPath reports whether p, an unescaped URL path, is prefix or below it; prefix has no
+// trailing slash. Paths that are not clean are below nothing (see cleanPath).
+func underPath(p, prefix string) bool {
+	if !cleanPath(p) {
+		return false
+	}
+	rest, ok := strings.CutPrefix(p, prefix)
+	return ok && (rest == "" || rest[0] == '/')
+}
+
+// cleanPath reports whether p, an unescaped URL path, is as path.Clean leaves it, but for a
+// trailing slash, and has no "..": a server would resolve dot segments (%2e%2e once unescaped)
+// and empty ones ("//") to a path outside the prefix p appears to be below.
+func cleanPath(p string) bool {
+	trimmed := strings.TrimSuffix(p, "/")
+	return trimmed == "" || (path.Clean(trimmed) == trimmed && !strings.Contains(p, ".."))
+}
@@ -0,0 +1,99 @@
+package egress
+
+import (
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+
+	oas3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+// maxServerURLs bounds the URLs one server of a spec expands to, as every combination of the
+// values of its variables is one.
+const maxServerURLs = 64
+
+// Server is a base URL of a service's API: the proxy fetches URLs at or below it.
+type Server struct {
+	Service string // slug of the service whose spec declares it
+	URL     *url.URL
+}
+
+// ServerURLs returns the absolute http(s) base URLs doc declares: its servers, with each value a
+// variable may take (those of its enum, or else its default alone, so a variable cannot point a
+// server anywhere), and the endpoints of its OAuth 2 flows and OpenID Connect discovery, which
+// Swagger UI calls too. Relative servers are left out: Swagger UI resolves them against the
+// spec's own URL, which it calls directly.
+func ServerURLs(doc *oas3.T) []*url.URL {
+	if doc == nil {
+		return nil
+	}
+	var raws []string
+	for _, server := range doc.Servers {
+		if server != nil {
+			raws = append(raws, expand(server.URL, server.Variables)...)
+		}
+	}
+	if doc.Components != nil {
+		for _, ref := range doc.Components.SecuritySchemes {
+			if ref == nil || ref.Value == nil {
+				continue
+			}
+			raws = append(raws, ref.Value.OpenIdConnectUrl)
+			if flows := ref.Value.Flows; flows != nil {
+				for _, flow := range []*oas3.OAuthFlow{
+					flows.Implicit, flows.Password, flows.ClientCredentials, flows.AuthorizationCode,
+				} {
+					if flow != nil {
+						raws = append(raws, flow.TokenURL, flow.RefreshURL)
+					}
+				}
+			}
+		}
+	}
+
+	var urls []*url.URL
+	for _, raw := range raws {
+		u, err := url.Parse(raw)
+		if err != nil || !webScheme(u.Scheme) || u.Hostname() == "" {
+			continue
+		}
+		u.Path = strings.TrimRight(u.Path, "/")
+		urls = append(urls, u)
+	}
+	return urls
+}
+
+// expand returns the URLs a server URL template stands for, with every combination of the values
+// of its variables, up to maxServerURLs. Variables are expanded by name, so the same URLs make
+// the cut every time.
+func expand(template string, variables map[string]*oas3.ServerVariable) []string {
+	urls := []string{template}
+	for _, name := range slices.Sorted(maps.Keys(variables)) {
+		variable := variables[name]
+		placeholder := "{" + name + "}"
+		if variable == nil || !strings.Contains(template, placeholder) {
+			continue
+		}
+		values := []string{variable.Default}
+		if len(variable.Enum) > 0 {
+			values = variable.Enum
+		}
+		var expanded []string
+		for _, u := range urls {
+			for _, value := range values {
+				if len(expanded) < maxServerURLs {
+					expanded = append(expanded, strings.ReplaceAll(u, placeholder, value))
+				}
+			}
+		}
+		urls = expanded
+	}
+	return urls
+}
+
+// under reports whether u is the server's base URL or below it.
+func (s Server) under(u *url.URL) bool {
+	return strings.EqualFold(s.URL.Scheme, u.Scheme) && strings.EqualFold(s.URL.Hostname(), u.Hostname()) &&
+		portOf(s.URL) == portOf(u) && underPath(u.Path, s.URL.Path)
+}
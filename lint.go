@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/Hossein-Roshandel/webswags/discovery"
+	"github.com/Hossein-Roshandel/webswags/lint"
+)
+
+// exitLintFailed is the exit code of "webswags lint" when findings reach -fail-on.
+const exitLintFailed = 3
+
+// runLint implements "webswags lint": it lints spec files, or the specs discovered in
+// directories, and prints the findings as text, JSON or SARIF. It returns the process exit code.
+func runLint(args []string) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	rulesetPath := flags.String("ruleset", "",
+		"Ruleset file (default: "+lint.RulesetFile+" in the current directory, if any)")
+	format := flags.String("format", "text", "Output format: text, json or sarif")
+	failOn := flags.String("fail-on", string(lint.SeverityError),
+		"Exit with status 3 if a finding is at least this severe: error, warning, info or off (never)")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: webswags lint [flags] [path...]\n\n")
+		fmt.Fprintf(flags.Output(), "Checks specs against the lint ruleset. Paths are spec files or directories to\n")
+		fmt.Fprintf(flags.Output(), "discover specs in (default: the current directory).\n\n")
+		fmt.Fprintf(flags.Output(), "Flags:\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	threshold, err := lint.ParseSeverity(*failOn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "lint: -fail-on: %v\n", err)
+		return exitUsage
+	}
+	if *format != "text" && *format != jsonFormat && *format != "sarif" {
+		fmt.Fprintf(os.Stderr, "lint: unsupported format %q\n", *format)
+		return exitUsage
+	}
+	ruleset, err := loadRuleset(*rulesetPath, ".")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "lint: %v\n", err)
+		return exitUsage
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	code := exitOK
+	var specs []discovery.SwaggerSpec
+	for _, path := range paths {
+		found, findErr := lintTargets(context.Background(), path)
+		if findErr != nil {
+			fmt.Fprintf(os.Stderr, "lint: %v\n", findErr)
+			code = exitError
+		}
+		specs = append(specs, found...)
+	}
+
+	reports := make([]LintReport, 0, len(specs))
+	for _, spec := range specs {
+		if spec.DocV3 == nil {
+			fmt.Fprintf(os.Stderr, "lint: %s could not be loaded as an OpenAPI document\n", spec.Path)
+			code = exitError
+			continue
+		}
+		reports = append(reports, newLintReport(spec, ruleset.Lint(spec)))
+	}
+
+	switch *format {
+	case jsonFormat:
+		err = writeLintJSON(os.Stdout, reports)
+	case "sarif":
+		targets := make([]lint.Target, 0, len(reports))
+		for _, report := range reports {
+			targets = append(targets, lint.Target{Path: report.Path, Result: report.Result})
+		}
+		err = lint.WriteSARIF(os.Stdout, ruleset, targets)
+	default:
+		err = writeLintText(os.Stdout, reports)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "lint: %v\n", err)
+		return exitError
+	}
+
+	if code == exitOK && threshold != lint.SeverityOff {
+		for _, report := range reports {
+			if worst := report.Worst(); worst != lint.SeverityOff && worst.AtLeast(threshold) {
+				return exitLintFailed
+			}
+		}
+	}
+	return code
+}
+
+// lintTargets parses path, a spec file, or discovers the specs of a directory.
+func lintTargets(ctx context.Context, path string) ([]discovery.SwaggerSpec, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		spec, parseErr := discovery.ParseFile(path)
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		return []discovery.SwaggerSpec{spec}, nil
+	}
+	result, err := discovery.Discover(ctx, path, discovery.DiscoverOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover specs in %s: %w", path, err)
+	}
+	return result.Specs, nil
+}
+
+// loadRuleset reads the ruleset at path, or lint.RulesetFile in dir if path is "". Without
+// either, it returns nil: every rule at its default severity.
+func loadRuleset(path, dir string) (*lint.Ruleset, error) {
+	if path == "" {
+		candidate := filepath.Join(dir, lint.RulesetFile)
+		if !isFile(candidate) {
+			return nil, nil //nolint:nilnil // no ruleset: the defaults apply
+		}
+		path = candidate
+	}
+	return lint.LoadRuleset(path)
+}
+
+// writeLintText prints findings one per line, as compilers do ("file:line:column: ..."), then a summary.
+func writeLintText(w io.Writer, reports []LintReport) error {
+	var total lint.Result
+	for _, report := range reports {
+		for _, f := range report.Findings {
+			location := report.Path
+			if f.Line > 0 {
+				location = fmt.Sprintf("%s:%d:%d", report.Path, f.Line, f.Column)
+			}
+			if _, err := fmt.Fprintf(w, "%s: %s: %s [%s]\n", location, f.Severity, f.Message, f.Rule); err != nil {
+				return err
+			}
+		}
+		total.Errors += report.Errors
+		total.Warnings += report.Warnings
+		total.Infos += report.Infos
+		total.Suppressed += report.Suppressed
+	}
+	_, err := fmt.Fprintf(w, "%d error(s), %d warning(s), %d info(s) in %d spec(s); %d suppressed\n",
+		total.Errors, total.Warnings, total.Infos, len(reports), total.Suppressed)
+	return err
+}
+
+// writeLintJSON prints one report per spec, as the lint endpoint returns them.
+func writeLintJSON(w io.Writer, reports []LintReport) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(reports)
+}
@@ -0,0 +1,217 @@
+// Package lint checks OpenAPI documents against house-style rules that go beyond validity,
+// such as naming conventions and required metadata.
+//
+// The built-in rules (see Rules) are configured by a Ruleset, usually read from a YAML file,
+// which sets the severity of each rule and its options. Findings can also be suppressed in the
+// document itself: an x-lint-ignore extension lists the rules (or true, for all of them) that
+// do not apply to the object it is set on and everything below it.
+//
+// Swagger 2.0 specs are linted through their OpenAPI 3 upgrade, with locations mapped back.
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	oas3 "github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/Hossein-Roshandel/webswags/discovery"
+)
+
+// Severity says how much a finding matters.
+type Severity string
+
+const (
+	// SeverityError marks a finding that should fail a build.
+	SeverityError Severity = "error"
+	// SeverityWarning marks a finding that should be fixed.
+	SeverityWarning Severity = "warning"
+	// SeverityInfo marks a suggestion.
+	SeverityInfo Severity = "info"
+	// SeverityOff disables a rule.
+	SeverityOff Severity = "off"
+)
+
+// rank orders severities from off (0) to error.
+func (s Severity) rank() int {
+	switch s {
+	case SeverityError:
+		return 3 //nolint:mnd // most severe
+	case SeverityWarning:
+		return 2 //nolint:mnd // between error and info
+	case SeverityInfo:
+		return 1
+	case SeverityOff:
+	}
+	return 0
+}
+
+// AtLeast reports whether s is as severe as other, or more.
+func (s Severity) AtLeast(other Severity) bool {
+	return s.rank() >= other.rank()
+}
+
+// ParseSeverity checks that s names a severity.
+func ParseSeverity(s string) (Severity, error) {
+	switch severity := Severity(s); severity {
+	case SeverityError, SeverityWarning, SeverityInfo, SeverityOff:
+		return severity, nil
+	}
+	return "", fmt.Errorf("unknown severity %q (want error, warning, info or off)", s)
+}
+
+// ignoreExtension is the extension that suppresses rules below the object it is set on.
+const ignoreExtension = "x-lint-ignore"
+
+// Finding is one place where a document breaks a rule.
+type Finding struct {
+	Rule      string   `json:"rule"`
+	Severity  Severity `json:"severity"`
+	Message   string   `json:"message"`
+	Pointer   string   `json:"pointer"`             // JSON pointer into the spec's file
+	Line      int      `json:"line,omitempty"`      // 1-based line in the file, 0 if unknown
+	Column    int      `json:"column,omitempty"`    // 1-based column in the file, 0 if unknown
+	Operation string   `json:"operation,omitempty"` // affected operation, e.g. "GET /pets/{id}"
+}
+
+// Result lists the findings of one spec, rule by rule and in document order within each rule.
+type Result struct {
+	Errors     int       `json:"errors"`
+	Warnings   int       `json:"warnings"`
+	Infos      int       `json:"infos"`
+	Suppressed int       `json:"suppressed"` // findings silenced by x-lint-ignore
+	Findings   []Finding `json:"findings"`
+}
+
+// Worst returns the severity of the most severe finding, or SeverityOff if there are none.
+func (r Result) Worst() Severity {
+	switch {
+	case r.Errors > 0:
+		return SeverityError
+	case r.Warnings > 0:
+		return SeverityWarning
+	case r.Infos > 0:
+		return SeverityInfo
+	default:
+		return SeverityOff
+	}
+}
+
+// Lint runs every enabled rule over spec.DocV3. Specs without one (files that failed to
+// load) have no findings; their problems are in spec.Diagnostics. A nil Ruleset runs every
+// rule with its default severity.
+func (rs *Ruleset) Lint(spec discovery.SwaggerSpec) Result {
+	result := Result{Findings: []Finding{}}
+	if spec.DocV3 == nil {
+		return result
+	}
+	ignored := findSuppressions(spec.DocV3)
+	locate := spec.Locator()
+
+	for _, rule := range Rules() {
+		severity, options := rs.config(rule)
+		if severity == SeverityOff {
+			continue
+		}
+		c := &checker{doc: spec.DocV3, options: options}
+		rule.check(c)
+
+		for _, f := range c.found {
+			if ignored.covers(rule.ID, f.Pointer) {
+				result.Suppressed++
+				continue
+			}
+			f.Rule, f.Severity = rule.ID, severity
+			f.Pointer, f.Line, f.Column = locate(f.Pointer)
+			result.Findings = append(result.Findings, f)
+			switch severity {
+			case SeverityError:
+				result.Errors++
+			case SeverityWarning:
+				result.Warnings++
+			case SeverityInfo, SeverityOff:
+				result.Infos++
+			}
+		}
+	}
+	return result
+}
+
+// suppressions maps JSON pointers to the rules x-lint-ignore disables there ("*" for all).
+type suppressions map[string][]string
+
+// findSuppressions collects every x-lint-ignore of a document. The document is walked in its
+// JSON form, so extensions are found on any object, wherever kin-openapi keeps them.
+func findSuppressions(doc *oas3.T) suppressions {
+	found := suppressions{}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return found
+	}
+	var tree any
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return found
+	}
+	found.walk("", tree)
+	return found
+}
+
+func (s suppressions) walk(pointer string, node any) {
+	switch node := node.(type) {
+	case map[string]any:
+		for key, value := range node {
+			if key == ignoreExtension {
+				s[pointer] = append(s[pointer], ignoredRules(value)...)
+				continue
+			}
+			s.walk(pointer+"/"+escapePointer(key), value)
+		}
+	case []any:
+		for i, value := range node {
+			s.walk(fmt.Sprintf("%s/%d", pointer, i), value)
+		}
+	}
+}
+
+// ignoredRules reads an x-lint-ignore value: a rule ID, a list of them, or true for all rules.
+func ignoredRules(value any) []string {
+	switch value := value.(type) {
+	case bool:
+		if value {
+			return []string{"*"}
+		}
+	case string:
+		return []string{value}
+	case []any:
+		rules := make([]string, 0, len(value))
+		for _, v := range value {
+			if rule, ok := v.(string); ok {
+				rules = append(rules, rule)
+			}
+		}
+		return rules
+	}
+	return nil
+}
+
+// covers reports whether rule is suppressed at pointer or at any of its ancestors.
+func (s suppressions) covers(rule, pointer string) bool {
+	for {
+		for _, ignored := range s[pointer] {
+			if ignored == rule || ignored == "*" {
+				return true
+			}
+		}
+		i := strings.LastIndex(pointer, "/")
+		if i < 0 {
+			return false
+		}
+		pointer = pointer[:i]
+	}
+}
+
+// escapePointer escapes a single JSON pointer reference token.
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
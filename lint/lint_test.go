@@ -0,0 +1,230 @@
+package lint_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Hossein-Roshandel/webswags/discovery"
+	"github.com/Hossein-Roshandel/webswags/lint"
+)
+
+// petsSpec breaks every built-in rule once.
+const petsSpec = `openapi: 3.0.3
+info:
+  title: Pets
+  version: "1"
+paths:
+  /pets:
+    get:
+      operationId: list_pets
+      responses:
+        "200":
+          description: Pets
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  type: object
+                  properties:
+                    id: {type: integer}
+        "404":
+          description: Not found
+    delete:
+      tags: [pets]
+      responses:
+        "204":
+          description: Deleted
+        default:
+          description: Error
+          content:
+            application/json:
+              schema: {$ref: '#/components/schemas/Problem'}
+components:
+  schemas:
+    Problem: {type: object}
+`
+
+// Findings of petsSpec with every rule at its default severity, as "rule pointer".
+const (
+	infoContact    = "info-contact /info"
+	operationID    = "operation-operationId /paths/~1pets/delete"
+	camelCase      = "operation-operationId-camel-case /paths/~1pets/get/operationId"
+	operationTags  = "operation-tags /paths/~1pets/get"
+	inlineSchema   = "no-inline-schemas /paths/~1pets/get/responses/200/content/application~1json/schema/items"
+	problemMissing = "error-response-problem /paths/~1pets/get/responses/404"
+)
+
+func parse(t *testing.T, data string) discovery.SwaggerSpec {
+	t.Helper()
+	spec, err := discovery.ParseFS(fstest.MapFS{"openapi.yaml": {Data: []byte(data)}}, "openapi.yaml")
+	if err != nil {
+		t.Fatalf("ParseFS: %v", err)
+	}
+	return spec
+}
+
+// found lists the findings of a result as "rule pointer", in order.
+func found(result lint.Result) []string {
+	findings := make([]string, 0, len(result.Findings))
+	for _, f := range result.Findings {
+		findings = append(findings, f.Rule+" "+f.Pointer)
+	}
+	return findings
+}
+
+func TestLintRules(t *testing.T) {
+	t.Parallel()
+	result := (*lint.Ruleset)(nil).Lint(parse(t, petsSpec))
+	want := []string{infoContact, operationID, camelCase, operationTags, inlineSchema, problemMissing}
+	if got := found(result); !slices.Equal(got, want) {
+		t.Errorf("findings = %q, want %q", got, want)
+	}
+	if result.Warnings != len(want) || result.Errors != 0 || result.Worst() != lint.SeverityWarning {
+		t.Errorf("counts = %d errors, %d warnings; want %d warnings", result.Errors, result.Warnings, len(want))
+	}
+	for _, f := range result.Findings {
+		if f.Line == 0 {
+			t.Errorf("finding %s %s has no line", f.Rule, f.Pointer)
+		}
+	}
+}
+
+func TestLintIgnore(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name           string
+		from, to       string
+		want           []string
+		wantSuppressed int
+	}{
+		{
+			name:           "single rule",
+			from:           "info:\n",
+			to:             "info:\n  x-lint-ignore: info-contact\n",
+			want:           []string{operationID, camelCase, operationTags, inlineSchema, problemMissing},
+			wantSuppressed: 1,
+		},
+		{
+			name:           "rule list",
+			from:           "    get:\n",
+			to:             "    get:\n      x-lint-ignore: [operation-tags, error-response-problem]\n",
+			want:           []string{infoContact, operationID, camelCase, inlineSchema},
+			wantSuppressed: 2,
+		},
+		{
+			name:           "all rules below a path",
+			from:           "  /pets:\n",
+			to:             "  /pets:\n    x-lint-ignore: true\n",
+			want:           []string{infoContact},
+			wantSuppressed: 5,
+		},
+		{
+			name:           "other rule",
+			from:           "info:\n",
+			to:             "info:\n  x-lint-ignore: operation-tags\n",
+			want:           []string{infoContact, operationID, camelCase, operationTags, inlineSchema, problemMissing},
+			wantSuppressed: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			result := (*lint.Ruleset)(nil).Lint(parse(t, strings.Replace(petsSpec, tt.from, tt.to, 1)))
+			if got := found(result); !slices.Equal(got, tt.want) {
+				t.Errorf("findings = %q, want %q", got, tt.want)
+			}
+			if result.Suppressed != tt.wantSuppressed {
+				t.Errorf("suppressed = %d, want %d", result.Suppressed, tt.wantSuppressed)
+			}
+		})
+	}
+}
+
+func TestRuleset(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		ruleset     string
+		want        []string
+		wantErrors  int
+		wantProblem string // message of the first error-response-problem finding
+	}{
+		{
+			name:       "severities",
+			ruleset:    "rules:\n  info-contact: off\n  operation-tags: error\n",
+			want:       []string{operationID, camelCase, operationTags, inlineSchema, problemMissing},
+			wantErrors: 1,
+		},
+		{
+			name:       "extends none",
+			ruleset:    "extends: none\nrules:\n  operation-operationId: error\n",
+			want:       []string{operationID},
+			wantErrors: 1,
+		},
+		{
+			name: "options",
+			ruleset: "extends: none\nrules:\n  error-response-problem:\n" +
+				"    severity: info\n    schema: ApiProblem\n",
+			want: []string{
+				"error-response-problem /paths/~1pets/delete/responses/default/content",
+				problemMissing,
+			},
+			wantProblem: "the default response of DELETE /pets does not use the ApiProblem schema",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			rs, err := lint.ParseRuleset([]byte(tt.ruleset))
+			if err != nil {
+				t.Fatalf("ParseRuleset: %v", err)
+			}
+			result := rs.Lint(parse(t, petsSpec))
+			if got := found(result); !slices.Equal(got, tt.want) {
+				t.Errorf("findings = %q, want %q", got, tt.want)
+			}
+			if result.Errors != tt.wantErrors {
+				t.Errorf("errors = %d, want %d", result.Errors, tt.wantErrors)
+			}
+			if tt.wantProblem != "" && result.Findings[0].Message != tt.wantProblem {
+				t.Errorf("message = %q, want %q", result.Findings[0].Message, tt.wantProblem)
+			}
+		})
+	}
+}
+
+func TestParseRulesetRejects(t *testing.T) {
+	t.Parallel()
+	tests := map[string]string{
+		"unknown rule":     "rules:\n  operation-summary: warning\n",
+		"unknown option":   "rules:\n  operation-tags:\n    severity: error\n    schema: Problem\n",
+		"unknown severity": "rules:\n  operation-tags: fatal\n",
+		"unknown extends":  "extends: strict\n",
+		"invalid yaml":     "rules: [",
+	}
+	for name, ruleset := range tests {
+		if _, err := lint.ParseRuleset([]byte(ruleset)); err == nil {
+			t.Errorf("%s: ParseRuleset(%q) succeeded", name, ruleset)
+		}
+	}
+}
+
+func TestRulesetEffective(t *testing.T) {
+	t.Parallel()
+	rs, err := lint.ParseRuleset([]byte("extends: none\nrules:\n  operation-tags: error\n"))
+	if err != nil {
+		t.Fatalf("ParseRuleset: %v", err)
+	}
+	for _, rule := range rs.Effective() {
+		want := lint.SeverityOff
+		if rule.ID == "operation-tags" {
+			want = lint.SeverityError
+		}
+		if rule.Severity != want {
+			t.Errorf("%s runs at %q, want %q", rule.ID, rule.Severity, want)
+		}
+	}
+}
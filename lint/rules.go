@@ -0,0 +1,302 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	oas3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+// Rule is a built-in check.
+type Rule struct {
+	ID          string   `json:"id"`
+	Description string   `json:"description"`
+	Severity    Severity `json:"severity"`          // default severity
+	Options     []string `json:"options,omitempty"` // options the rule accepts in a Ruleset
+	check       func(c *checker)
+}
+
+// camelCasePattern matches camelCase identifiers: a lowercase letter, then letters and digits.
+var camelCasePattern = regexp.MustCompile(`^[a-z][a-zA-Z0-9]*$`)
+
+// Rules returns the built-in rules, in the order they run.
+func Rules() []Rule {
+	return []Rule{
+		{
+			ID:          "info-contact",
+			Description: "info.contact says who owns the API",
+			Severity:    SeverityWarning,
+			check:       checkInfoContact,
+		},
+		{
+			ID:          "operation-operationId",
+			Description: "Every operation has an operationId",
+			Severity:    SeverityWarning,
+			check:       checkOperationID,
+		},
+		{
+			ID:          "operation-operationId-camel-case",
+			Description: "operationIds are camelCase, e.g. listPets",
+			Severity:    SeverityWarning,
+			check:       checkOperationIDCase,
+		},
+		{
+			ID:          "operation-tags",
+			Description: "Every operation has at least one tag",
+			Severity:    SeverityWarning,
+			check:       checkOperationTags,
+		},
+		{
+			ID:          "no-inline-schemas",
+			Description: "Bodies and parameters $ref named object schemas instead of defining them inline",
+			Severity:    SeverityWarning,
+			check:       checkInlineSchemas,
+		},
+		{
+			ID:          "error-response-problem",
+			Description: "Error responses (4xx, 5xx, default) $ref the Problem schema (option: schema)",
+			Severity:    SeverityWarning,
+			Options:     []string{"schema"},
+			check:       checkErrorResponses,
+		},
+	}
+}
+
+// checker runs one rule over a document and collects its findings.
+type checker struct {
+	doc     *oas3.T
+	options map[string]any
+	found   []Finding
+}
+
+// report records a finding at pointer, a JSON pointer into the OpenAPI 3 document.
+func (c *checker) report(pointer, operation, format string, args ...any) {
+	c.found = append(c.found, Finding{Pointer: pointer, Operation: operation, Message: fmt.Sprintf(format, args...)})
+}
+
+// option returns a string option of the rule, or fallback if it is not set.
+func (c *checker) option(name, fallback string) string {
+	if value, ok := c.options[name].(string); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+// operation is an operation of the document with its location.
+type operation struct {
+	name    string // e.g. "GET /pets"
+	pointer string
+	op      *oas3.Operation
+}
+
+// operations lists the operations of the document by path, then method.
+func (c *checker) operations() []operation {
+	if c.doc.Paths == nil {
+		return nil
+	}
+	paths := c.doc.Paths.Map()
+	keys := make([]string, 0, len(paths))
+	for path := range paths {
+		keys = append(keys, path)
+	}
+	sort.Strings(keys)
+
+	var ops []operation
+	for _, path := range keys {
+		item := paths[path]
+		if item == nil {
+			continue
+		}
+		byMethod := item.Operations()
+		methods := make([]string, 0, len(byMethod))
+		for method := range byMethod {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		for _, method := range methods {
+			ops = append(ops, operation{
+				name:    method + " " + path,
+				pointer: "/paths/" + escapePointer(path) + "/" + strings.ToLower(method),
+				op:      byMethod[method],
+			})
+		}
+	}
+	return ops
+}
+
+func checkInfoContact(c *checker) {
+	if c.doc.Info == nil || c.doc.Info.Contact == nil {
+		c.report("/info", "", "info.contact is missing: say who owns the API")
+	}
+}
+
+func checkOperationID(c *checker) {
+	for _, op := range c.operations() {
+		if op.op.OperationID == "" {
+			c.report(op.pointer, op.name, "%s has no operationId", op.name)
+		}
+	}
+}
+
+func checkOperationIDCase(c *checker) {
+	for _, op := range c.operations() {
+		if id := op.op.OperationID; id != "" && !camelCasePattern.MatchString(id) {
+			c.report(op.pointer+"/operationId", op.name, "operationId %q is not camelCase", id)
+		}
+	}
+}
+
+func checkOperationTags(c *checker) {
+	for _, op := range c.operations() {
+		if len(op.op.Tags) == 0 {
+			c.report(op.pointer, op.name, "%s has no tags", op.name)
+		}
+	}
+}
+
+func checkInlineSchemas(c *checker) {
+	for _, op := range c.operations() {
+		for i, param := range op.op.Parameters {
+			if param != nil && param.Ref == "" && param.Value != nil {
+				c.inlineSchema(op.name, fmt.Sprintf("%s/parameters/%d/schema", op.pointer, i),
+					fmt.Sprintf("parameter %q of %s", param.Value.Name, op.name), param.Value.Schema)
+			}
+		}
+		if body := op.op.RequestBody; body != nil && body.Ref == "" && body.Value != nil {
+			c.inlineContent(op.name, op.pointer+"/requestBody", "request body of "+op.name, body.Value.Content)
+		}
+		if op.op.Responses == nil {
+			continue
+		}
+		for _, code := range sortedKeys(op.op.Responses.Map()) {
+			resp := op.op.Responses.Value(code)
+			if resp == nil || resp.Ref != "" || resp.Value == nil {
+				continue // shared responses are checked in components
+			}
+			c.inlineContent(op.name, op.pointer+"/responses/"+escapePointer(code),
+				code+" response of "+op.name, resp.Value.Content)
+		}
+	}
+
+	if c.doc.Components == nil {
+		return
+	}
+	for _, name := range sortedKeys(c.doc.Components.RequestBodies) {
+		if body := c.doc.Components.RequestBodies[name]; body != nil && body.Ref == "" && body.Value != nil {
+			c.inlineContent("", "/components/requestBodies/"+escapePointer(name),
+				fmt.Sprintf("request body %q", name), body.Value.Content)
+		}
+	}
+	for _, name := range sortedKeys(c.doc.Components.Responses) {
+		if resp := c.doc.Components.Responses[name]; resp != nil && resp.Ref == "" && resp.Value != nil {
+			c.inlineContent("", "/components/responses/"+escapePointer(name),
+				fmt.Sprintf("response %q", name), resp.Value.Content)
+		}
+	}
+}
+
+// inlineContent checks the schema of every media type of a body.
+func (c *checker) inlineContent(operation, pointer, what string, content oas3.Content) {
+	for _, mediaType := range sortedKeys(content) {
+		if media := content[mediaType]; media != nil {
+			c.inlineSchema(operation, pointer+"/content/"+escapePointer(mediaType)+"/schema", what, media.Schema)
+		}
+	}
+}
+
+// inlineSchema reports a schema defined in place that describes an object (with properties or
+// inline subschemas), looking through arrays and maps for the schema of their elements.
+func (c *checker) inlineSchema(operation, pointer, what string, ref *oas3.SchemaRef) {
+	for ref != nil && ref.Ref == "" && ref.Value != nil {
+		schema := ref.Value
+		switch {
+		case len(schema.Properties) > 0 ||
+			hasInline(schema.AllOf) || hasInline(schema.OneOf) || hasInline(schema.AnyOf):
+			c.report(pointer, operation,
+				"the %s has an inline schema: define it in components/schemas and $ref it", what)
+			return
+		case schema.Items != nil:
+			ref, pointer = schema.Items, pointer+"/items"
+		case schema.AdditionalProperties.Schema != nil:
+			ref, pointer = schema.AdditionalProperties.Schema, pointer+"/additionalProperties"
+		default:
+			return
+		}
+	}
+}
+
+// hasInline reports whether a composition has a member defined in place; composing named
+// schemas is fine.
+func hasInline(members oas3.SchemaRefs) bool {
+	return slices.ContainsFunc(members, func(member *oas3.SchemaRef) bool { return member.Ref == "" })
+}
+
+func checkErrorResponses(c *checker) {
+	name := c.option("schema", "Problem")
+	for _, op := range c.operations() {
+		if op.op.Responses == nil {
+			continue
+		}
+		for _, code := range sortedKeys(op.op.Responses.Map()) {
+			if !isErrorStatus(code) {
+				continue
+			}
+			resp := op.op.Responses.Value(code)
+			if resp == nil || resp.Value == nil {
+				continue
+			}
+			pointer := op.pointer + "/responses/" + escapePointer(code)
+			switch {
+			case len(resp.Value.Content) == 0:
+				c.report(pointer, op.name, "the %s response of %s has no body; return a %s", code, op.name, name)
+			case !usesSchema(resp.Value.Content, name):
+				c.report(pointer+"/content", op.name, "the %s response of %s does not use the %s schema",
+					code, op.name, name)
+			}
+		}
+	}
+}
+
+// isErrorStatus reports whether a response key covers errors: 4xx, 5xx, their ranges or default.
+func isErrorStatus(code string) bool {
+	return code == "default" || strings.HasPrefix(code, "4") || strings.HasPrefix(code, "5")
+}
+
+// usesSchema reports whether a media type of content $refs the named component schema,
+// directly or as a member of allOf.
+func usesSchema(content oas3.Content, name string) bool {
+	for _, media := range content {
+		if media == nil || media.Schema == nil {
+			continue
+		}
+		if refersTo(media.Schema, name) {
+			return true
+		}
+		if media.Schema.Value == nil {
+			continue
+		}
+		if slices.ContainsFunc(media.Schema.Value.AllOf, func(member *oas3.SchemaRef) bool {
+			return refersTo(member, name)
+		}) {
+			return true
+		}
+	}
+	return false
+}
+
+// refersTo reports whether ref points at the component schema name, in this or another file.
+func refersTo(ref *oas3.SchemaRef, name string) bool {
+	return ref != nil && strings.HasSuffix(ref.Ref, "/"+name)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
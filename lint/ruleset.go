@@ -0,0 +1,145 @@
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RulesetFile is the ruleset looked for in the root directory when none is given.
+const RulesetFile = ".webswags-lint.yaml"
+
+// Values of Ruleset.Extends.
+const (
+	// ExtendsRecommended starts from every rule at its default severity.
+	ExtendsRecommended = "recommended"
+	// ExtendsNone starts with every rule off, so only the listed ones run.
+	ExtendsNone = "none"
+)
+
+// Ruleset configures which rules run, how severe their findings are and their options:
+//
+//	extends: recommended        # or none: only the rules below run
+//	rules:
+//	  info-contact: off
+//	  operation-tags: error
+//	  error-response-problem:
+//	    severity: error
+//	    schema: ApiProblem      # options follow the severity
+type Ruleset struct {
+	Extends string                `json:"extends,omitempty" yaml:"extends,omitempty"`
+	Rules   map[string]RuleConfig `json:"rules,omitempty"   yaml:"rules,omitempty"`
+}
+
+// RuleConfig is the configuration of one rule: a severity, or a mapping with a severity
+// (optional, defaulting to the rule's) and the rule's options.
+type RuleConfig struct {
+	Severity Severity       `json:"severity,omitempty" yaml:"severity,omitempty"`
+	Options  map[string]any `json:"options,omitempty"  yaml:",inline"`
+}
+
+// UnmarshalYAML accepts both a bare severity and a mapping.
+func (rc *RuleConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&rc.Severity)
+	}
+	type plain RuleConfig
+	return node.Decode((*plain)(rc))
+}
+
+// LoadRuleset reads and checks a YAML ruleset file.
+func LoadRuleset(path string) (*Ruleset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ruleset: %w", err)
+	}
+	rs, err := ParseRuleset(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rs, nil
+}
+
+// ParseRuleset parses and checks a YAML ruleset: unknown rules, options and severities are
+// errors, so typos do not silently disable a check.
+func ParseRuleset(data []byte) (*Ruleset, error) {
+	var rs Ruleset
+	if err := yaml.Unmarshal(data, &rs); err != nil {
+		return nil, fmt.Errorf("invalid ruleset: %w", err)
+	}
+	if rs.Extends != "" && rs.Extends != ExtendsRecommended && rs.Extends != ExtendsNone {
+		return nil, fmt.Errorf("unknown extends %q (want %s or %s)", rs.Extends, ExtendsRecommended, ExtendsNone)
+	}
+
+	rules := make(map[string]Rule)
+	for _, rule := range Rules() {
+		rules[rule.ID] = rule
+	}
+	var problems []error
+	for _, id := range slices.Sorted(maps.Keys(rs.Rules)) {
+		rule, ok := rules[id]
+		if !ok {
+			problems = append(problems, fmt.Errorf("unknown rule %q", id))
+			continue
+		}
+		config := rs.Rules[id]
+		if config.Severity != "" {
+			if _, err := ParseSeverity(string(config.Severity)); err != nil {
+				problems = append(problems, fmt.Errorf("rule %s: %w", id, err))
+			}
+		}
+		for name := range config.Options {
+			if !slices.Contains(rule.Options, name) {
+				problems = append(problems, fmt.Errorf("rule %s: unknown option %q", id, name))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return nil, errors.Join(problems...)
+	}
+	return &rs, nil
+}
+
+// Effective lists every rule with the severity it runs at in rs, for display.
+func (rs *Ruleset) Effective() []EffectiveRule {
+	rules := Rules()
+	effective := make([]EffectiveRule, 0, len(rules))
+	for _, rule := range rules {
+		severity, _ := rs.config(rule)
+		effective = append(effective, EffectiveRule{ID: rule.ID, Description: rule.Description, Severity: severity})
+	}
+	return effective
+}
+
+// EffectiveRule is a rule as a Ruleset configures it.
+type EffectiveRule struct {
+	ID          string   `json:"id"`
+	Description string   `json:"description"`
+	Severity    Severity `json:"severity"`
+}
+
+// config returns the severity and options rule runs with.
+func (rs *Ruleset) config(rule Rule) (Severity, map[string]any) {
+	severity := rule.Severity
+	if rs == nil {
+		return severity, nil
+	}
+	if rs.Extends == ExtendsNone {
+		severity = SeverityOff
+	}
+	config, ok := rs.Rules[rule.ID]
+	if !ok {
+		return severity, nil
+	}
+	switch {
+	case config.Severity != "":
+		severity = config.Severity
+	case severity == SeverityOff:
+		severity = rule.Severity // listed with options only: enabled at its default
+	}
+	return severity, config.Options
+}
@@ -1,29 +1,37 @@
 package main
 
 import (
+	"context"
 	"embed"
 	"encoding/json"
-	"errors"
 	"flag"
 	"fmt"
 	"html/template"
-	"io"
 	"log/slog"
 	"net/http"
 	"os"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
-	"golang.org/x/text/cases"
-	"golang.org/x/text/language"
 
+	"github.com/Hossein-Roshandel/webswags/contract"
 	"github.com/Hossein-Roshandel/webswags/discovery"
+	"github.com/Hossein-Roshandel/webswags/egress"
+	"github.com/Hossein-Roshandel/webswags/lint"
+	"github.com/Hossein-Roshandel/webswags/mock"
+	"github.com/Hossein-Roshandel/webswags/quality"
 )
 
 //go:embed templates/*
 var templatesFS embed.FS
 
+// pages holds every template, parsed once at startup. Pages are executed by file name and
+// include the shared styles and scripts by theirs (e.g. {{template "theme.css"}}).
+var pages = template.Must(template.ParseFS(templatesFS, "templates/*")) //nolint:gochecknoglobals // Parsed once
+
 const (
 	port             = "8085"
 	swaggerUIVersion = "5.9.0"
@@ -38,27 +46,190 @@ const (
 	// Proxy configuration.
 	proxyTimeout    = 30        // seconds
 	proxyBufferSize = 32 * 1024 // 32KB buffer for streaming
+	proxyCheckLimit = 4 << 20   // 4MiB; larger response bodies are not checked against the spec
+	proxyRedirects  = 10        // redirects followed, each of which must be allowed too
+
+	// Proxy request validation (-proxy-validate): off, warn (forward and report the violations
+	// in headerValidation) or block (answer 400 with the violations instead of forwarding).
+	validateOff   = "off"
+	validateWarn  = "warn"
+	validateBlock = "block"
+
+	// headerSpecRef names the spec a proxied request is made from (see resolveSpecRef); Swagger
+	// UI sends it and the proxy drops it. headerValidation and headerResponseValidation report
+	// the outcome of checking the request and the response against the spec.
+	headerSpecRef            = "X-WebSwags-Spec"
+	headerValidation         = "X-WebSwags-Validation"
+	headerResponseValidation = "X-WebSwags-Response-Validation"
+
+	// headerService names the service a proxied request was made for.
+	headerService = "X-WebSwags-Service"
 
 	// UI colors.
 	colorJSON = "#f39c12" // Orange for JSON
 	colorYAML = "#27ae60" // Green for YAML
 )
 
-var rootDir string //nolint:gochecknoglobals // Global variable to store root directory
+var (
+	rootDir       string                    //nolint:gochecknoglobals // Global variable to store root directory
+	watch         bool                      //nolint:gochecknoglobals // Whether to hot-reload specs on file changes
+	discoverOpt   discovery.DiscoverOptions //nolint:gochecknoglobals // Tuning for the discovery walk
+	sourceSpecs   []string                  //nolint:gochecknoglobals // Additional spec sources (-source)
+	lintRules     string                    //nolint:gochecknoglobals // Lint ruleset file (-lint-ruleset)
+	proxyValidate string                    //nolint:gochecknoglobals // Proxy request validation mode (-proxy-validate)
+	proxyAllow    []string                  //nolint:gochecknoglobals // Proxy allowlist rules (-proxy-allow)
+)
 
-// IndexData represents the data structure for the index page template.
-type IndexData struct {
-	TotalServices int
-	Services      []discovery.SwaggerSpec
-	Empty         bool
+// DiagnosticsReport is the /api/specs/{service}/diagnostics response.
+type DiagnosticsReport struct {
+	Service     string                 `json:"service"`
+	Slug        string                 `json:"slug"`
+	Version     string                 `json:"version"`
+	Path        string                 `json:"path"`
+	Health      string                 `json:"health"`
+	Errors      int                    `json:"errors"`
+	Warnings    int                    `json:"warnings"`
+	Diagnostics []discovery.Diagnostic `json:"diagnostics"`
 }
 
-type ServiceData struct {
-	ServiceTitle     string
-	SwaggerUIVersion string
-	Format           string
-	FormatColor      string
-	SpecURL          string
+// LintReport is the /api/specs/{service}/lint response.
+type LintReport struct {
+	Service string `json:"service"`
+	Slug    string `json:"slug"`
+	Version string `json:"version"`
+	Path    string `json:"path"`
+	lint.Result
+}
+
+// newLintReport describes the lint result of spec.
+func newLintReport(spec discovery.SwaggerSpec, result lint.Result) LintReport {
+	return LintReport{
+		Service: spec.Service,
+		Slug:    spec.Slug,
+		Version: spec.VersionKey,
+		Path:    spec.Path,
+		Result:  result,
+	}
+}
+
+// LintData is the data for the lint page of a spec.
+type LintData struct {
+	LintReport
+	ServiceURL string
+	APIURL     string
+	Loaded     bool // false for specs that failed to load, which cannot be linted
+	Rules      []lint.EffectiveRule
+}
+
+// QualityReport is the /api/specs/{service}/quality response, and one row of /api/quality.
+type QualityReport struct {
+	Service string `json:"service"`
+	Slug    string `json:"slug"`
+	Version string `json:"version"`
+	Path    string `json:"path"`
+	PageURL string `json:"pageUrl"` // drill-down page
+	quality.Scorecard
+}
+
+// newQualityReport scores spec, a version of svc.
+func newQualityReport(svc discovery.Service, spec discovery.SwaggerSpec, ruleset *lint.Ruleset) QualityReport {
+	return QualityReport{
+		Service:   spec.Service,
+		Slug:      spec.Slug,
+		Version:   spec.VersionKey,
+		Path:      spec.Path,
+		PageURL:   servicePageURL(svc, spec) + "/quality",
+		Scorecard: quality.Score(spec, ruleset.Lint(spec)),
+	}
+}
+
+// QualityData is the data for the quality page of a spec.
+type QualityData struct {
+	QualityReport
+	ServiceURL string
+	LintURL    string
+	APIURL     string
+	Loaded     bool // false for specs that failed to load, which score 0
+}
+
+// QualityOverviewData is the data for the quality overview of every service.
+type QualityOverviewData struct {
+	Reports []QualityReport // each service's default version, lowest score first
+	Average int
+}
+
+// catalogQuality keeps the quality report, lint result included, of each service's default
+// version. It is recomputed when the catalog changes (see discovery.Registry.OnRebuild), so
+// pages listing every service do not lint and score them all on each load.
+type catalogQuality struct {
+	ruleset *lint.Ruleset
+
+	mu       sync.RWMutex
+	overview QualityOverviewData
+	bySlug   map[string]QualityReport // keyed by spec slug
+}
+
+func newCatalogQuality(ruleset *lint.Ruleset) *catalogQuality {
+	return &catalogQuality{ruleset: ruleset, bySlug: make(map[string]QualityReport)}
+}
+
+// rebuild scores the default version of every service, lowest score first.
+func (c *catalogQuality) rebuild(services []discovery.Service) {
+	overview := QualityOverviewData{Reports: make([]QualityReport, 0, len(services))}
+	bySlug := make(map[string]QualityReport, len(services))
+	total := 0
+	for _, svc := range services {
+		report := newQualityReport(svc, svc.Latest(), c.ruleset)
+		overview.Reports = append(overview.Reports, report)
+		bySlug[report.Slug] = report
+		total += report.Score
+	}
+	sort.SliceStable(overview.Reports, func(i, j int) bool {
+		return overview.Reports[i].Score < overview.Reports[j].Score
+	})
+	if len(overview.Reports) > 0 {
+		overview.Average = total / len(overview.Reports)
+	}
+
+	c.mu.Lock()
+	c.overview, c.bySlug = overview, bySlug
+	c.mu.Unlock()
+}
+
+// Overview returns the scores of every service. The reports are shared: do not modify them.
+func (c *catalogQuality) Overview() QualityOverviewData {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.overview
+}
+
+// Report returns the quality report of the spec known by slug, if it is the default version
+// of its service.
+func (c *catalogQuality) Report(slug string) (QualityReport, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	report, ok := c.bySlug[slug]
+	return report, ok
+}
+
+// WebhooksReport is the /api/specs/{service}/webhooks response.
+type WebhooksReport struct {
+	Service  string              `json:"service"`
+	Slug     string              `json:"slug"`
+	Version  string              `json:"version"`
+	Webhooks []discovery.Webhook `json:"webhooks"`
+}
+
+// stringList is a flag.Value collecting every occurrence of a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
 }
 
 func loggingMiddleware(next http.Handler) http.Handler {
@@ -71,41 +242,187 @@ func loggingMiddleware(next http.Handler) http.Handler {
 }
 
 func main() {
+	// Subcommands
+	if len(os.Args) > 1 && os.Args[1] == "convert" {
+		os.Exit(runConvert(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		os.Exit(runDiff(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "lint" {
+		os.Exit(runLint(os.Args[2:]))
+	}
+
 	// Parse command line arguments
 	flag.StringVar(&rootDir, "root", "..", "Root directory to search for swagger specifications")
+	flag.BoolVar(&watch, "watch", true, "Watch the root directory and hot-reload specs when files change")
+	flag.IntVar(&discoverOpt.Workers, "workers", 0, "Number of files parsed concurrently (0 = number of CPUs)")
+	flag.Int64Var(&discoverOpt.MaxFileSize, "max-file-size", discovery.DefaultMaxFileSize,
+		"Skip files larger than this many bytes (negative = no limit)")
+	flag.IntVar(&discoverOpt.MaxDepth, "max-depth", 0, "Maximum directory depth below root to search (0 = unlimited)")
+	flag.BoolVar(&discoverOpt.FollowSymlinks, "follow-symlinks", false, "Follow symlinked files and directories")
+	flag.Var((*stringList)(&discoverOpt.Include), "include",
+		"Only discover files matching this glob (repeatable, e.g. -include 'apis/**/*.yaml')")
+	flag.Var((*stringList)(&discoverOpt.Exclude), "exclude",
+		"Skip files and directories matching this .gitignore-style pattern (repeatable, e.g. -exclude node_modules/)")
+	flag.BoolVar(&discoverOpt.DisableIgnoreFiles, "no-ignore-files", false,
+		"Do not honour .gitignore and .webswagsignore files")
+	flag.Var((*stringList)(&sourceSpecs), "source",
+		"Also discover specs from this source (repeatable): a directory, a .zip/.tar.gz/.tar archive, "+
+			"git:REPO@REF or an http(s) URL")
+	flag.StringVar(&lintRules, "lint-ruleset", "",
+		"Lint ruleset file (default: "+lint.RulesetFile+" in the root directory, if any)")
+	flag.StringVar(&proxyValidate, "proxy-validate", validateOff,
+		"Validate requests proxied from Swagger UI against their operation: off, warn (report violations "+
+			"in the "+headerValidation+" response header) or block (answer 400 without forwarding)")
+	flag.Var((*stringList)(&proxyAllow), "proxy-allow",
+		"Also let the proxy fetch this target (repeatable): a URL prefix, a host[:port] such as *.example.com, "+
+			"or an IP address or network such as 10.0.0.0/8. Private addresses are only reached through "+
+			"networks and rules naming them, such as 10.1.2.3:8080 or http://localhost:3000")
 	flag.Parse()
 
+	switch proxyValidate {
+	case validateOff, validateWarn, validateBlock:
+	default:
+		slog.Error("Invalid -proxy-validate mode, want off, warn or block", "mode", proxyValidate)
+		os.Exit(1)
+	}
+	rules := make([]egress.Rule, 0, len(proxyAllow))
+	for _, raw := range proxyAllow {
+		rule, err := egress.ParseRule(raw)
+		if err != nil {
+			slog.Error("Invalid -proxy-allow rule", "error", err)
+			os.Exit(1)
+		}
+		rules = append(rules, rule)
+	}
+
+	ruleset, err := loadRuleset(lintRules, rootDir)
+	if err != nil {
+		slog.Error("Invalid lint ruleset", "error", err)
+		os.Exit(1)
+	}
+
+	sources := make([]discovery.Source, 0, len(sourceSpecs))
+	for _, spec := range sourceSpecs {
+		source, err := discovery.ParseSource(spec)
+		if err != nil {
+			slog.Error("Invalid source", "source", spec, "error", err)
+			os.Exit(1)
+		}
+		sources = append(sources, source)
+	}
+
 	slog.Info("Starting webswags server", "address", "http://localhost:"+port)
-	slog.Info("Searching for specifications", "root_dir", rootDir)
+	slog.Info("Searching for specifications", "root_dir", rootDir, "sources", len(sources))
 
 	// Discover all swagger specs
-	specs, err := discovery.DiscoverSwaggerSpecs(rootDir)
-	if err != nil {
+	registry := discovery.NewRegistry(rootDir, discoverOpt, sources...)
+	scores := newCatalogQuality(ruleset)
+	registry.OnRebuild(scores.rebuild)
+	servers := &catalogServers{}
+	registry.OnRebuild(servers.rebuild)
+	if err := registry.Load(context.Background()); err != nil {
 		slog.Error("Failed to discover swagger specs", "error", err)
 		os.Exit(1)
 	}
 
+	specs := registry.Specs()
 	slog.Info("Discovered swagger specifications", "count", len(specs))
 	for _, spec := range specs {
-		slog.Info("Service found", "name", spec.Name, "service", spec.Service)
+		slog.Info("Service found", "name", spec.Name, "service", spec.Service, "slug", spec.Slug)
+	}
+
+	// Keep the registry in sync with the working tree.
+	if watch {
+		go func() {
+			if err := registry.Watch(context.Background()); err != nil {
+				slog.Error("Spec watcher stopped", "error", err)
+			}
+		}()
 	}
 
 	// Setup routes.
 	r := mux.NewRouter()
 
 	// API routes.
-	r.HandleFunc("/api/specs", handleSpecs(specs)).Methods("GET")
-	r.HandleFunc("/api/specs/{service}/swagger.yaml", handleSwaggerFile(specs)).Methods("GET")
-	r.HandleFunc("/api/specs/{service}/swagger.json", handleSwaggerFile(specs)).Methods("GET")
+	r.HandleFunc("/api/specs", handleSpecs(registry)).Methods("GET")
+	r.HandleFunc("/api/operations", handleOperations(registry)).Methods("GET")
+	r.HandleFunc("/api/search", handleSearch(registry)).Methods("GET")
+
+	// Past revisions of a spec are served like the current one, with @{rev} (a commit, tag or
+	// branch) after the service or version. They are registered first as {service} also matches "@".
+	for _, base := range []string{
+		"/api/specs/{service:[^/@]+}@{rev}",
+		"/api/specs/{service}/v/{version:[^/@]+}@{rev}",
+	} {
+		r.HandleFunc(base+"/swagger.yaml", handleSwaggerFile(registry)).Methods("GET")
+		r.HandleFunc(base+"/swagger.json", handleSwaggerFile(registry)).Methods("GET")
+		r.HandleFunc(base+"/swagger", handleSwaggerFile(registry)).Methods("GET")
+		r.HandleFunc(base+"/openapi3.yaml", handleOpenAPI3(registry)).Methods("GET")
+		r.HandleFunc(base+"/openapi3.json", handleOpenAPI3(registry)).Methods("GET")
+		r.HandleFunc(base+"/diagnostics", handleDiagnostics(registry)).Methods("GET")
+		r.HandleFunc(base+"/webhooks", handleWebhooks(registry)).Methods("GET")
+		r.HandleFunc(base+"/lint", handleLint(registry, ruleset)).Methods("GET")
+		r.HandleFunc(base+"/quality", handleQuality(registry, ruleset)).Methods("GET")
+		r.HandleFunc(base+"/files/{file:.+}", handleSpecFiles(registry)).Methods("GET")
+	}
+
+	r.HandleFunc("/api/specs/{service}/swagger.yaml", handleSwaggerFile(registry)).Methods("GET")
+	r.HandleFunc("/api/specs/{service}/swagger.json", handleSwaggerFile(registry)).Methods("GET")
+	r.HandleFunc("/api/specs/{service}/swagger", handleSwaggerFile(registry)).Methods("GET")
+	r.HandleFunc("/api/specs/{service}/versions", handleVersions(registry)).Methods("GET")
+	r.HandleFunc("/api/specs/{service}/history", handleHistory(registry)).Methods("GET")
+	r.HandleFunc("/api/specs/{service}/v/{version}/history", handleHistory(registry)).Methods("GET")
+	r.HandleFunc("/api/specs/{service}/diagnostics", handleDiagnostics(registry)).Methods("GET")
+	r.HandleFunc("/api/specs/{service}/v/{version}/diagnostics", handleDiagnostics(registry)).Methods("GET")
+	r.HandleFunc("/api/specs/{service}/webhooks", handleWebhooks(registry)).Methods("GET")
+	r.HandleFunc("/api/specs/{service}/v/{version}/webhooks", handleWebhooks(registry)).Methods("GET")
+	r.HandleFunc("/api/specs/{service}/lint", handleLint(registry, ruleset)).Methods("GET")
+	r.HandleFunc("/api/specs/{service}/v/{version}/lint", handleLint(registry, ruleset)).Methods("GET")
+	r.HandleFunc("/api/specs/{service}/quality", handleQuality(registry, ruleset)).Methods("GET")
+	r.HandleFunc("/api/specs/{service}/v/{version}/quality", handleQuality(registry, ruleset)).Methods("GET")
+	r.HandleFunc("/api/specs/{service}/v/{version}/swagger.yaml", handleSwaggerFile(registry)).Methods("GET")
+	r.HandleFunc("/api/specs/{service}/v/{version}/swagger.json", handleSwaggerFile(registry)).Methods("GET")
+	r.HandleFunc("/api/specs/{service}/v/{version}/swagger", handleSwaggerFile(registry)).Methods("GET")
+	r.HandleFunc("/api/specs/{service}/openapi3.yaml", handleOpenAPI3(registry)).Methods("GET")
+	r.HandleFunc("/api/specs/{service}/openapi3.json", handleOpenAPI3(registry)).Methods("GET")
+	r.HandleFunc("/api/specs/{service}/v/{version}/openapi3.yaml", handleOpenAPI3(registry)).Methods("GET")
+	r.HandleFunc("/api/specs/{service}/v/{version}/openapi3.json", handleOpenAPI3(registry)).Methods("GET")
+	r.HandleFunc("/api/specs/{service}/files/{file:.+}", handleSpecFiles(registry)).Methods("GET")
+	r.HandleFunc("/api/specs/{service}/v/{version}/files/{file:.+}", handleSpecFiles(registry)).Methods("GET")
+
+	r.HandleFunc("/api/discovery/report", handleDiscoveryReport(registry)).Methods("GET")
+	r.HandleFunc("/api/diff", handleDiff(registry)).Methods("GET")
+	r.HandleFunc("/api/quality", handleQualityOverview(scores)).Methods("GET")
 
 	// CORS proxy route - allows Swagger UI to make requests through our server
-	r.HandleFunc("/proxy", handleProxy()).Methods(
+	drift := contract.NewDriftLog()
+	r.HandleFunc("/api/drift", handleDrift(drift)).Methods("GET")
+	r.HandleFunc("/api/drift", handleDriftReset(drift)).Methods("DELETE")
+	r.HandleFunc("/proxy", handleProxy(registry, proxyValidate, drift, egress.NewPolicy(rules), servers)).Methods(
 		"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS", "HEAD", "CONNECT", "TRACE",
 	)
 
+	// Mock servers: /mock/{service}[/v/{version}]/{path of the spec}, any method.
+	mocks := mock.NewMocks()
+	r.PathPrefix("/mock/{service}").Handler(handleMock(registry, mocks))
+	r.HandleFunc("/api/specs/{service}/mock", handleMockReset(registry, mocks)).Methods("DELETE")
+	r.HandleFunc("/api/specs/{service}/v/{version}/mock", handleMockReset(registry, mocks)).Methods("DELETE")
+
 	// Main routes
-	r.HandleFunc("/", handleIndex(specs)).Methods("GET")
-	r.HandleFunc("/service/{service}", handleServiceSwagger(specs)).Methods("GET")
+	r.HandleFunc("/", handleIndex(registry, scores)).Methods("GET")
+	r.HandleFunc("/discovery", handleDiscoveryPage(registry)).Methods("GET")
+	r.HandleFunc("/diff", handleDiffPage(registry)).Methods("GET")
+	r.HandleFunc("/quality", handleQualityOverviewPage(scores)).Methods("GET")
+	r.HandleFunc("/service/{service:[^/@]+}@{rev:.+}", handleServiceSwagger(registry)).Methods("GET")
+	r.HandleFunc("/service/{service}/v/{version:[^/@]+}@{rev:.+}", handleServiceSwagger(registry)).Methods("GET")
+	r.HandleFunc("/service/{service}", handleServiceSwagger(registry)).Methods("GET")
+	r.HandleFunc("/service/{service}/v/{version}", handleServiceSwagger(registry)).Methods("GET")
+	r.HandleFunc("/service/{service}/lint", handleLintPage(registry, ruleset)).Methods("GET")
+	r.HandleFunc("/service/{service}/v/{version}/lint", handleLintPage(registry, ruleset)).Methods("GET")
+	r.HandleFunc("/service/{service}/quality", handleQualityPage(registry, ruleset)).Methods("GET")
+	r.HandleFunc("/service/{service}/v/{version}/quality", handleQualityPage(registry, ruleset)).Methods("GET")
 
 	r.Use(loggingMiddleware)
 
@@ -125,275 +442,249 @@ func main() {
 	}
 }
 
-// handleIndex serves the main page listing all services.
-func handleIndex(specs []discovery.SwaggerSpec) http.HandlerFunc {
-	return func(w http.ResponseWriter, _ *http.Request) {
-		w.Header().Set("Content-Type", "text/html")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
+// renderPage writes the page template name, executed with data, as HTML.
+func renderPage(w http.ResponseWriter, name string, data any) {
+	w.Header().Set("Content-Type", "text/html")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
 
-		tmpl, err := template.ParseFS(
-			templatesFS,
-			"templates/index.html",
-			"templates/index-styles.css",
-			"templates/theme.css",
-			"templates/theme.js",
-			"templates/SwaggerDark.css",
-		)
-		if err != nil {
-			http.Error(w, "Error loading template", http.StatusInternalServerError)
+	if err := pages.ExecuteTemplate(w, name, data); err != nil {
+		slog.Error("Failed to render template", "template", name, "error", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+	}
+}
+
+// handleDiagnostics returns the load and validation problems found in a spec.
+func handleDiagnostics(registry *discovery.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, spec, ok := lookupSpec(registry, w, r)
+		if !ok {
 			return
 		}
 
-		data := IndexData{
-			TotalServices: len(specs),
-			Services:      specs,
-			Empty:         len(specs) == 0,
+		report := DiagnosticsReport{
+			Service:     spec.Service,
+			Slug:        spec.Slug,
+			Version:     spec.VersionKey,
+			Path:        spec.Path,
+			Health:      spec.Health(),
+			Errors:      spec.ErrorCount(),
+			Warnings:    spec.WarningCount(),
+			Diagnostics: spec.Diagnostics,
+		}
+		if report.Diagnostics == nil {
+			report.Diagnostics = []discovery.Diagnostic{}
 		}
 
-		if execErr := tmpl.Execute(w, data); execErr != nil {
-			slog.Error("Failed to render index template", "error", execErr)
-			http.Error(w, "Error rendering template", http.StatusInternalServerError)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			slog.Error("Failed to encode diagnostics", "service", spec.Slug, "error", err)
+			http.Error(w, "Failed to encode diagnostics", http.StatusInternalServerError)
 		}
 	}
 }
 
-// handleServiceSwagger serves the Swagger UI for a specific service.
-func handleServiceSwagger(specs []discovery.SwaggerSpec) http.HandlerFunc {
+// handleLint lints a spec. With ?format=sarif, the findings are returned as a SARIF log.
+func handleLint(registry *discovery.Registry, ruleset *lint.Ruleset) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		vars := mux.Vars(r)
-		service := vars["service"]
-
-		// Find the spec for this service to determine format
-		specFormat := yamlFormat // default
-		for _, spec := range specs {
-			if spec.Service == service {
-				specFormat = spec.Format
-				break
-			}
+		_, spec, ok := lookupSpec(registry, w, r)
+		if !ok {
+			return
 		}
+		report := newLintReport(spec, ruleset.Lint(spec))
 
-		// Determine the correct URL based on format.
-		specURL := fmt.Sprintf("/api/specs/%s/swagger.%s", service, specFormat)
-
-		w.Header().Set("Content-Type", "text/html")
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-
-		tmpl, err := template.ParseFS(
-			templatesFS,
-			"templates/service.html",
-			"templates/service-styles.css",
-			"templates/service-script.js",
-			"templates/theme.css",
-			"templates/theme.js",
-			"templates/SwaggerDark.css",
-		)
+		var err error
+		if r.URL.Query().Get("format") == "sarif" {
+			w.Header().Set("Content-Type", "application/sarif+json")
+			err = lint.WriteSARIF(w, ruleset, []lint.Target{{Path: spec.Path, Result: report.Result}})
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+			err = json.NewEncoder(w).Encode(report)
+		}
 		if err != nil {
-			http.Error(w, "Error loading template", http.StatusInternalServerError)
-			return
+			slog.Error("Failed to encode lint report", "service", spec.Slug, "error", err)
+			http.Error(w, "Failed to encode lint report", http.StatusInternalServerError)
 		}
+	}
+}
 
-		data := ServiceData{
-			ServiceTitle:     cases.Title(language.English, cases.Compact).String(service),
-			SwaggerUIVersion: swaggerUIVersion,
-			Format:           specFormat,
-			FormatColor:      getFormatColor(specFormat),
-			SpecURL:          specURL,
+// handleLintPage renders the lint findings of a spec and the rules in effect.
+func handleLintPage(registry *discovery.Registry, ruleset *lint.Ruleset) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		svc, spec, ok := lookupSpec(registry, w, r)
+		if !ok {
+			return
 		}
 
-		if execErr := tmpl.Execute(w, data); execErr != nil {
-			slog.Error("Failed to render service template", "service", service, "error", execErr)
-			http.Error(w, "Error rendering template", http.StatusInternalServerError)
+		data := LintData{
+			LintReport: newLintReport(spec, ruleset.Lint(spec)),
+			ServiceURL: servicePageURL(svc, spec),
+			APIURL:     fmt.Sprintf("/api/specs/%s/v/%s/lint", svc.Slug, versionRef(spec)),
+			Loaded:     spec.DocV3 != nil,
+			Rules:      ruleset.Effective(),
 		}
+
+		renderPage(w, "lint.html", data)
 	}
 }
 
-// getFormatColor returns a color for the format badge.
-func getFormatColor(format string) string {
-	if format == jsonFormat {
-		return colorJSON // Orange for JSON.
+// handleQuality returns the quality scorecard of a spec.
+func handleQuality(registry *discovery.Registry, ruleset *lint.Ruleset) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		svc, spec, ok := lookupSpec(registry, w, r)
+		if !ok {
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if err := json.NewEncoder(w).Encode(newQualityReport(svc, spec, ruleset)); err != nil {
+			slog.Error("Failed to encode quality report", "service", spec.Slug, "error", err)
+			http.Error(w, "Failed to encode quality report", http.StatusInternalServerError)
+		}
 	}
-	return colorYAML // Green for YAML.
 }
 
-// handleSpecs returns JSON list of all discovered specs.
-func handleSpecs(specs []discovery.SwaggerSpec) http.HandlerFunc {
+// handleQualityOverview returns the quality scorecards of every service's default version.
+func handleQualityOverview(scores *catalogQuality) http.HandlerFunc {
 	return func(w http.ResponseWriter, _ *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		if err := json.NewEncoder(w).Encode(specs); err != nil {
-			http.Error(w, "Failed to encode specs to JSON", http.StatusInternalServerError)
-			return
+		if err := json.NewEncoder(w).Encode(scores.Overview().Reports); err != nil {
+			slog.Error("Failed to encode quality overview", "error", err)
+			http.Error(w, "Failed to encode quality overview", http.StatusInternalServerError)
 		}
 	}
 }
 
-// handleSwaggerFile serves the raw YAML or JSON file for a specific service.
-func handleSwaggerFile(specs []discovery.SwaggerSpec) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		vars := mux.Vars(r)
-		service := vars["service"]
-
-		// Determine requested format from URL
-		requestedFormat := yamlFormat
-		if strings.HasSuffix(r.URL.Path, ".json") {
-			requestedFormat = jsonFormat
-		}
-
-		// Find the spec for this service
-		var specPath string
-		var contentType string
-		found := false
-
-		for _, spec := range specs {
-			if spec.Service == service && spec.Format == requestedFormat {
-				specPath = spec.Path
-				found = true
-				break
-			}
-		}
+// handleQualityOverviewPage renders the scores of every service, lowest first.
+func handleQualityOverviewPage(scores *catalogQuality) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		renderPage(w, "quality-overview.html", scores.Overview())
+	}
+}
 
-		if !found {
-			http.NotFound(w, r)
+// handleQualityPage renders the quality scorecard of a spec, with the operations behind each check.
+func handleQualityPage(registry *discovery.Registry, ruleset *lint.Ruleset) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		svc, spec, ok := lookupSpec(registry, w, r)
+		if !ok {
 			return
 		}
 
-		if requestedFormat == jsonFormat {
-			contentType = "application/json"
-		} else {
-			contentType = "text/yaml"
+		data := QualityData{
+			QualityReport: newQualityReport(svc, spec, ruleset),
+			ServiceURL:    servicePageURL(svc, spec),
+			LintURL:       servicePageURL(svc, spec) + "/lint",
+			APIURL:        fmt.Sprintf("/api/specs/%s/v/%s/quality", svc.Slug, versionRef(spec)),
+			Loaded:        spec.Quality != nil,
 		}
 
-		// Serve the file
-		w.Header().Set("Content-Type", contentType)
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		http.ServeFile(w, r, specPath)
+		renderPage(w, "quality.html", data)
 	}
 }
 
-// setCORSHeaders sets CORS headers on the response writer.
-func setCORSHeaders(w http.ResponseWriter) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set(
-		"Access-Control-Allow-Methods",
-		"GET, POST, PUT, PATCH, DELETE, OPTIONS, HEAD, CONNECT, TRACE",
-	)
-	w.Header().Set(
-		"Access-Control-Allow-Headers",
-		"Content-Type, Authorization, X-Requested-With, Accept, X-API-Key, X-Custom-Header",
-	)
-}
-
-// handlePreflightRequest handles CORS preflight OPTIONS requests.
-func handlePreflightRequest(w http.ResponseWriter) {
-	setCORSHeaders(w)
-	w.Header().Set("Access-Control-Max-Age", "3600")
-	w.WriteHeader(http.StatusOK)
-}
-
-// copyRequestHeaders copies headers from the original request to the proxy request, excluding Host.
-func copyRequestHeaders(proxyReq *http.Request, originalReq *http.Request) {
-	for key, values := range originalReq.Header {
-		if key != "Host" {
-			for _, value := range values {
-				proxyReq.Header.Add(key, value)
-			}
+// handleWebhooks lists the webhook operations of an OpenAPI 3.1 spec (empty for older specs).
+func handleWebhooks(registry *discovery.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, spec, ok := lookupSpec(registry, w, r)
+		if !ok {
+			return
 		}
-	}
-}
 
-// copyQueryParameters copies query parameters from the original request to the proxy request,
-// excluding the 'url' parameter.
-func copyQueryParameters(proxyReq *http.Request, originalReq *http.Request) {
-	query := originalReq.URL.Query()
-	query.Del("url") // Remove the proxy URL parameter
-	proxyReq.URL.RawQuery = query.Encode()
-}
-
-// copyResponseHeaders copies headers from the proxy response to the response writer.
-func copyResponseHeaders(w http.ResponseWriter, resp *http.Response) {
-	for key, values := range resp.Header {
-		for _, value := range values {
-			w.Header().Add(key, value)
+		report := WebhooksReport{
+			Service:  spec.Service,
+			Slug:     spec.Slug,
+			Version:  spec.VersionKey,
+			Webhooks: spec.WebhookOperations(),
 		}
-	}
-}
 
-// streamResponseBody streams the response body from the proxy response to the response writer.
-func streamResponseBody(w http.ResponseWriter, resp *http.Response) error {
-	buf := make([]byte, proxyBufferSize)
-	for {
-		n, readErr := resp.Body.Read(buf)
-		if n > 0 {
-			if _, writeErr := w.Write(buf[:n]); writeErr != nil {
-				return fmt.Errorf("failed to write response: %w", writeErr)
-			}
-		}
-		if readErr != nil {
-			if errors.Is(readErr, io.EOF) {
-				break
-			}
-			return fmt.Errorf("failed to read response: %w", readErr)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			slog.Error("Failed to encode webhooks", "service", spec.Slug, "error", err)
+			http.Error(w, "Failed to encode webhooks", http.StatusInternalServerError)
 		}
 	}
-	return nil
 }
 
-// handleProxy acts as a CORS proxy for API requests made from Swagger UI.
-// It forwards requests to the actual API servers, bypassing CORS restrictions.
-// Usage: /proxy?url={target-url}
-// Example: /proxy?url=https://testcertsapi.bpglobal.com/VEDAUTH/Authorize/OAuth
-func handleProxy() http.HandlerFunc {
+// handleMock answers requests to /mock/{service}[/v/{version}]/... from the spec of the service,
+// so clients can be built before the API exists. CORS is allowed for any origin.
+func handleMock(registry *discovery.Registry, mocks *mock.Mocks) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Extract the target URL from query parameter
-		targetURL := r.URL.Query().Get("url")
-		if targetURL == "" {
-			http.Error(w, "Target URL is required. Usage: /proxy?url={target-url}", http.StatusBadRequest)
+		setCORSHeaders(w)
+		w.Header().Set("Access-Control-Allow-Headers", w.Header().Get("Access-Control-Allow-Headers")+
+			", "+mock.HeaderPrefer+", "+mock.HeaderScenario)
+		w.Header().Set("Access-Control-Expose-Headers", "Location, Preference-Applied")
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Max-Age", "3600")
+			w.WriteHeader(http.StatusOK)
 			return
 		}
 
-		// Handle preflight OPTIONS request
-		if r.Method == http.MethodOptions {
-			handlePreflightRequest(w)
-			return
+		service := mux.Vars(r)["service"]
+		path := strings.TrimPrefix(r.URL.Path, "/mock/"+service)
+		version := ""
+		if rest, ok := strings.CutPrefix(path, "/v/"); ok {
+			// A version segment selects a version only if the service has it: the spec's own
+			// paths may start with /v/ too.
+			candidate, after, _ := strings.Cut(rest, "/")
+			if svc, found := registry.Service(service); found {
+				if _, exists := svc.Version(candidate); exists {
+					version, path = candidate, "/"+after
+				}
+			}
 		}
-
-		// Create the proxied request with context
-		proxyReq, err := http.NewRequestWithContext(r.Context(), r.Method, targetURL, r.Body)
-		if err != nil {
-			slog.Error("Failed to create proxy request", "error", err, "target_url", targetURL)
-			http.Error(w, "Failed to create proxy request", http.StatusInternalServerError)
-			return
+		if path == "" {
+			path = "/"
 		}
 
-		// Copy headers and query parameters
-		copyRequestHeaders(proxyReq, r)
-		copyQueryParameters(proxyReq, r)
-
-		// Make the request
-		client := &http.Client{
-			Timeout: proxyTimeout * time.Second,
+		_, spec, found := findSpec(registry, service, version)
+		if !found {
+			http.Error(w, "Service not found", http.StatusNotFound)
+			return
 		}
-		resp, err := client.Do(proxyReq)
-		if err != nil {
-			slog.Error("Proxy request failed", "error", err, "target_url", targetURL)
-			http.Error(w, fmt.Sprintf("Proxy request failed: %v", err), http.StatusBadGateway)
+		if spec.DocV3 == nil {
+			http.Error(w, fmt.Sprintf("%s could not be loaded as an OpenAPI document", spec.Slug),
+				http.StatusUnprocessableEntity)
 			return
 		}
-		defer resp.Body.Close()
-
-		// Set CORS headers and copy response headers
-		setCORSHeaders(w)
-		copyResponseHeaders(w, resp)
-
-		// Set status code
-		w.WriteHeader(resp.StatusCode)
+		mocks.Get(spec.Slug, spec.DocV3).Serve(w, r, path)
+	}
+}
 
-		// Stream the response body
-		if streamErr := streamResponseBody(w, resp); streamErr != nil {
-			slog.Error("Failed to write proxy response", "error", streamErr)
+// handleMockReset forgets what clients stored in the mock of a spec.
+func handleMockReset(registry *discovery.Registry, mocks *mock.Mocks) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, spec, ok := lookupSpec(registry, w, r)
+		if !ok {
 			return
 		}
+		mocks.Reset(spec.Slug)
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// handleDrift lists the operations whose responses broke their spec in proxied traffic, the most
+// recent first; ?service= narrows the list to one service.
+func handleDrift(drift *contract.DriftLog) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if err := json.NewEncoder(w).Encode(drift.Entries(r.URL.Query().Get("service"))); err != nil {
+			slog.Error("Failed to encode drift log", "error", err)
+			http.Error(w, "Failed to encode drift log", http.StatusInternalServerError)
+		}
+	}
+}
 
-		slog.Info("Proxy request completed", "method", r.Method, "target_url", targetURL, "status", resp.StatusCode)
+// handleDriftReset clears the drift log, or the entries of ?service= only.
+func handleDriftReset(drift *contract.DriftLog) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		drift.Reset(r.URL.Query().Get("service"))
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.WriteHeader(http.StatusNoContent)
 	}
 }
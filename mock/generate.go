@@ -0,0 +1,219 @@
+package mock
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"strings"
+
+	oas3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+// maxDepth is how deep Generate nests objects and arrays.
+const maxDepth = 8
+
+// maxItems caps the length of generated arrays, whatever their minItems.
+const maxItems = 10
+
+// Generate returns a value that fits schema: its example, default or first enum value if it has
+// one, or a value synthesized from its type and constraints. Write-only properties are left
+// out, as the value is meant for a response body. Recursive schemas stop at the first repeat:
+// a Pet's owner lists no pets.
+func Generate(schema *oas3.SchemaRef) any {
+	g := generator{active: make(map[*oas3.Schema]bool)}
+	value, _ := g.generate(schema, 0)
+	return value
+}
+
+// generator synthesizes one value, tracking the schemas it is inside of.
+type generator struct {
+	active map[*oas3.Schema]bool
+}
+
+// generate returns a value of ref, or false if ref is a schema being generated already.
+func (g generator) generate(ref *oas3.SchemaRef, depth int) (any, bool) {
+	if ref == nil || ref.Value == nil {
+		return nil, true
+	}
+	s := ref.Value
+	if g.active[s] {
+		return nil, false
+	}
+	g.active[s] = true
+	defer delete(g.active, s)
+	return g.value(s, depth), true
+}
+
+func (g generator) value(s *oas3.Schema, depth int) any {
+	switch {
+	case s.Example != nil:
+		return s.Example
+	case s.Default != nil:
+		return s.Default
+	case len(s.Enum) > 0:
+		return s.Enum[0]
+	case len(s.AllOf) > 0:
+		return g.allOf(s, depth)
+	case len(s.OneOf) > 0:
+		value, _ := g.generate(s.OneOf[0], depth)
+		return value
+	case len(s.AnyOf) > 0:
+		value, _ := g.generate(s.AnyOf[0], depth)
+		return value
+	}
+
+	switch schemaType(s) {
+	case oas3.TypeObject:
+		return g.object(s, depth)
+	case oas3.TypeArray:
+		if depth >= maxDepth || s.Items == nil {
+			return []any{}
+		}
+		item, ok := g.generate(s.Items, depth+1)
+		if !ok {
+			return []any{}
+		}
+		items := make([]any, min(max(1, int(s.MinItems)), maxItems)) //nolint:gosec // capped at maxItems
+		for i := range items {
+			items[i] = item
+		}
+		return items
+	case oas3.TypeInteger:
+		return int64(generateNumber(s, true))
+	case oas3.TypeNumber:
+		return generateNumber(s, false)
+	case oas3.TypeBoolean:
+		return true
+	case oas3.TypeNull:
+		return nil
+	}
+	return generateString(s)
+}
+
+// schemaType returns the type of s, or the one its keywords imply when it declares none.
+func schemaType(s *oas3.Schema) string {
+	if s.Type != nil {
+		for _, t := range s.Type.Slice() {
+			if t != oas3.TypeNull {
+				return t
+			}
+		}
+		if s.Type.Is(oas3.TypeNull) {
+			return oas3.TypeNull
+		}
+	}
+	switch {
+	case len(s.Properties) > 0 || s.AdditionalProperties.Schema != nil:
+		return oas3.TypeObject
+	case s.Items != nil:
+		return oas3.TypeArray
+	}
+	return oas3.TypeString
+}
+
+func (g generator) object(s *oas3.Schema, depth int) map[string]any {
+	object := make(map[string]any, len(s.Properties))
+	if depth >= maxDepth {
+		return object
+	}
+	for _, name := range slices.Sorted(maps.Keys(s.Properties)) {
+		prop := s.Properties[name]
+		if prop != nil && prop.Value != nil && prop.Value.WriteOnly {
+			continue
+		}
+		if value, ok := g.generate(prop, depth+1); ok {
+			object[name] = value
+		}
+	}
+	if len(s.Properties) == 0 && s.AdditionalProperties.Schema != nil {
+		if value, ok := g.generate(s.AdditionalProperties.Schema, depth+1); ok {
+			object["key"] = value
+		}
+	}
+	return object
+}
+
+// allOf merges the objects generated for the members of an allOf and the schema's own
+// properties; a composition of non-objects yields its first member's value.
+func (g generator) allOf(s *oas3.Schema, depth int) any {
+	merged := map[string]any{}
+	for _, member := range s.AllOf {
+		value, _ := g.generate(member, depth)
+		object, ok := value.(map[string]any)
+		if !ok {
+			return value
+		}
+		maps.Copy(merged, object)
+	}
+	own := *s
+	own.AllOf = nil
+	if len(own.Properties) > 0 {
+		maps.Copy(merged, g.object(&own, depth))
+	}
+	return merged
+}
+
+// generateNumber returns 0, moved into the schema's bounds.
+func generateNumber(s *oas3.Schema, integer bool) float64 {
+	value := 0.0
+	if s.Min != nil && value <= *s.Min {
+		value = *s.Min
+		if s.ExclusiveMin {
+			value++
+		}
+	}
+	if s.Max != nil && value >= *s.Max {
+		value = *s.Max
+		if s.ExclusiveMax {
+			value--
+		}
+	}
+	if integer {
+		value = math.Ceil(value)
+	}
+	if s.MultipleOf != nil && *s.MultipleOf > 0 {
+		value = math.Ceil(value / *s.MultipleOf) * *s.MultipleOf
+	}
+	return value
+}
+
+// generateString returns a sample of the schema's format, or "string", fitted to its length bounds.
+func generateString(s *oas3.Schema) string {
+	value := formatSample(s.Format)
+	if n := int(s.MinLength); len(value) < n { //nolint:gosec // lengths of real schemas fit an int
+		value += strings.Repeat("x", n-len(value))
+	}
+	if s.MaxLength != nil && uint64(len(value)) > *s.MaxLength {
+		value = value[:*s.MaxLength]
+	}
+	return value
+}
+
+// formatSample returns a sample value of a string format, or "string" for unknown formats.
+func formatSample(format string) string {
+	switch format {
+	case "date-time":
+		return "2024-01-01T12:00:00Z"
+	case "date":
+		return "2024-01-01"
+	case "time":
+		return "12:00:00"
+	case "email":
+		return "user@example.com"
+	case "uuid":
+		return "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+	case "uri", "url":
+		return "https://example.com"
+	case "hostname":
+		return "example.com"
+	case "ipv4":
+		return "192.0.2.1"
+	case "ipv6":
+		return "2001:db8::1"
+	case "byte":
+		return "c3RyaW5n"
+	case "password":
+		return "p4ssw0rd"
+	}
+	return "string"
+}
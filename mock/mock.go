@@ -0,0 +1,341 @@
+// Package mock answers requests from an OpenAPI 3 document alone: it matches the request to an
+// operation of the spec's paths and replies with the operation's success response, using the
+// declared example when there is one and a value synthesized from the response schema
+// otherwise, so clients can be built against a contract before its backend exists.
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"mime"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	oas3 "github.com/getkin/kin-openapi/openapi3"
+	"sigs.k8s.io/yaml"
+)
+
+// Mock serves the operations of one document, keeping the items of its collections in memory.
+type Mock struct {
+	doc    *oas3.T
+	routes []route
+	bases  []string // path components of the servers, which request paths may start with
+	store  *store
+}
+
+// New returns a Mock of doc with empty collections.
+func New(doc *oas3.T) *Mock {
+	return &Mock{doc: doc, routes: compileRoutes(doc), bases: basePaths(doc), store: newStore()}
+}
+
+// Serve answers r as the API would. path is the request path relative to the API, e.g.
+// "/pets/1"; paths that start with the path of one of the spec's servers work too. Paths the
+// spec does not define get 404, methods it does not define for the path 405 and Accept headers
+// no response content type satisfies 406.
+//
+// Collections ("/pets" next to "/pets/{petId}") are stateful: see serveState. A scenario
+// header (see HeaderPrefer and HeaderScenario) forces a response of the operation instead,
+// with a named example or status, and leaves the state alone.
+func (m *Mock) Serve(w http.ResponseWriter, r *http.Request, path string) {
+	rt, matched, ok := m.find(path)
+	if !ok {
+		http.Error(w, fmt.Sprintf("No path of the spec matches %s", path), http.StatusNotFound)
+		return
+	}
+	op := rt.item.GetOperation(r.Method)
+	if op == nil && r.Method == http.MethodHead {
+		op = rt.item.GetOperation(http.MethodGet)
+	}
+	if op == nil {
+		w.Header().Set("Allow", strings.Join(slices.Sorted(maps.Keys(rt.item.Operations())), ", "))
+		http.Error(w, fmt.Sprintf("%s does not define %s", rt.template, r.Method), http.StatusMethodNotAllowed)
+		return
+	}
+
+	if sc := parseScenario(r.Header); sc.requested() {
+		status, resp, err := sc.resolve(op.Responses)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("%s %s: %v", r.Method, rt.template, err), http.StatusBadRequest)
+			return
+		}
+		if len(sc.applied) > 0 {
+			w.Header().Set("Preference-Applied", strings.Join(sc.applied, ", "))
+		}
+		writeResponse(w, r, status, resp, sc.example)
+		return
+	}
+	if m.serveState(w, r, rt, matched, op) {
+		return
+	}
+
+	status, resp := pickResponse(op.Responses)
+	if resp == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeResponse(w, r, status, resp, "")
+}
+
+// find returns the route of path, trying it as given, then without each server's base path,
+// and the path it matched.
+func (m *Mock) find(path string) (route, string, bool) {
+	candidates := []string{path}
+	for _, base := range m.bases {
+		if rest, ok := strings.CutPrefix(path, base); ok && (rest == "" || rest[0] == '/') {
+			candidates = append(candidates, rest)
+		}
+	}
+	for _, candidate := range candidates {
+		for _, rt := range m.routes {
+			if rt.match(candidate) {
+				return rt, candidate, true
+			}
+		}
+	}
+	return route{}, "", false
+}
+
+// Ranks of the kinds of response keys: Serve answers with the lowest.
+const (
+	rankSuccess      = iota // 2xx codes, the lowest first
+	rankSuccessRange        // 2XX
+	rankDefault             // default
+	rankOther               // other codes, e.g. 304 or 404
+	rankOtherRange          // other ranges, e.g. 4XX
+)
+
+// pickResponse chooses the response a mock gives: the lowest 2xx status, else 2XX, else
+// default (as 200), else the lowest other status.
+func pickResponse(responses *oas3.Responses) (int, *oas3.Response) {
+	if responses == nil {
+		return 0, nil
+	}
+	bestRank, bestStatus := rankOtherRange+1, 0
+	var best *oas3.Response
+	for key, ref := range responses.Map() {
+		if ref == nil || ref.Value == nil {
+			continue
+		}
+		rank, status := responseRank(key)
+		if status == 0 {
+			continue
+		}
+		if rank < bestRank || (rank == bestRank && status < bestStatus) {
+			bestRank, bestStatus, best = rank, status, ref.Value
+		}
+	}
+	return bestStatus, best
+}
+
+// responseRank ranks a response key and returns the status it answers with; 0 if the key is
+// not a status.
+func responseRank(key string) (int, int) {
+	if key == "default" {
+		return rankDefault, http.StatusOK
+	}
+	if len(key) == 3 && key[0] >= '1' && key[0] <= '5' && strings.EqualFold(key[1:], "XX") { //nolint:mnd // "4XX"
+		status := int(key[0]-'0') * 100 //nolint:mnd // "4XX" answers 400
+		if key[0] == '2' {
+			return rankSuccessRange, status
+		}
+		return rankOtherRange, status
+	}
+	status, err := strconv.Atoi(key)
+	if err != nil || status < 100 || status > 599 { //nolint:mnd // the range of HTTP statuses
+		return 0, 0
+	}
+	if status >= 200 && status < 300 {
+		return rankSuccess, status
+	}
+	return rankOther, status
+}
+
+// writeResponse writes resp with status, in the content type the request accepts, with the
+// example named exampleName if it has one.
+func writeResponse(w http.ResponseWriter, r *http.Request, status int, resp *oas3.Response, exampleName string) {
+	setHeaders(w, resp.Headers)
+	if len(resp.Content) == 0 {
+		w.WriteHeader(status)
+		return
+	}
+
+	mediaType, media, ok := negotiate(resp.Content, r.Header.Get("Accept"))
+	if !ok {
+		http.Error(w, "No content type of the response matches Accept: "+
+			strings.Join(slices.Sorted(maps.Keys(resp.Content)), ", "), http.StatusNotAcceptable)
+		return
+	}
+	value := example(media, exampleName)
+	if value == nil && (media == nil || media.Schema == nil) {
+		w.Header().Set("Content-Type", mediaType)
+		w.WriteHeader(status)
+		return
+	}
+	body, err := encode(mediaType, value)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to encode the %s example: %v", mediaType, err),
+			http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", mediaType)
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+// example returns the example of a media type: the one named name, else example, the first of
+// examples by name, or a value generated from the schema.
+func example(media *oas3.MediaType, name string) any {
+	if media == nil {
+		return nil
+	}
+	if ex := media.Examples[name]; name != "" && ex != nil && ex.Value != nil && ex.Value.Value != nil {
+		return ex.Value.Value
+	}
+	if media.Example != nil {
+		return media.Example
+	}
+	for _, name := range slices.Sorted(maps.Keys(media.Examples)) {
+		if ex := media.Examples[name]; ex != nil && ex.Value != nil && ex.Value.Value != nil {
+			return ex.Value.Value
+		}
+	}
+	return Generate(media.Schema)
+}
+
+// setHeaders sets the response headers the spec declares, from their examples or schemas.
+func setHeaders(w http.ResponseWriter, headers oas3.Headers) {
+	for _, name := range slices.Sorted(maps.Keys(headers)) {
+		ref := headers[name]
+		if ref == nil || ref.Value == nil || strings.EqualFold(name, "Content-Type") {
+			continue
+		}
+		value := ref.Value.Example
+		if value == nil {
+			value = Generate(ref.Value.Schema)
+		}
+		if text, ok := headerValue(value); ok {
+			w.Header().Set(name, text)
+		}
+	}
+}
+
+// headerValue formats a scalar or an array of scalars as a header value.
+func headerValue(value any) (string, bool) {
+	switch v := value.(type) {
+	case nil, map[string]any:
+		return "", false
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(parts, ","), true
+	default:
+		return fmt.Sprint(v), true
+	}
+}
+
+// negotiate picks the media type of content that the Accept header prefers, JSON first among
+// equals. An empty Accept header accepts anything.
+func negotiate(content oas3.Content, accept string) (string, *oas3.MediaType, bool) {
+	declared := slices.Sorted(maps.Keys(content))
+	sort.SliceStable(declared, func(i, j int) bool { return isJSON(declared[i]) && !isJSON(declared[j]) })
+
+	for _, wanted := range acceptedRanges(accept) {
+		for _, key := range declared {
+			if matchesRange(wanted, key) {
+				return concreteType(key, wanted), content[key], true
+			}
+		}
+	}
+	return "", nil, false
+}
+
+// acceptedRanges returns the media ranges of an Accept header, most preferred first, without
+// those with q=0.
+func acceptedRanges(accept string) []string {
+	if strings.TrimSpace(accept) == "" {
+		return []string{"*/*"}
+	}
+	type weighted struct {
+		media string
+		q     float64
+	}
+	var ranges []weighted
+	for _, part := range strings.Split(accept, ",") {
+		media, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			if parsed, parseErr := strconv.ParseFloat(raw, 64); parseErr == nil {
+				q = parsed
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, weighted{media: media, q: q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+	out := make([]string, len(ranges))
+	for i, r := range ranges {
+		out[i] = r.media
+	}
+	return out
+}
+
+// matchesRange reports whether two media types or ranges overlap, e.g. "application/*" and
+// "application/json".
+func matchesRange(a, b string) bool {
+	a, b = baseType(a), baseType(b)
+	aType, aSub, _ := strings.Cut(a, "/")
+	bType, bSub, _ := strings.Cut(b, "/")
+	return (aType == "*" || bType == "*" || aType == bType) && (aSub == "*" || bSub == "*" || aSub == bSub)
+}
+
+// concreteType returns the type a response is sent as: the declared one, or for a declared
+// range the accepted type, JSON when that is a range too.
+func concreteType(declared, accepted string) string {
+	switch {
+	case !strings.Contains(declared, "*"):
+		return declared
+	case !strings.Contains(accepted, "*"):
+		return accepted
+	case strings.HasPrefix(baseType(declared), "text/"):
+		return "text/plain"
+	}
+	return "application/json"
+}
+
+// baseType returns a media type without parameters, in lower case.
+func baseType(mediaType string) string {
+	base, _, _ := strings.Cut(mediaType, ";")
+	return strings.ToLower(strings.TrimSpace(base))
+}
+
+// isJSON reports whether mediaType is JSON or a JSON-based type such as application/problem+json.
+func isJSON(mediaType string) bool {
+	base := baseType(mediaType)
+	return base == "application/json" || strings.HasSuffix(base, "+json") || base == "*/*"
+}
+
+// encode serializes value for mediaType: as YAML for YAML types, as is for strings sent as
+// anything but JSON, and as JSON otherwise.
+func encode(mediaType string, value any) ([]byte, error) {
+	if text, ok := value.(string); ok && !isJSON(mediaType) {
+		return []byte(text), nil
+	}
+	if strings.Contains(baseType(mediaType), "yaml") {
+		return yaml.Marshal(value)
+	}
+	body, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(body, '\n'), nil
+}
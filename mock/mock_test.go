@@ -0,0 +1,163 @@
+package mock_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	oas3 "github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/Hossein-Roshandel/webswags/mock"
+)
+
+const filesSpec = `openapi: 3.0.3
+info: {title: Files, version: "1"}
+servers:
+  - url: https://api.example.com/{version}
+    variables:
+      version: {default: v1}
+paths:
+  /files/latest:
+    get:
+      responses:
+        "200":
+          description: The latest file
+          content:
+            application/json:
+              example: {name: latest}
+  /files/{name}/meta:
+    get:
+      responses:
+        "404":
+          description: Not found
+        "200":
+          description: Metadata
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  size: {type: integer, example: 42}
+  /health:
+    get:
+      responses:
+        default:
+          description: Health
+          content:
+            text/plain:
+              example: ok
+  /files/latest/lock:
+    put:
+      responses:
+        "204":
+          description: Locked
+`
+
+// load parses an OpenAPI 3 document.
+func load(t *testing.T, data string) *oas3.T {
+	t.Helper()
+	doc, err := oas3.NewLoader().LoadFromData([]byte(data))
+	if err != nil {
+		t.Fatalf("LoadFromData: %v", err)
+	}
+	return doc
+}
+
+// serve sends a request to m and returns the response. body may be "".
+func serve(m *mock.Mock, method, path, body string, header http.Header) *http.Response {
+	r := httptest.NewRequest(method, "/mock/svc"+path, strings.NewReader(body))
+	for name, values := range header {
+		r.Header[name] = values
+	}
+	w := httptest.NewRecorder()
+	m.Serve(w, r, path)
+	return w.Result()
+}
+
+// readBody returns the body of resp.
+func readBody(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestServeRoutes(t *testing.T) {
+	t.Parallel()
+	m := mock.New(load(t, filesSpec))
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		accept     string
+		wantStatus int
+		wantType   string
+		wantBody   string // a substring of the body
+	}{
+		{
+			name: "example", method: http.MethodGet, path: "/files/latest",
+			wantStatus: http.StatusOK, wantType: "application/json", wantBody: `"name": "latest"`,
+		},
+		{
+			name: "literal segments win over parameters", method: http.MethodGet, path: "/files/latest/meta",
+			wantStatus: http.StatusOK, wantType: "application/json", wantBody: `"size": 42`,
+		},
+		{
+			name: "server base path", method: http.MethodGet, path: "/v1/files/latest/",
+			wantStatus: http.StatusOK, wantBody: `"name": "latest"`,
+		},
+		{
+			name: "default response as 200", method: http.MethodGet, path: "/health", accept: "text/*",
+			wantStatus: http.StatusOK, wantType: "text/plain", wantBody: "ok",
+		},
+		{
+			name: "head", method: http.MethodHead, path: "/health",
+			wantStatus: http.StatusOK, wantType: "text/plain",
+		},
+		{
+			name: "no content", method: http.MethodPut, path: "/files/latest/lock",
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name: "unknown path", method: http.MethodGet, path: "/folders",
+			wantStatus: http.StatusNotFound, wantBody: "No path of the spec matches /folders",
+		},
+		{
+			name: "unknown method", method: http.MethodPost, path: "/files/latest",
+			wantStatus: http.StatusMethodNotAllowed, wantBody: "/files/latest does not define POST",
+		},
+		{
+			name: "not acceptable", method: http.MethodGet, path: "/files/latest", accept: "application/xml",
+			wantStatus: http.StatusNotAcceptable, wantBody: "application/json",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			resp := serve(m, tt.method, tt.path, "", http.Header{"Accept": {tt.accept}})
+			body := readBody(t, resp)
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d; body %q", resp.StatusCode, tt.wantStatus, body)
+			}
+			if got := resp.Header.Get("Content-Type"); tt.wantType != "" && got != tt.wantType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantType)
+			}
+			if !strings.Contains(body, tt.wantBody) {
+				t.Errorf("body = %q, want it to contain %q", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestServeAllowsDeclaredMethods(t *testing.T) {
+	t.Parallel()
+	m := mock.New(load(t, filesSpec))
+	resp := serve(m, http.MethodDelete, "/files/latest/lock", "", nil)
+	if got := resp.Header.Get("Allow"); got != http.MethodPut {
+		t.Errorf("Allow = %q, want PUT", got)
+	}
+}
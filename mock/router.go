@@ -0,0 +1,134 @@
+package mock
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	oas3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+// paramPattern matches a path parameter of a path template, e.g. "{petId}".
+var paramPattern = regexp.MustCompile(`\{([^{}/]+)\}`)
+
+// Kinds of resources a route addresses, for stateful mocking.
+const (
+	kindOther      = iota
+	kindCollection // e.g. "/pets", when "/pets/{petId}" exists too
+	kindItem       // e.g. "/pets/{petId}": a path whose last segment is a parameter
+)
+
+// route is a path of the spec compiled for matching request paths.
+type route struct {
+	template string // e.g. "/pets/{petId}"
+	pattern  *regexp.Regexp
+	literals int // segments without parameters; more specific routes are tried first
+	item     *oas3.PathItem
+	kind     int
+	idParam  string // for items, the parameter naming the item; for collections, that of their items
+}
+
+// compileRoutes compiles the paths of doc, most specific first: "/pets/mine" is tried before
+// "/pets/{petId}". Paths ending in a parameter are items of the collection their parent path
+// names, which is a collection route if the spec declares it.
+func compileRoutes(doc *oas3.T) []route {
+	if doc.Paths == nil {
+		return nil
+	}
+	var routes []route
+	for template, item := range doc.Paths.Map() {
+		if item == nil {
+			continue
+		}
+		r := route{template: template, item: item}
+		var pattern strings.Builder
+		pattern.WriteString("^")
+		for _, segment := range strings.Split(strings.Trim(template, "/"), "/") {
+			if segment == "" {
+				continue
+			}
+			pattern.WriteString("/")
+			if !strings.Contains(segment, "{") {
+				r.literals++
+			}
+			last := 0
+			for _, loc := range paramPattern.FindAllStringIndex(segment, -1) {
+				pattern.WriteString(regexp.QuoteMeta(segment[last:loc[0]]))
+				pattern.WriteString("[^/]+")
+				last = loc[1]
+			}
+			pattern.WriteString(regexp.QuoteMeta(segment[last:]))
+		}
+		pattern.WriteString("/?$")
+		r.pattern = regexp.MustCompile(pattern.String())
+		if _, param := itemOf(template); param != "" {
+			r.kind, r.idParam = kindItem, param
+		}
+		routes = append(routes, r)
+	}
+	for i := range routes {
+		for _, other := range routes {
+			parent, param := itemOf(other.template)
+			if param != "" && parent == strings.TrimRight(routes[i].template, "/") && routes[i].kind == kindOther {
+				routes[i].kind, routes[i].idParam = kindCollection, param
+			}
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].literals != routes[j].literals {
+			return routes[i].literals > routes[j].literals
+		}
+		return routes[i].template < routes[j].template
+	})
+	return routes
+}
+
+// itemOf splits an item path template into its collection's template and the parameter naming
+// the item: "/pets/{petId}" is "/pets" and "petId". The parameter is "" for other templates.
+func itemOf(template string) (string, string) {
+	parent, last, found := cutLast(strings.TrimRight(template, "/"))
+	if !found || !strings.HasPrefix(last, "{") || !strings.HasSuffix(last, "}") || strings.Count(last, "{") != 1 {
+		return "", ""
+	}
+	return parent, last[1 : len(last)-1]
+}
+
+// cutLast splits a path at its last slash.
+func cutLast(path string) (string, string, bool) {
+	i := strings.LastIndex(path, "/")
+	if i < 0 {
+		return "", "", false
+	}
+	return path[:i], path[i+1:], true
+}
+
+// match reports whether path is a path of the route.
+func (r route) match(path string) bool {
+	return r.pattern.MatchString(path)
+}
+
+// basePaths returns the path components of the servers of doc, with server variables at their
+// defaults: clients written against "https://api.example.com/v1" call "/v1/pets".
+func basePaths(doc *oas3.T) []string {
+	var bases []string
+	for _, server := range doc.Servers {
+		if server == nil {
+			continue
+		}
+		raw := server.URL
+		for name, variable := range server.Variables {
+			if variable != nil {
+				raw = strings.ReplaceAll(raw, "{"+name+"}", variable.Default)
+			}
+		}
+		u, err := url.Parse(raw)
+		if err != nil {
+			continue
+		}
+		if base := strings.TrimRight(u.Path, "/"); base != "" && base != "." {
+			bases = append(bases, "/"+strings.TrimLeft(base, "/"))
+		}
+	}
+	return bases
+}
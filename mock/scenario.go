@@ -0,0 +1,123 @@
+package mock
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	oas3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+// Headers that select a scenario: "Prefer: code=404, example=notFound" as Prism reads it, or
+// "X-Mock-Scenario: notFound" (an example name) or "X-Mock-Scenario: 404" (a response).
+const (
+	HeaderPrefer   = "Prefer"
+	HeaderScenario = "X-Mock-Scenario"
+)
+
+// scenario is the response a request asks for instead of the mock's default behaviour.
+type scenario struct {
+	code    string   // response key: a status such as "404", a range such as "4XX", or "default"
+	example string   // name of an example of the response
+	applied []string // preferences of the Prefer header honoured, for Preference-Applied
+}
+
+// parseScenario reads the scenario headers of a request; the zero scenario asks for nothing.
+func parseScenario(header http.Header) scenario {
+	var sc scenario
+	for _, value := range header.Values(HeaderPrefer) {
+		for _, preference := range strings.Split(value, ",") {
+			preference, _, _ = strings.Cut(preference, ";")
+			name, raw, _ := strings.Cut(preference, "=")
+			raw = strings.Trim(strings.TrimSpace(raw), `"`)
+			switch strings.ToLower(strings.TrimSpace(name)) {
+			case "code":
+				sc.code = raw
+				sc.applied = append(sc.applied, "code="+raw)
+			case "example":
+				sc.example = raw
+				sc.applied = append(sc.applied, "example="+raw)
+			}
+		}
+	}
+	if value := strings.TrimSpace(header.Get(HeaderScenario)); value != "" {
+		if isResponseKey(value) {
+			sc.code = value
+		} else {
+			sc.example = value
+		}
+	}
+	return sc
+}
+
+// requested reports whether the request asks for a scenario.
+func (sc scenario) requested() bool {
+	return sc.code != "" || sc.example != ""
+}
+
+// isResponseKey reports whether value names a response rather than an example: "404", "4XX"
+// or "default".
+func isResponseKey(value string) bool {
+	_, status := responseRank(strings.ToLower(value))
+	return status != 0
+}
+
+// resolve finds the response of the scenario among responses: the one with its code (exact,
+// then its range, then default), else the first with an example of its name, success first.
+// It returns the status to answer with.
+func (sc scenario) resolve(responses *oas3.Responses) (int, *oas3.Response, error) {
+	if responses == nil {
+		return 0, nil, fmt.Errorf("the operation declares no responses")
+	}
+	if sc.code != "" {
+		if _, status := responseRank(strings.ToLower(sc.code)); status == 0 {
+			return 0, nil, fmt.Errorf("%q is not a response status", sc.code)
+		}
+		for _, key := range []string{sc.code, strings.ToUpper(sc.code[:1]) + "XX", "default"} {
+			ref := responses.Value(key)
+			if ref == nil || ref.Value == nil {
+				continue
+			}
+			status, err := strconv.Atoi(sc.code)
+			if err != nil {
+				_, status = responseRank(key)
+			}
+			if sc.example != "" && !hasExample(ref.Value, sc.example) {
+				return 0, nil, fmt.Errorf("the %s response has no example named %q", key, sc.example)
+			}
+			return status, ref.Value, nil
+		}
+		return 0, nil, fmt.Errorf("the operation declares no %s response", sc.code)
+	}
+
+	keys := slices.Collect(maps.Keys(responses.Map()))
+	sort.Slice(keys, func(i, j int) bool {
+		ri, si := responseRank(keys[i])
+		rj, sj := responseRank(keys[j])
+		return ri < rj || (ri == rj && si < sj)
+	})
+	for _, key := range keys {
+		ref := responses.Value(key)
+		if ref == nil || ref.Value == nil || !hasExample(ref.Value, sc.example) {
+			continue
+		}
+		if _, status := responseRank(key); status != 0 {
+			return status, ref.Value, nil
+		}
+	}
+	return 0, nil, fmt.Errorf("no response of the operation has an example named %q", sc.example)
+}
+
+// hasExample reports whether a media type of resp has an example named name.
+func hasExample(resp *oas3.Response, name string) bool {
+	for _, media := range resp.Content {
+		if media != nil && media.Examples[name] != nil {
+			return true
+		}
+	}
+	return false
+}
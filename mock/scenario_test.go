@@ -0,0 +1,92 @@
+package mock_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Hossein-Roshandel/webswags/mock"
+)
+
+func TestServeScenarios(t *testing.T) {
+	t.Parallel()
+	m := mock.New(load(t, petsSpec))
+	tests := []struct {
+		name        string
+		header      http.Header
+		wantStatus  int
+		wantBody    string // a substring of the body
+		wantApplied string // Preference-Applied
+	}{
+		{
+			name:        "prefer code",
+			header:      http.Header{mock.HeaderPrefer: {"code=404"}},
+			wantStatus:  http.StatusNotFound,
+			wantBody:    "no such pet",
+			wantApplied: "code=404",
+		},
+		{
+			name:        "prefer code and example",
+			header:      http.Header{mock.HeaderPrefer: {`code=200, example="tom"`}},
+			wantStatus:  http.StatusOK,
+			wantBody:    "Tom",
+			wantApplied: "code=200, example=tom",
+		},
+		{
+			name:       "scenario example",
+			header:     http.Header{mock.HeaderScenario: {"notFound"}},
+			wantStatus: http.StatusNotFound,
+			wantBody:   "no such pet",
+		},
+		{
+			name:       "scenario status falls back to default",
+			header:     http.Header{mock.HeaderScenario: {"503"}},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   `"message": "error"`,
+		},
+		{
+			name:       "unknown example",
+			header:     http.Header{mock.HeaderScenario: {"missing"}},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `no response of the operation has an example named "missing"`,
+		},
+		{
+			name:       "example of another response",
+			header:     http.Header{mock.HeaderPrefer: {"code=404, example=tom"}},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `the 404 response has no example named "tom"`,
+		},
+		{
+			name:       "not a status",
+			header:     http.Header{mock.HeaderPrefer: {"code=teapot"}},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `"teapot" is not a response status`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			resp := serve(m, http.MethodGet, "/pets/1", "", tt.header)
+			body := readBody(t, resp)
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d; body %q", resp.StatusCode, tt.wantStatus, body)
+			}
+			if !strings.Contains(body, tt.wantBody) {
+				t.Errorf("body = %q, want it to contain %q", body, tt.wantBody)
+			}
+			if got := resp.Header.Get("Preference-Applied"); got != tt.wantApplied {
+				t.Errorf("Preference-Applied = %q, want %q", got, tt.wantApplied)
+			}
+		})
+	}
+}
+
+func TestServeScenarioLeavesStateAlone(t *testing.T) {
+	t.Parallel()
+	m := mock.New(load(t, petsSpec))
+	resp := serve(m, http.MethodDelete, "/pets/1", "", http.Header{mock.HeaderScenario: {"204"}})
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("DELETE status = %d, want 204", resp.StatusCode)
+	}
+	decode(t, m, http.MethodGet, "/pets/1", "", http.StatusOK)
+}
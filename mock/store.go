@@ -0,0 +1,373 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	oas3 "github.com/getkin/kin-openapi/openapi3"
+)
+
+// maxBodySize is the largest request body a stateful mock reads.
+const maxBodySize = 1 << 20 // 1 MiB
+
+// Mocks keeps the Mock of each spec, so the state of its collections survives between
+// requests and a spec is compiled again only when it changes.
+type Mocks struct {
+	mu    sync.Mutex
+	mocks map[string]*Mock
+}
+
+// NewMocks returns an empty set of mocks.
+func NewMocks() *Mocks {
+	return &Mocks{mocks: make(map[string]*Mock)}
+}
+
+// Get returns the Mock of doc, known by key (e.g. the slug of its spec). When doc has changed
+// since the last call, the new Mock keeps the state of the previous one.
+func (ms *Mocks) Get(key string, doc *oas3.T) *Mock {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	m := ms.mocks[key]
+	if m == nil || m.doc != doc {
+		previous := m
+		m = New(doc)
+		if previous != nil {
+			m.store = previous.store
+		}
+		ms.mocks[key] = m
+	}
+	return m
+}
+
+// Reset forgets the state of the Mock known by key: its collections start over from the
+// spec's examples.
+func (ms *Mocks) Reset(key string) {
+	ms.mu.Lock()
+	m := ms.mocks[key]
+	ms.mu.Unlock()
+	if m != nil {
+		m.store.reset()
+	}
+}
+
+// store holds the items of a Mock's collections.
+type store struct {
+	mu          sync.Mutex
+	collections map[string]*collection // keyed by the collection's request path, e.g. "/customers/7/orders"
+}
+
+func newStore() *store {
+	return &store{collections: make(map[string]*collection)}
+}
+
+func (s *store) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.collections = make(map[string]*collection)
+}
+
+// collection is the state of one collection: its items by ID, in the order they were added.
+type collection struct {
+	ids     []string
+	items   map[string]map[string]any
+	idField string // property holding the ID of an item, e.g. "id"
+	numeric bool   // IDs are numbers rather than strings
+	next    int    // highest numeric ID so far
+	// Shape of list responses: the items alone, or an object holding them in listKey.
+	list    map[string]any
+	listKey string
+}
+
+func (c *collection) add(id string, item map[string]any) {
+	if _, exists := c.items[id]; !exists {
+		c.ids = append(c.ids, id)
+	}
+	c.items[id] = item
+	if n, err := strconv.Atoi(id); err == nil {
+		c.next = max(c.next, n)
+	}
+}
+
+func (c *collection) remove(id string) {
+	delete(c.items, id)
+	for i, existing := range c.ids {
+		if existing == id {
+			c.ids = append(c.ids[:i], c.ids[i+1:]...)
+			break
+		}
+	}
+}
+
+// value returns an ID as an item holds it: a number for numeric collections.
+func (c *collection) value(id string) any {
+	if n, err := strconv.ParseInt(id, 10, 64); err == nil && c.numeric {
+		return n
+	}
+	return id
+}
+
+// listing returns the items as a list response: an array, or the wrapping object of the
+// spec's example with the items in place of its array.
+func (c *collection) listing() any {
+	items := make([]any, 0, len(c.ids))
+	for _, id := range c.ids {
+		items = append(items, c.items[id])
+	}
+	if c.list == nil {
+		return items
+	}
+	wrapped := maps.Clone(c.list)
+	wrapped[c.listKey] = items
+	return wrapped
+}
+
+// serveState answers requests to collections and their items from the store: POST adds to a
+// collection and GET lists it; GET, PUT, PATCH and DELETE read, replace, merge and remove an
+// item. It returns false for requests it leaves to the static responses, such as those whose
+// response is not JSON.
+func (m *Mock) serveState(w http.ResponseWriter, r *http.Request, rt route, path string, op *oas3.Operation) bool {
+	method := r.Method
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+	var collectionPath, id string
+	switch {
+	case rt.kind == kindCollection && (method == http.MethodGet || method == http.MethodPost):
+		collectionPath = strings.TrimRight(path, "/")
+	case rt.kind == kindItem && method != http.MethodPost:
+		var found bool
+		collectionPath, id, found = cutLast(strings.TrimRight(path, "/"))
+		if !found {
+			return false
+		}
+	default:
+		return false
+	}
+
+	status, resp := pickResponse(op.Responses)
+	if status == 0 {
+		status = defaultStatus(method)
+	}
+	mediaType, ok := jsonResponse(resp, r.Header.Get("Accept"))
+	if !ok {
+		return false
+	}
+
+	var body map[string]any
+	if method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch {
+		var err error
+		if body, err = readObject(r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return true
+		}
+	}
+
+	m.store.mu.Lock()
+	c := m.collection(collectionPath, rt)
+	var result any
+	switch {
+	case id == "" && method == http.MethodGet:
+		result = c.listing()
+	case id == "":
+		item := maps.Clone(objectExample(resp))
+		if item == nil {
+			item = map[string]any{}
+		}
+		maps.Copy(item, body)
+		c.next++
+		id = strconv.Itoa(c.next)
+		item[c.idField] = c.value(id)
+		c.add(id, item)
+		w.Header().Set("Location", strings.TrimRight(r.URL.Path, "/")+"/"+url.PathEscape(id))
+		result = item
+	case method == http.MethodPut:
+		item := maps.Clone(body)
+		item[c.idField] = c.value(id)
+		c.add(id, item)
+		result = item
+	default:
+		existing, exists := c.items[id]
+		if !exists {
+			m.store.mu.Unlock()
+			notFound(w, r, op, collectionPath, id)
+			return true
+		}
+		result = existing
+		switch method {
+		case http.MethodPatch:
+			item := maps.Clone(existing)
+			for key, value := range body {
+				if value == nil {
+					delete(item, key)
+				} else {
+					item[key] = value
+				}
+			}
+			item[c.idField] = existing[c.idField]
+			c.add(id, item)
+			result = item
+		case http.MethodDelete:
+			c.remove(id)
+		}
+	}
+	m.store.mu.Unlock()
+
+	writeJSON(w, status, mediaType, result)
+	return true
+}
+
+// collection returns the collection at path, creating it from the spec's examples: the items
+// of the example of the collection's list response, if it has one.
+func (m *Mock) collection(path string, rt route) *collection {
+	if c, ok := m.store.collections[path]; ok {
+		return c
+	}
+	c := &collection{items: make(map[string]map[string]any)}
+	m.store.collections[path] = c
+
+	listTemplate := rt.template
+	if rt.kind == kindItem {
+		listTemplate, _ = itemOf(rt.template)
+	}
+	var seed []any
+	for _, candidate := range m.routes {
+		if strings.TrimRight(candidate.template, "/") != listTemplate || candidate.item.Get == nil {
+			continue
+		}
+		_, resp := pickResponse(candidate.item.Get.Responses)
+		switch value := jsonExample(resp).(type) {
+		case []any:
+			seed = value
+		case map[string]any:
+			// An object wrapping the items, e.g. {"items": [...], "total": 2}: only one array
+			// property says for sure which holds them.
+			var arrays []string
+			for key, inner := range value {
+				if _, ok := inner.([]any); ok {
+					arrays = append(arrays, key)
+				}
+			}
+			if len(arrays) == 1 {
+				seed, c.list, c.listKey = value[arrays[0]].([]any), value, arrays[0]
+			}
+		}
+	}
+
+	// The ID property is the item parameter's name if items have it ("petId"), else "id".
+	sample := map[string]any{}
+	if len(seed) > 0 {
+		sample, _ = seed[0].(map[string]any)
+	}
+	c.idField = "id"
+	if _, ok := sample[rt.idParam]; ok && rt.idParam != "" {
+		c.idField = rt.idParam
+	}
+	_, stringIDs := sample[c.idField].(string)
+	c.numeric = !stringIDs
+	for _, raw := range seed {
+		item, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		id := fmt.Sprint(item[c.idField])
+		if _, has := item[c.idField]; !has {
+			c.next++
+			id = strconv.Itoa(c.next)
+			item = maps.Clone(item)
+			item[c.idField] = c.value(id)
+		}
+		c.add(id, item)
+	}
+	return c
+}
+
+// defaultStatus is the status of an operation that declares no response.
+func defaultStatus(method string) int {
+	switch method {
+	case http.MethodPost:
+		return http.StatusCreated
+	case http.MethodDelete:
+		return http.StatusNoContent
+	}
+	return http.StatusOK
+}
+
+// jsonResponse returns the JSON media type resp is sent as, "" if it has no content. It
+// returns false when the Accept header asks for a type other than JSON.
+func jsonResponse(resp *oas3.Response, accept string) (string, bool) {
+	if resp == nil || len(resp.Content) == 0 {
+		return "", true
+	}
+	mediaType, _, ok := negotiate(resp.Content, accept)
+	return mediaType, ok && isJSON(mediaType)
+}
+
+// jsonExample returns the example of the JSON content of resp, or nil.
+func jsonExample(resp *oas3.Response) any {
+	if resp == nil {
+		return nil
+	}
+	_, media, ok := negotiate(resp.Content, "application/json")
+	if !ok {
+		return nil
+	}
+	return example(media, "")
+}
+
+// objectExample returns the example of the JSON content of resp if it is an object.
+func objectExample(resp *oas3.Response) map[string]any {
+	object, _ := jsonExample(resp).(map[string]any)
+	return object
+}
+
+// readObject reads a JSON object from the request body; an empty body is an empty object.
+func readObject(r *http.Request) (map[string]any, error) {
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the request body: %w", err)
+	}
+	object := map[string]any{}
+	if strings.TrimSpace(string(data)) == "" {
+		return object, nil
+	}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, fmt.Errorf("the request body must be a JSON object: %w", err)
+	}
+	return object, nil
+}
+
+// notFound answers a request for an item the collection does not hold, with the operation's
+// 404 response if it declares one.
+func notFound(w http.ResponseWriter, r *http.Request, op *oas3.Operation, collectionPath, id string) {
+	if op.Responses != nil {
+		if ref := op.Responses.Value("404"); ref != nil && ref.Value != nil {
+			writeResponse(w, r, http.StatusNotFound, ref.Value, "")
+			return
+		}
+	}
+	http.Error(w, fmt.Sprintf("%s holds no item %q", collectionPath, id), http.StatusNotFound)
+}
+
+// writeJSON writes value as the body of a response with status; mediaType "" means no body.
+func writeJSON(w http.ResponseWriter, status int, mediaType string, value any) {
+	if mediaType == "" {
+		w.WriteHeader(status)
+		return
+	}
+	body, err := encode(mediaType, value)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to encode the response: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", mediaType)
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
@@ -0,0 +1,161 @@
+package mock_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/Hossein-Roshandel/webswags/mock"
+)
+
+const petsSpec = `openapi: 3.0.3
+info: {title: Pets, version: "1"}
+paths:
+  /pets:
+    get:
+      responses:
+        "200":
+          description: Pets
+          content:
+            application/json:
+              example:
+                items: [{id: 1, name: Rex}, {id: 2, name: Tom}]
+                total: 2
+    post:
+      responses:
+        "201":
+          description: Created
+          content:
+            application/json:
+              example: {name: Unnamed, status: available}
+  /pets/{petId}:
+    get:
+      responses:
+        "200":
+          description: A pet
+          content:
+            application/json:
+              examples:
+                rex: {value: {id: 1, name: Rex}}
+                tom: {value: {id: 2, name: Tom}}
+        "404":
+          description: No such pet
+          content:
+            application/json:
+              examples:
+                notFound: {value: {message: no such pet}}
+        default:
+          description: Error
+          content:
+            application/json:
+              example: {message: error}
+    put:
+      responses:
+        "200":
+          description: Replaced
+          content:
+            application/json:
+              schema: {type: object}
+    patch:
+      responses:
+        "200":
+          description: Updated
+          content:
+            application/json:
+              schema: {type: object}
+    delete:
+      responses:
+        "204":
+          description: Deleted
+`
+
+// decode serves a request to m and decodes its JSON response.
+func decode(t *testing.T, m *mock.Mock, method, path, body string, wantStatus int) map[string]any {
+	t.Helper()
+	resp := serve(m, method, path, body, nil)
+	data := readBody(t, resp)
+	if resp.StatusCode != wantStatus {
+		t.Fatalf("%s %s: status %d, want %d; body %q", method, path, resp.StatusCode, wantStatus, data)
+	}
+	var value map[string]any
+	if data != "" {
+		if err := json.Unmarshal([]byte(data), &value); err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+	}
+	return value
+}
+
+// names lists the names of the items of a list response.
+func names(list map[string]any) []any {
+	var out []any
+	items, _ := list["items"].([]any)
+	for _, item := range items {
+		out = append(out, item.(map[string]any)["name"])
+	}
+	return out
+}
+
+func TestServeCollections(t *testing.T) {
+	t.Parallel()
+	mocks := mock.NewMocks()
+	doc := load(t, petsSpec)
+	m := mocks.Get("pets", doc)
+
+	if got := names(decode(t, m, http.MethodGet, "/pets", "", http.StatusOK)); len(got) != 2 {
+		t.Fatalf("seeded pets = %v, want Rex and Tom", got)
+	}
+
+	resp := serve(m, http.MethodPost, "/pets", `{"name": "Kit"}`, nil)
+	if resp.StatusCode != http.StatusCreated || resp.Header.Get("Location") != "/mock/svc/pets/3" {
+		t.Errorf("POST: status %d, Location %q; want 201 and /mock/svc/pets/3",
+			resp.StatusCode, resp.Header.Get("Location"))
+	}
+	kit := decode(t, m, http.MethodGet, "/pets/3", "", http.StatusOK)
+	if kit["name"] != "Kit" || kit["status"] != "available" || kit["id"] != 3.0 {
+		t.Errorf("created pet = %v, want Kit with id 3 and the example's status", kit)
+	}
+
+	patched := decode(t, m, http.MethodPatch, "/pets/3", `{"status": null, "age": 2}`, http.StatusOK)
+	if _, has := patched["status"]; has || patched["age"] != 2.0 || patched["name"] != "Kit" {
+		t.Errorf("patched pet = %v, want Kit aged 2 without a status", patched)
+	}
+	replaced := decode(t, m, http.MethodPut, "/pets/1", `{"name": "Max", "id": 9}`, http.StatusOK)
+	if replaced["name"] != "Max" || replaced["id"] != 1.0 {
+		t.Errorf("replaced pet = %v, want Max keeping id 1", replaced)
+	}
+	decode(t, m, http.MethodDelete, "/pets/2", "", http.StatusNoContent)
+	missing := decode(t, m, http.MethodGet, "/pets/2", "", http.StatusNotFound)
+	if missing["message"] != "no such pet" {
+		t.Errorf("deleted pet = %v, want the 404 example", missing)
+	}
+
+	list := decode(t, m, http.MethodGet, "/pets", "", http.StatusOK)
+	if got := names(list); len(got) != 2 || got[0] != "Max" || got[1] != "Kit" {
+		t.Errorf("pets = %v, want [Max Kit]", got)
+	}
+	if list["total"] != 2.0 {
+		t.Errorf("list = %v, want the example's wrapping object", list)
+	}
+
+	if mocks.Get("pets", doc) != m {
+		t.Error("Get of an unchanged document returned a new Mock")
+	}
+	reloaded := mocks.Get("pets", load(t, petsSpec))
+	if got := names(decode(t, reloaded, http.MethodGet, "/pets", "", http.StatusOK)); len(got) != 2 || got[0] != "Max" {
+		t.Errorf("pets after the spec changed = %v, want the state kept", got)
+	}
+	mocks.Reset("pets")
+	if got := names(decode(t, m, http.MethodGet, "/pets", "", http.StatusOK)); len(got) != 2 || got[0] != "Rex" {
+		t.Errorf("pets after Reset = %v, want [Rex Tom]", got)
+	}
+}
+
+func TestServeCollectionsRejectsInvalidBodies(t *testing.T) {
+	t.Parallel()
+	m := mock.New(load(t, petsSpec))
+	resp := serve(m, http.MethodPost, "/pets", `["not", "an", "object"]`, nil)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", resp.StatusCode)
+	}
+}
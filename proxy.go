@@ -0,0 +1,393 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Hossein-Roshandel/webswags/contract"
+	"github.com/Hossein-Roshandel/webswags/discovery"
+	"github.com/Hossein-Roshandel/webswags/egress"
+)
+
+// setCORSHeaders sets CORS headers on the response writer.
+func setCORSHeaders(w http.ResponseWriter) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set(
+		"Access-Control-Allow-Methods",
+		"GET, POST, PUT, PATCH, DELETE, OPTIONS, HEAD, CONNECT, TRACE",
+	)
+	w.Header().Set(
+		"Access-Control-Allow-Headers",
+		"Content-Type, Authorization, X-Requested-With, Accept, X-API-Key, X-Custom-Header",
+	)
+}
+
+// handlePreflightRequest handles CORS preflight OPTIONS requests.
+func handlePreflightRequest(w http.ResponseWriter) {
+	setCORSHeaders(w)
+	w.Header().Set("Access-Control-Max-Age", "3600")
+	w.WriteHeader(http.StatusOK)
+}
+
+// copyRequestHeaders copies headers from the original request to the proxy request, excluding
+// Host and Accept-Encoding: the transport negotiates compression itself and decompresses the
+// response, so its body can be checked against the spec.
+func copyRequestHeaders(proxyReq *http.Request, originalReq *http.Request) {
+	for key, values := range originalReq.Header {
+		if key != "Host" && key != "Accept-Encoding" {
+			for _, value := range values {
+				proxyReq.Header.Add(key, value)
+			}
+		}
+	}
+}
+
+// copyQueryParameters adds the query parameters of the original request to those of the target
+// URL, excluding the 'url' parameter.
+func copyQueryParameters(proxyReq *http.Request, originalReq *http.Request) {
+	extra := originalReq.URL.Query()
+	extra.Del("url") // Remove the proxy URL parameter
+	if len(extra) == 0 {
+		return // Keep the target's query as it was encoded
+	}
+	query := proxyReq.URL.Query()
+	for key, values := range extra {
+		query[key] = append(query[key], values...)
+	}
+	proxyReq.URL.RawQuery = query.Encode()
+}
+
+// copyResponseHeaders copies headers from the proxy response to the response writer.
+func copyResponseHeaders(w http.ResponseWriter, resp *http.Response) {
+	for key, values := range resp.Header {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+}
+
+// streamResponseBody streams the response body from the proxy response to the response writer.
+func streamResponseBody(w http.ResponseWriter, resp *http.Response) error {
+	buf := make([]byte, proxyBufferSize)
+	for {
+		n, readErr := resp.Body.Read(buf)
+		if n > 0 {
+			if _, writeErr := w.Write(buf[:n]); writeErr != nil {
+				return fmt.Errorf("failed to write response: %w", writeErr)
+			}
+		}
+		if readErr != nil {
+			if errors.Is(readErr, io.EOF) {
+				break
+			}
+			return fmt.Errorf("failed to read response: %w", readErr)
+		}
+	}
+	return nil
+}
+
+// ProxyValidation is the outcome of checking a proxied request or its response against its spec.
+type ProxyValidation struct {
+	Spec       string               `json:"spec"`                // reference to the spec, from headerSpecRef
+	Operation  string               `json:"operation,omitempty"` // e.g. "GET /pets/{petId}"
+	Error      string               `json:"error,omitempty"`     // why the request could not be checked
+	Violations []contract.Violation `json:"violations"`
+}
+
+// Summary describes the outcome on one line, for headerValidation or headerResponseValidation.
+func (v *ProxyValidation) Summary() string {
+	switch {
+	case v.Error != "":
+		return "unchecked: " + strings.Join(strings.Fields(v.Error), " ")
+	case len(v.Violations) == 0:
+		return "valid: " + v.Operation
+	}
+	described := make([]string, 0, len(v.Violations))
+	for _, violation := range v.Violations {
+		described = append(described, violation.String())
+	}
+	return fmt.Sprintf("%d violation(s) of %s: %s", len(v.Violations), v.Operation, strings.Join(described, "; "))
+}
+
+// catalogServers keeps the servers of every spec in the catalog (see egress.ServerURLs), which
+// the proxy may fetch from. It is rebuilt when the catalog changes (see
+// discovery.Registry.OnRebuild), so specs of past revisions, which are not in the catalog, never
+// widen what the proxy may reach.
+type catalogServers struct {
+	mu      sync.RWMutex
+	servers []egress.Server
+}
+
+// rebuild lists the servers of every version of every service, in catalog order.
+func (c *catalogServers) rebuild(services []discovery.Service) {
+	var servers []egress.Server
+	for _, svc := range services {
+		for _, spec := range svc.Versions {
+			for _, u := range egress.ServerURLs(spec.DocV3) {
+				servers = append(servers, egress.Server{Service: spec.ServiceSlug, URL: u})
+			}
+		}
+	}
+
+	c.mu.Lock()
+	c.servers = servers
+	c.mu.Unlock()
+}
+
+// Servers returns the servers of the catalog. The returned slice is shared: do not modify it.
+func (c *catalogServers) Servers() []egress.Server {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.servers
+}
+
+// proxyTarget is the operation of its spec a proxied request is for.
+type proxyTarget struct {
+	ref  string // reference to the spec, from headerSpecRef
+	spec discovery.SwaggerSpec
+	op   *contract.Operation
+	err  error // why the operation is unknown
+}
+
+// findProxyTarget finds the operation of the spec ref names that proxyReq, the request the proxy
+// is about to send, is for. Requests the spec has no operation for, such as token requests of an
+// OAuth flow, get a target with an error: they cannot be checked, but are not violations either.
+func findProxyTarget(
+	registry *discovery.Registry,
+	validators *contract.Validators,
+	proxyReq *http.Request,
+	ref string,
+) *proxyTarget {
+	target := &proxyTarget{ref: ref}
+	target.spec, target.err = resolveSpecRef(proxyReq.Context(), registry, ref)
+	switch {
+	case target.err != nil:
+		return target
+	case target.spec.DocV3 == nil:
+		target.err = fmt.Errorf("%s could not be loaded as an OpenAPI document", ref)
+		return target
+	}
+	validator := validators.Get(target.spec.Slug, target.spec.DocV3)
+	target.op, target.err = validator.Find(proxyReq.Method, proxyReq.URL)
+	return target
+}
+
+// validation returns the outcome of a check of the target without violations.
+func (t *proxyTarget) validation() *ProxyValidation {
+	validation := &ProxyValidation{Spec: t.ref, Violations: []contract.Violation{}}
+	if t.err != nil {
+		validation.Error = t.err.Error()
+	} else {
+		validation.Operation = t.op.String()
+	}
+	return validation
+}
+
+// validateProxyRequest checks proxyReq against the operation of its target.
+func validateProxyRequest(target *proxyTarget, proxyReq *http.Request) *ProxyValidation {
+	validation := target.validation()
+	if target.op == nil {
+		return validation
+	}
+	if violations := target.op.ValidateRequest(proxyReq); len(violations) > 0 {
+		validation.Violations = violations
+		slog.Warn("Proxied request violates its spec", "spec", target.ref, "operation", validation.Operation,
+			"violations", len(violations))
+	}
+	return validation
+}
+
+// checkProxyResponse checks resp, the response to a proxied request, against the operation of its
+// target and records the outcome in drift. The body is buffered for the check, up to
+// proxyCheckLimit, and resp.Body replaced so it can still be streamed to the client. Bodies still
+// encoded (see identityEncoded) are not checked.
+func checkProxyResponse(drift *contract.DriftLog, target *proxyTarget, resp *http.Response) *ProxyValidation {
+	validation := target.validation()
+	if target.op == nil {
+		return validation
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, proxyCheckLimit+1))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+	if err != nil || len(body) > proxyCheckLimit || !identityEncoded(resp.Header) {
+		body = nil // not checked
+	}
+
+	violations := target.op.ValidateResponse(resp.StatusCode, resp.Header, body)
+	drift.Record(target.spec.ServiceSlug, target.spec.VersionKey, target.op, resp.StatusCode, violations)
+	if len(violations) > 0 {
+		validation.Violations = violations
+		slog.Warn("Proxied response violates its spec", "spec", target.ref, "operation", validation.Operation,
+			"status", resp.StatusCode, "violations", len(violations))
+	}
+	return validation
+}
+
+// identityEncoded reports whether a response body is sent as is, rather than compressed with an
+// encoding the transport did not undo, such as one the server uses unasked.
+func identityEncoded(header http.Header) bool {
+	encoding := strings.TrimSpace(header.Get("Content-Encoding"))
+	return encoding == "" || strings.EqualFold(encoding, "identity")
+}
+
+// writeProxyViolations answers a proxied request that violates its spec with 400 and the
+// violations, without forwarding it.
+func writeProxyViolations(w http.ResponseWriter, validation *ProxyValidation) {
+	setCORSHeaders(w)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(headerValidation, validation.Summary())
+	w.WriteHeader(http.StatusBadRequest)
+	if err := json.NewEncoder(w).Encode(validation); err != nil {
+		slog.Error("Failed to encode proxy validation", "error", err)
+	}
+}
+
+// handleProxy acts as a CORS proxy for API requests made from Swagger UI.
+// It forwards requests to the actual API servers, bypassing CORS restrictions.
+// Usage: /proxy?url={target-url}
+// Example: /proxy?url=https://testcertsapi.bpglobal.com/VEDAUTH/Authorize/OAuth
+//
+// Requests naming their spec in headerSpecRef, as those from Swagger UI do, are checked against
+// the operation they are for: with -proxy-validate, the request before it is forwarded (see
+// validateProxyRequest), and always the response, whose violations are recorded in drift (see
+// checkProxyResponse).
+//
+// Only targets policy permits are fetched, below the servers of the specs in the catalog or in
+// the allowlist (-proxy-allow), and only on public addresses unless the allowlist says otherwise;
+// redirects are held to the same policy. The service a request was made for is logged and
+// returned in headerService.
+func handleProxy(
+	registry *discovery.Registry,
+	mode string,
+	drift *contract.DriftLog,
+	policy *egress.Policy,
+	servers *catalogServers,
+) http.HandlerFunc {
+	validators := contract.NewValidators()
+	client := &http.Client{
+		Timeout:   proxyTimeout * time.Second,
+		Transport: policy.Transport(servers.Servers),
+		CheckRedirect: func(_ *http.Request, via []*http.Request) error {
+			if len(via) >= proxyRedirects {
+				return fmt.Errorf("stopped after %d redirects", proxyRedirects)
+			}
+			return nil
+		},
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Extract the target URL from query parameter
+		targetURL := r.URL.Query().Get("url")
+		if targetURL == "" {
+			http.Error(w, "Target URL is required. Usage: /proxy?url={target-url}", http.StatusBadRequest)
+			return
+		}
+
+		// Handle preflight OPTIONS request
+		if r.Method == http.MethodOptions {
+			handlePreflightRequest(w)
+			return
+		}
+
+		// Create the proxied request with context
+		proxyReq, err := http.NewRequestWithContext(r.Context(), r.Method, targetURL, r.Body)
+		if err != nil {
+			slog.Error("Failed to create proxy request", "error", err, "target_url", targetURL)
+			http.Error(w, "Failed to create proxy request", http.StatusInternalServerError)
+			return
+		}
+
+		// Copy headers and query parameters
+		copyRequestHeaders(proxyReq, r)
+		copyQueryParameters(proxyReq, r)
+		proxyReq.Header.Del(headerSpecRef)
+
+		// Find the operation the request is for and check the request against it
+		var (
+			target     *proxyTarget
+			validation *ProxyValidation
+		)
+		if ref := r.Header.Get(headerSpecRef); ref != "" {
+			target = findProxyTarget(registry, validators, proxyReq, ref)
+		}
+
+		// Refuse targets that are not below a server of the catalog or in the allowlist; the spec
+		// the request names only tells which service it is for
+		grant, err := policy.Permit(proxyReq.URL, servers.Servers())
+		if err != nil {
+			slog.Warn("Proxy target refused", "error", err, "target_url", targetURL)
+			setCORSHeaders(w)
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		service := grant.Service
+		if service == "" && target != nil {
+			service = target.spec.ServiceSlug
+		}
+
+		if target != nil && mode != validateOff {
+			validation = validateProxyRequest(target, proxyReq)
+			if mode == validateBlock && len(validation.Violations) > 0 {
+				writeProxyViolations(w, validation)
+				return
+			}
+		}
+
+		// Make the request
+		resp, err := client.Do(proxyReq)
+		switch {
+		case errors.Is(err, egress.ErrNotAllowed), errors.Is(err, egress.ErrPrivateAddress):
+			slog.Warn("Proxy target refused", "error", err, "target_url", targetURL, "service", service)
+			setCORSHeaders(w)
+			http.Error(w, fmt.Sprintf("Proxy request refused: %v", err), http.StatusForbidden)
+			return
+		case err != nil:
+			slog.Error("Proxy request failed", "error", err, "target_url", targetURL, "service", service)
+			http.Error(w, fmt.Sprintf("Proxy request failed: %v", err), http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+
+		// Check the response against the operation too
+		var checked *ProxyValidation
+		if target != nil {
+			checked = checkProxyResponse(drift, target, resp)
+		}
+
+		// Set CORS headers and copy response headers
+		setCORSHeaders(w)
+		copyResponseHeaders(w, resp)
+		if validation != nil {
+			w.Header().Set(headerValidation, validation.Summary())
+		}
+		if checked != nil {
+			w.Header().Set(headerResponseValidation, checked.Summary())
+		}
+		if service != "" {
+			w.Header().Set(headerService, service)
+		}
+
+		// Set status code
+		w.WriteHeader(resp.StatusCode)
+
+		// Stream the response body
+		if streamErr := streamResponseBody(w, resp); streamErr != nil {
+			slog.Error("Failed to write proxy response", "error", streamErr)
+			return
+		}
+
+		slog.Info("Proxy request completed", "method", r.Method, "target_url", targetURL, "status", resp.StatusCode,
+			"service", service)
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"github.com/Hossein-Roshandel/webswags/contract"
+	"github.com/Hossein-Roshandel/webswags/discovery"
+	"github.com/Hossein-Roshandel/webswags/egress"
+)
+
+const proxiedPet = `{"id": 1, "name": "Rex"}`
+
+// proxyUpstream serves proxiedPet at /pets/1, gzipped when the request accepts gzip, or
+// with encoding, which the test claims whatever the request accepts.
+func proxyUpstream(t *testing.T, encoding string) *httptest.Server {
+	t.Helper()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case encoding != "":
+			w.Header().Set("Content-Encoding", encoding)
+			_, _ = w.Write([]byte{0xce, 0xb2, 0x1b}) // not JSON
+		case strings.Contains(r.Header.Get("Accept-Encoding"), "gzip"):
+			w.Header().Set("Content-Encoding", "gzip")
+			gz := gzip.NewWriter(w)
+			_, _ = gz.Write([]byte(proxiedPet))
+			_ = gz.Close()
+		default:
+			_, _ = w.Write([]byte(proxiedPet))
+		}
+	}))
+	t.Cleanup(upstream.Close)
+	return upstream
+}
+
+// proxySpec returns a spec of the pets API served at serverURL.
+func proxySpec(serverURL string) string {
+	return `openapi: 3.0.3
+info: {title: Pets, version: "1"}
+servers:
+  - url: ` + serverURL + `
+paths:
+  /pets/{petId}:
+    get:
+      parameters:
+        - {name: petId, in: path, required: true, schema: {type: integer}}
+      responses:
+        "200":
+          description: A pet
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [id, name]
+                properties:
+                  id: {type: integer}
+                  name: {type: string}
+`
+}
+
+// proxyRegistry returns a registry of a spec of the pets API served at serverURL, and the servers
+// of its catalog.
+func proxyRegistry(t *testing.T, serverURL string) (*discovery.Registry, *catalogServers) {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "pets.yaml"), []byte(proxySpec(serverURL)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return proxyCatalog(t, root)
+}
+
+// proxyCatalog returns a registry of the specs under root, and the servers of its catalog.
+func proxyCatalog(t *testing.T, root string) (*discovery.Registry, *catalogServers) {
+	t.Helper()
+	registry := discovery.NewRegistry(root, discovery.DiscoverOptions{})
+	servers := &catalogServers{}
+	registry.OnRebuild(servers.rebuild)
+	if err := registry.Load(t.Context()); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return registry, servers
+}
+
+// commitFile writes data to name in the working tree of the repository at dir and commits it.
+func commitFile(t *testing.T, dir, name, data string) plumbing.Hash {
+	t.Helper()
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.Add(name); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := wt.Commit("Update "+name, &git.CommitOptions{
+		Author: &object.Signature{Name: "Tester", Email: "tester@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func TestProxyChecksCompressedResponses(t *testing.T) {
+	t.Parallel()
+	loopback, err := egress.ParseRule("127.0.0.0/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name         string
+		encoding     string // forced by the upstream
+		wantEncoding string
+		wantBody     string
+	}{
+		{name: "gzip negotiated", wantBody: proxiedPet},
+		{name: "unknown encoding", encoding: "br", wantEncoding: "br"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			upstream := proxyUpstream(t, tt.encoding)
+			drift := contract.NewDriftLog()
+			registry, servers := proxyRegistry(t, upstream.URL)
+			handler := handleProxy(registry, validateOff, drift, egress.NewPolicy([]egress.Rule{loopback}), servers)
+
+			req := httptest.NewRequest(http.MethodGet, "/proxy?url="+url.QueryEscape(upstream.URL+"/pets/1"), nil)
+			req.Header.Set("Accept-Encoding", "gzip, deflate, br")
+			req.Header.Set(headerSpecRef, "pets")
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			resp := w.Result()
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want 200; body %q", resp.StatusCode, w.Body.String())
+			}
+			if got := resp.Header.Get(headerResponseValidation); got != "valid: GET /pets/{petId}" {
+				t.Errorf("%s = %q, want the response to be valid", headerResponseValidation, got)
+			}
+			if entries := drift.Entries("pets"); len(entries) != 0 {
+				t.Errorf("drift = %+v, want none", entries)
+			}
+			if got := resp.Header.Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Errorf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestProxyAllowsCatalogServersOnly(t *testing.T) {
+	t.Parallel()
+	current, past := proxyUpstream(t, ""), proxyUpstream(t, "")
+	root := t.TempDir()
+	if _, err := git.PlainInit(root, false); err != nil {
+		t.Fatal(err)
+	}
+	first := commitFile(t, root, "pets.yaml", proxySpec(past.URL))
+	commitFile(t, root, "pets.yaml", proxySpec(current.URL))
+	registry, servers := proxyCatalog(t, root)
+	handler := handleProxy(registry, validateOff, contract.NewDriftLog(), egress.NewPolicy(nil), servers)
+
+	tests := []struct {
+		name    string
+		target  string
+		wantErr string
+	}{
+		// Loopback servers are refused when connecting; permitted ones get that far.
+		{name: "server of the catalog", target: current.URL, wantErr: egress.ErrPrivateAddress.Error()},
+		{name: "server of a past revision", target: past.URL, wantErr: egress.ErrNotAllowed.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequest(http.MethodGet, "/proxy?url="+url.QueryEscape(tt.target+"/pets/1"), nil)
+			req.Header.Set(headerSpecRef, "pets@"+first.String())
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), tt.wantErr) {
+				t.Errorf("status = %d, body %q; want 403 saying %q", w.Code, w.Body.String(), tt.wantErr)
+			}
+		})
+	}
+}
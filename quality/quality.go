@@ -0,0 +1,141 @@
+// Package quality rolls the documentation metrics of a spec (discovery.Quality) and its lint
+// findings up into a score from 0 to 100 and a letter grade, with the operations that hold it
+// back, so platform owners can see which contracts need work and teams know what to fix.
+package quality
+
+import (
+	"fmt"
+
+	"github.com/Hossein-Roshandel/webswags/discovery"
+	"github.com/Hossein-Roshandel/webswags/lint"
+)
+
+// Weights of the checks in the overall score. Checks that do not apply (a spec without
+// operations has nothing to describe) are left out and the others weigh proportionally more.
+const (
+	weightDescriptions   = 25
+	weightExamples       = 20
+	weightErrorResponses = 20
+	weightSecurity       = 20
+	weightLint           = 15
+)
+
+// Points the lint check loses per finding, down to 0.
+const (
+	lintErrorCost   = 10
+	lintWarningCost = 2
+)
+
+// Lowest scores of each grade; anything below gradeD is an F.
+const (
+	gradeA = 90
+	gradeB = 75
+	gradeC = 60
+	gradeD = 40
+)
+
+// fullScore is the score of a check everything passes.
+const fullScore = 100
+
+// Check is one component of a Scorecard.
+type Check struct {
+	ID      string   `json:"id"`
+	Title   string   `json:"title"`
+	Weight  int      `json:"weight"`
+	Score   int      `json:"score"`             // 0–100
+	Passed  int      `json:"passed"`            // operations that pass; for lint, 0
+	Total   int      `json:"total"`             // operations checked; for lint, 0
+	Summary string   `json:"summary"`           // e.g. "12 of 15 operations"
+	Missing []string `json:"missing,omitempty"` // operations that fail the check
+}
+
+// Scorecard is the quality score of one spec.
+type Scorecard struct {
+	Score      int         `json:"score"` // weighted average of the checks, 0–100
+	Grade      string      `json:"grade"` // A to F
+	Operations int         `json:"operations"`
+	Deprecated []string    `json:"deprecated"` // deprecated operations, listed but not penalised
+	Checks     []Check     `json:"checks"`
+	Lint       lint.Result `json:"lint"`
+}
+
+// Score computes the scorecard of spec, whose lint result is findings. Specs that could not be
+// loaded (no spec.Quality) score 0.
+func Score(spec discovery.SwaggerSpec, findings lint.Result) Scorecard {
+	card := Scorecard{Grade: grade(0), Deprecated: []string{}, Checks: []Check{}, Lint: findings}
+	q := spec.Quality
+	if q == nil {
+		return card
+	}
+	card.Operations = q.Operations
+
+	coverage := []struct {
+		id, title string
+		weight    int
+		passes    func(discovery.OperationQuality) bool
+	}{
+		{"descriptions", "Operations with a description", weightDescriptions,
+			func(op discovery.OperationQuality) bool { return op.Described }},
+		{"examples", "Operations with examples", weightExamples,
+			func(op discovery.OperationQuality) bool { return op.HasExamples }},
+		{"error-responses", "Operations documenting error responses", weightErrorResponses,
+			func(op discovery.OperationQuality) bool { return op.HasErrorResponse }},
+		{"security", "Operations with declared security", weightSecurity,
+			func(op discovery.OperationQuality) bool { return op.Secured }},
+	}
+	for _, c := range coverage {
+		if q.Operations == 0 {
+			continue
+		}
+		check := Check{ID: c.id, Title: c.title, Weight: c.weight, Total: q.Operations}
+		for _, op := range q.OperationDetails {
+			if c.passes(op) {
+				check.Passed++
+			} else {
+				check.Missing = append(check.Missing, op.Operation)
+			}
+		}
+		check.Score = (fullScore*check.Passed + check.Total/2) / check.Total //nolint:mnd // rounds to nearest
+		check.Summary = fmt.Sprintf("%d of %d operations", check.Passed, check.Total)
+		card.Checks = append(card.Checks, check)
+	}
+	for _, op := range q.OperationDetails {
+		if op.Deprecated {
+			card.Deprecated = append(card.Deprecated, op.Operation)
+		}
+	}
+
+	card.Checks = append(card.Checks, Check{
+		ID:     "lint",
+		Title:  "Lint findings",
+		Weight: weightLint,
+		Score:  max(0, fullScore-lintErrorCost*findings.Errors-lintWarningCost*findings.Warnings),
+		Summary: fmt.Sprintf("%d error(s), %d warning(s), %d info(s)",
+			findings.Errors, findings.Warnings, findings.Infos),
+	})
+
+	weighted, weights := 0, 0
+	for _, check := range card.Checks {
+		weighted += check.Weight * check.Score
+		weights += check.Weight
+	}
+	card.Score = (weighted + weights/2) / weights //nolint:mnd // rounds to nearest
+	card.Grade = grade(card.Score)
+	return card
+}
+
+// grade turns a score into a letter.
+func grade(score int) string {
+	switch {
+	case score >= gradeA:
+		return "A"
+	case score >= gradeB:
+		return "B"
+	case score >= gradeC:
+		return "C"
+	case score >= gradeD:
+		return "D"
+	default:
+		return "F"
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/Hossein-Roshandel/webswags/diff"
+	"github.com/Hossein-Roshandel/webswags/discovery"
+)
+
+// errSpecNotFound is returned for spec references that match no spec.
+var errSpecNotFound = errors.New("no such spec")
+
+// HistoryReport is the /api/specs/{service}/history response.
+type HistoryReport struct {
+	Service   string         `json:"service"`
+	Slug      string         `json:"slug"`
+	Version   string         `json:"version"`
+	Path      string         `json:"path"`
+	Revisions []RevisionInfo `json:"revisions"`
+}
+
+// RevisionInfo is one commit of a spec's history, with the URLs showing the spec as it was then.
+type RevisionInfo struct {
+	discovery.Revision
+
+	PageURL string `json:"pageUrl"`
+	SpecURL string `json:"specUrl"`
+}
+
+// DiffSide identifies one of the specs compared by /api/diff.
+type DiffSide struct {
+	Ref      string              `json:"ref"`
+	Service  string              `json:"service"`
+	Slug     string              `json:"slug"`
+	Version  string              `json:"version"`
+	Path     string              `json:"path"`
+	Revision *discovery.Revision `json:"revision,omitempty"`
+	PageURL  string              `json:"pageUrl,omitempty"`
+}
+
+// newDiffSide describes spec, which ref resolved to.
+func newDiffSide(ref string, spec discovery.SwaggerSpec) DiffSide {
+	return DiffSide{
+		Ref:      ref,
+		Service:  spec.Service,
+		Slug:     spec.Slug,
+		Version:  spec.VersionKey,
+		Path:     spec.Path,
+		Revision: spec.Revision,
+	}
+}
+
+// DiffReport is the /api/diff response: the changes from one spec to another.
+type DiffReport struct {
+	From DiffSide `json:"from"`
+	To   DiffSide `json:"to"`
+	diff.Report
+}
+
+// DiffData is the data for the diff page.
+type DiffData struct {
+	From   string
+	To     string
+	Refs   []string // suggestions for the inputs: every service and version
+	Error  string
+	Report *DiffReport
+}
+
+// resolveSpecRef resolves a spec reference as used by the diff endpoints:
+// "service[/v/version][@rev]", where service is a service slug, spec slug or service name and
+// rev a git commit, tag or branch, e.g. "orders/v/2@v2.1.0". Page paths ("/service/...") work too.
+func resolveSpecRef(ctx context.Context, registry *discovery.Registry, ref string) (discovery.SwaggerSpec, error) {
+	name, rev, _ := strings.Cut(strings.TrimPrefix(ref, "/service/"), "@")
+	service, version, _ := strings.Cut(strings.Trim(name, "/"), "/v/")
+
+	_, spec, found := findSpec(registry, service, version)
+	if !found && version == "" {
+		spec, found = registry.LookupByName(service)
+	}
+	if !found {
+		return discovery.SwaggerSpec{}, fmt.Errorf("%w: %q", errSpecNotFound, ref)
+	}
+	if rev == "" {
+		return spec, nil
+	}
+	return registry.SpecAt(ctx, spec, rev)
+}
+
+// diffSpecs compares the specs two references (see resolveSpecRef) name. On failure, it also
+// returns the HTTP status describing the problem.
+func diffSpecs(ctx context.Context, registry *discovery.Registry, from, to string) (DiffReport, int, error) {
+	if from == "" || to == "" {
+		return DiffReport{}, http.StatusBadRequest,
+			errors.New("both from and to are required, e.g. from=orders@v1.0&to=orders")
+	}
+
+	var report DiffReport
+	docs := make([]discovery.SwaggerSpec, 0, 2) //nolint:mnd // from and to
+	for _, side := range []struct {
+		ref  string
+		into *DiffSide
+	}{{from, &report.From}, {to, &report.To}} {
+		spec, err := resolveSpecRef(ctx, registry, side.ref)
+		switch {
+		case errors.Is(err, errSpecNotFound), errors.Is(err, discovery.ErrNoHistory),
+			errors.Is(err, discovery.ErrUnknownRevision):
+			return DiffReport{}, http.StatusNotFound, err
+		case err != nil:
+			return DiffReport{}, http.StatusInternalServerError, err
+		case spec.DocV3 == nil:
+			return DiffReport{}, http.StatusUnprocessableEntity,
+				fmt.Errorf("%s could not be loaded as an OpenAPI document", side.ref)
+		}
+		*side.into = newDiffSide(side.ref, spec)
+		side.into.PageURL = servicePageURL(discovery.Service{Slug: spec.ServiceSlug}, spec)
+		docs = append(docs, spec)
+	}
+
+	report.Report = diff.Compare(docs[0].DocV3, docs[1].DocV3)
+	return report, http.StatusOK, nil
+}
+
+// handleHistory lists the git commits that changed a spec's files, newest first, with links
+// to the spec as it was at each of them.
+func handleHistory(registry *discovery.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		svc, spec, ok := lookupSpec(registry, w, r)
+		if !ok {
+			return
+		}
+
+		revisions, err := spec.History(r.Context())
+		if errors.Is(err, discovery.ErrNoHistory) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to read spec history", "service", spec.Slug, "error", err)
+			http.Error(w, "Failed to read spec history", http.StatusInternalServerError)
+			return
+		}
+
+		report := HistoryReport{
+			Service:   spec.Service,
+			Slug:      spec.Slug,
+			Version:   spec.VersionKey,
+			Path:      spec.Path,
+			Revisions: make([]RevisionInfo, 0, len(revisions)),
+		}
+		for _, rev := range revisions {
+			at := spec
+			at.Revision = &rev
+			report.Revisions = append(report.Revisions, RevisionInfo{
+				Revision: rev,
+				PageURL:  servicePageURL(svc, at),
+				SpecURL:  specFileURL(svc, at, spec.Format),
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if encodeErr := json.NewEncoder(w).Encode(report); encodeErr != nil {
+			slog.Error("Failed to encode history", "service", spec.Slug, "error", encodeErr)
+			http.Error(w, "Failed to encode history", http.StatusInternalServerError)
+		}
+	}
+}
+
+// handleDiff compares two specs (?from=...&to=..., see resolveSpecRef) and lists every change,
+// classified as breaking or non-breaking.
+func handleDiff(registry *discovery.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		report, status, err := diffSpecs(r.Context(), registry, query.Get("from"), query.Get("to"))
+		if err != nil {
+			if status == http.StatusInternalServerError {
+				slog.Error("Failed to compare specs", "from", query.Get("from"), "to", query.Get("to"), "error", err)
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if encodeErr := json.NewEncoder(w).Encode(report); encodeErr != nil {
+			slog.Error("Failed to encode diff", "error", encodeErr)
+			http.Error(w, "Failed to encode diff", http.StatusInternalServerError)
+		}
+	}
+}
+
+// handleDiffPage renders the changes between two specs, with a form to pick them.
+func handleDiffPage(registry *discovery.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		data := DiffData{From: query.Get("from"), To: query.Get("to")}
+		for _, svc := range registry.Services() {
+			data.Refs = append(data.Refs, svc.Slug)
+			if len(svc.Versions) > 1 {
+				for _, v := range svc.Versions {
+					data.Refs = append(data.Refs, svc.Slug+"/v/"+v.VersionKey)
+				}
+			}
+		}
+		if data.From != "" || data.To != "" {
+			report, _, err := diffSpecs(r.Context(), registry, data.From, data.To)
+			if err != nil {
+				data.Error = err.Error()
+			} else {
+				data.Report = &report
+			}
+		}
+
+		renderPage(w, "diff.html", data)
+	}
+}
@@ -0,0 +1,190 @@
+// Package search is a small in-memory inverted index for the catalog's full-text search.
+// Documents are added as weighted text fields; queries match every term against whole words
+// or word prefixes, so "loyal point" finds a "loyaltyPoints" property.
+package search
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Weights of a field: how much a match in it counts towards a document's score.
+const (
+	WeightTitle = 8 // names: titles, paths, operationIds, schema names
+	WeightTerm  = 4 // identifiers: parameter and property names, tags
+	WeightText  = 1 // prose: summaries and descriptions
+)
+
+// minTermLength is the shortest query term matched as a prefix; shorter ones must match a whole word.
+const minTermLength = 3
+
+// Field is a piece of text of a document with its weight.
+type Field struct {
+	Text   string
+	Weight int
+}
+
+// Match is a document that matches a query, as the number Builder.Add returned for it.
+type Match struct {
+	Doc   int
+	Score int
+}
+
+// posting records that a word occurs in a document, with the highest weight it has there.
+type posting struct {
+	doc    int
+	weight int
+}
+
+// Builder collects documents into an Index.
+type Builder struct {
+	postings map[string][]posting
+	docs     int
+}
+
+// NewBuilder returns an empty Builder.
+func NewBuilder() *Builder {
+	return &Builder{postings: make(map[string][]posting)}
+}
+
+// Add indexes a document made of fields and returns its number, counting from 0.
+func (b *Builder) Add(fields ...Field) int {
+	doc := b.docs
+	b.docs++
+	weights := make(map[string]int)
+	for _, field := range fields {
+		for _, word := range Tokenize(field.Text) {
+			weights[word] = max(weights[word], field.Weight)
+		}
+	}
+	for word, weight := range weights {
+		b.postings[word] = append(b.postings[word], posting{doc: doc, weight: weight})
+	}
+	return doc
+}
+
+// Build returns the Index of the documents added so far. The Index is immutable and safe for
+// concurrent use.
+func (b *Builder) Build() *Index {
+	words := make([]string, 0, len(b.postings))
+	for word := range b.postings {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	return &Index{postings: b.postings, words: words, docs: b.docs}
+}
+
+// Index is an inverted index from words to the documents containing them.
+type Index struct {
+	postings map[string][]posting
+	words    []string // sorted, for prefix lookups
+	docs     int
+}
+
+// Len returns the number of documents in the index.
+func (ix *Index) Len() int {
+	if ix == nil {
+		return 0
+	}
+	return ix.docs
+}
+
+// Search returns the documents matching every term of query, best first. A term matches a
+// word exactly, at full weight, or as a prefix of it, at half weight. Ties keep the order in
+// which documents were added.
+func (ix *Index) Search(query string) []Match {
+	terms := Tokenize(query)
+	if ix == nil || len(terms) == 0 {
+		return nil
+	}
+
+	var scores map[int]int
+	for _, term := range slices.Compact(slices.Sorted(slices.Values(terms))) {
+		termScores := ix.lookup(term)
+		if scores == nil {
+			scores = termScores
+		} else {
+			for doc, score := range scores {
+				if termScore, ok := termScores[doc]; ok {
+					scores[doc] = score + termScore
+				} else {
+					delete(scores, doc)
+				}
+			}
+		}
+		if len(scores) == 0 {
+			return nil
+		}
+	}
+
+	matches := make([]Match, 0, len(scores))
+	for doc, score := range scores {
+		matches = append(matches, Match{Doc: doc, Score: score})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Doc < matches[j].Doc
+	})
+	return matches
+}
+
+// lookup scores the documents matching one term: the best weight of a word it matches.
+func (ix *Index) lookup(term string) map[int]int {
+	scores := make(map[int]int)
+	for _, p := range ix.postings[term] {
+		scores[p.doc] = max(scores[p.doc], 2*p.weight) //nolint:mnd // exact matches count double
+	}
+	if len(term) < minTermLength {
+		return scores
+	}
+	for i := sort.SearchStrings(ix.words, term); i < len(ix.words) && strings.HasPrefix(ix.words[i], term); i++ {
+		if ix.words[i] == term {
+			continue
+		}
+		for _, p := range ix.postings[ix.words[i]] {
+			scores[p.doc] = max(scores[p.doc], p.weight)
+		}
+	}
+	return scores
+}
+
+// Tokenize splits text into lowercase words at anything other than letters and digits, and
+// within identifiers at camelCase humps, keeping the whole identifier too: "loyaltyPoints" is
+// "loyaltypoints", "loyalty" and "points".
+func Tokenize(text string) []string {
+	var words []string
+	for _, token := range strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		lower := strings.ToLower(token)
+		words = append(words, lower)
+		if parts := splitCamel(token); len(parts) > 1 {
+			for _, part := range parts {
+				words = append(words, strings.ToLower(part))
+			}
+		}
+	}
+	return words
+}
+
+// splitCamel splits an identifier before each uppercase letter that starts a new word:
+// "getHTTPStatus" is "get", "HTTP" and "Status".
+func splitCamel(token string) []string {
+	runes := []rune(token)
+	var parts []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		if !unicode.IsUpper(runes[i]) {
+			continue
+		}
+		if !unicode.IsUpper(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			parts = append(parts, string(runes[start:i]))
+			start = i
+		}
+	}
+	return append(parts, string(runes[start:]))
+}
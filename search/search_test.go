@@ -0,0 +1,76 @@
+package search_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Hossein-Roshandel/webswags/search"
+)
+
+func TestTokenize(t *testing.T) {
+	t.Parallel()
+	tests := map[string][]string{
+		"loyaltyPoints":     {"loyaltypoints", "loyalty", "points"},
+		"getHTTPStatus":     {"gethttpstatus", "get", "http", "status"},
+		"GET /pets/{petId}": {"get", "pets", "petid", "pet", "id"},
+		"v2_api, Ünïcode":   {"v2", "api", "ünïcode"},
+		"":                  nil,
+	}
+	for text, want := range tests {
+		if got := search.Tokenize(text); !slices.Equal(got, want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestIndexSearch(t *testing.T) {
+	t.Parallel()
+	b := search.NewBuilder()
+	b.Add(search.Field{Text: "Loyalty program", Weight: search.WeightTitle},
+		search.Field{Text: "loyaltyPoints", Weight: search.WeightTerm})
+	b.Add(search.Field{Text: "Points of sale", Weight: search.WeightText})
+	b.Add(search.Field{Text: "Pets", Weight: search.WeightTitle})
+	b.Add(search.Field{Text: "Pets", Weight: search.WeightTitle})
+	index := b.Build()
+
+	tests := []struct {
+		query string
+		want  []search.Match
+	}{
+		{
+			query: "points", // exact matches count double
+			want:  []search.Match{{Doc: 0, Score: 2 * search.WeightTerm}, {Doc: 1, Score: 2 * search.WeightText}},
+		},
+		{
+			query: "loyal point", // prefixes at their weight, every term must match
+			want:  []search.Match{{Doc: 0, Score: search.WeightTitle + search.WeightTerm}},
+		},
+		{
+			query: "LOYALTY loyalty", // repeated terms count once
+			want:  []search.Match{{Doc: 0, Score: 2 * search.WeightTitle}},
+		},
+		{
+			query: "pets", // ties keep the order of Add
+			want:  []search.Match{{Doc: 2, Score: 2 * search.WeightTitle}, {Doc: 3, Score: 2 * search.WeightTitle}},
+		},
+		{query: "po"}, // too short to match as a prefix
+		{query: "pets points"},
+		{query: "  "},
+	}
+	for _, tt := range tests {
+		if got := index.Search(tt.query); !slices.Equal(got, tt.want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+	if index.Len() != 4 {
+		t.Errorf("Len() = %d, want 4", index.Len())
+	}
+}
+
+func TestNilIndex(t *testing.T) {
+	t.Parallel()
+	var index *search.Index
+	if index.Len() != 0 || index.Search("pets") != nil {
+		t.Error("a nil Index is not empty")
+	}
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/Hossein-Roshandel/webswags/discovery"
+	"github.com/Hossein-Roshandel/webswags/lint"
+)
+
+const petsYAML = `openapi: 3.0.3
+info: {title: Pets, version: "1.0.0"}
+paths: {}
+`
+
+// specsRegistry returns a loaded registry of files (name → data) written below a temporary root.
+func specsRegistry(t *testing.T, files map[string]string) *discovery.Registry {
+	t.Helper()
+	root := t.TempDir()
+	for name, data := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	registry := discovery.NewRegistry(root, discovery.DiscoverOptions{})
+	if err := registry.Load(t.Context()); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return registry
+}
+
+// serve calls handler for a GET of target with the route variables vars and, unless empty, an
+// Accept header.
+func serve(handler http.HandlerFunc, target string, vars map[string]string, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	handler(w, mux.SetURLVars(req, vars))
+	return w
+}
+
+func TestNegotiateFormat(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		accept   string
+		fallback string
+		want     string
+	}{
+		{accept: "", fallback: yamlFormat, want: yamlFormat},
+		{accept: "*/*", fallback: jsonFormat, want: jsonFormat},
+		{accept: "application/json", fallback: yamlFormat, want: jsonFormat},
+		{accept: "application/vnd.oai.openapi", fallback: jsonFormat, want: yamlFormat},
+		{accept: "text/html, application/yaml", fallback: jsonFormat, want: yamlFormat},
+		{accept: "application/json;q=0.5, application/yaml;q=0.9", fallback: jsonFormat, want: yamlFormat},
+		{accept: "application/yaml;q=0.1, application/json", fallback: yamlFormat, want: jsonFormat},
+		{accept: "text/html", fallback: yamlFormat, want: yamlFormat},
+	}
+	for _, tt := range tests {
+		if got := negotiateFormat(tt.accept, tt.fallback); got != tt.want {
+			t.Errorf("negotiateFormat(%q, %q) = %q, want %q", tt.accept, tt.fallback, got, tt.want)
+		}
+	}
+}
+
+func TestSwaggerFileServesEitherFormat(t *testing.T) {
+	t.Parallel()
+	handler := handleSwaggerFile(specsRegistry(t, map[string]string{"pets.yaml": petsYAML}))
+	vars := map[string]string{"service": "pets"}
+
+	tests := []struct {
+		path     string
+		accept   string
+		wantType string
+	}{
+		{path: "/api/specs/pets/swagger.yaml", wantType: "text/yaml"},
+		{path: "/api/specs/pets/swagger.json", wantType: "application/json"},
+		{path: "/api/specs/pets/swagger.json", accept: "application/yaml", wantType: "application/json"},
+		{path: "/api/specs/pets/swagger", wantType: "text/yaml"},
+		{path: "/api/specs/pets/swagger", accept: "application/json", wantType: "application/json"},
+	}
+	for _, tt := range tests {
+		w := serve(handler, tt.path, vars, tt.accept)
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != tt.wantType {
+			t.Errorf("GET %s (Accept %q) = %d %s, want 200 %s",
+				tt.path, tt.accept, w.Code, w.Header().Get("Content-Type"), tt.wantType)
+			continue
+		}
+		if tt.wantType == "text/yaml" {
+			if w.Body.String() != petsYAML {
+				t.Errorf("GET %s = %q, want the file as written", tt.path, w.Body)
+			}
+			continue
+		}
+		var doc struct {
+			OpenAPI string `json:"openapi"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil || doc.OpenAPI != "3.0.3" {
+			t.Errorf("GET %s = %q, %v; want the spec as JSON", tt.path, w.Body, err)
+		}
+	}
+
+	if w := serve(handler, "/api/specs/pets/swagger", vars, ""); !strings.Contains(w.Header().Get("Vary"), "Accept") {
+		t.Errorf("negotiated response varies by %q, want Accept", w.Header().Get("Vary"))
+	}
+}
+
+func TestSpecFilesServesReferencedFilesOnly(t *testing.T) {
+	t.Parallel()
+	registry := specsRegistry(t, map[string]string{
+		"orders/openapi.yaml": `openapi: 3.0.3
+info: {title: Orders, version: "1.0.0"}
+paths: {}
+components:
+  schemas:
+    Order: {$ref: "./schemas/order.yaml"}
+`,
+		"orders/schemas/order.yaml": "type: object\n",
+		"orders/secret.yaml":        "token: hunter2\n",
+	})
+	handler := handleSpecFiles(registry)
+
+	tests := []struct {
+		file     string
+		wantCode int
+	}{
+		{file: "orders/openapi.yaml", wantCode: http.StatusOK},
+		{file: "orders/schemas/order.yaml", wantCode: http.StatusOK},
+		{file: "orders/secret.yaml", wantCode: http.StatusNotFound},
+		{file: "orders/schemas/../secret.yaml", wantCode: http.StatusNotFound},
+		{file: "../orders/openapi.yaml", wantCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		vars := map[string]string{"service": "orders", "file": tt.file}
+		w := serve(handler, "/api/specs/orders/files/"+tt.file, vars, "")
+		if w.Code != tt.wantCode {
+			t.Errorf("GET files/%s = %d, want %d", tt.file, w.Code, tt.wantCode)
+		}
+	}
+}
+
+func TestDiagnosticsReportsHealth(t *testing.T) {
+	t.Parallel()
+	registry := specsRegistry(t, map[string]string{
+		"pets.yaml":  petsYAML,
+		"store.yaml": "openapi: 3.0.3\ninfo: {title: Store}\npaths: {}\n",
+	})
+	handler := handleDiagnostics(registry)
+
+	tests := []struct {
+		service    string
+		wantHealth string
+		wantErrors int
+	}{
+		{service: "pets", wantHealth: discovery.HealthValid},
+		{service: "store", wantHealth: discovery.HealthErrors, wantErrors: 1},
+	}
+	for _, tt := range tests {
+		vars := map[string]string{"service": tt.service}
+		w := serve(handler, "/api/specs/"+tt.service+"/diagnostics", vars, "")
+		var report DiagnosticsReport
+		if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+			t.Fatalf("GET %s diagnostics = %d %q: %v", tt.service, w.Code, w.Body, err)
+		}
+		if report.Health != tt.wantHealth || report.Errors != tt.wantErrors ||
+			len(report.Diagnostics) != tt.wantErrors {
+			t.Errorf("%s: health %s with %d errors (%+v), want %s with %d",
+				tt.service, report.Health, report.Errors, report.Diagnostics, tt.wantHealth, tt.wantErrors)
+		}
+	}
+}
+
+func TestDiscoveryReportEndpoint(t *testing.T) {
+	t.Parallel()
+	registry := specsRegistry(t, map[string]string{"pets.yaml": petsYAML, "package.json": `{"name": "apis"}`})
+	w := serve(handleDiscoveryReport(registry), "/api/discovery/report", nil, "")
+
+	var report discovery.DiscoveryReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("GET /api/discovery/report = %d %q: %v", w.Code, w.Body, err)
+	}
+	if report.Accepted != 1 || report.Rejected != 1 || len(report.Entries) != 2 {
+		t.Errorf("report = %+v, want pets.yaml accepted and package.json rejected", report)
+	}
+
+	page := serve(handleDiscoveryPage(registry), "/discovery", nil, "")
+	if page.Code != http.StatusOK || !strings.Contains(page.Body.String(), "package.json") {
+		t.Errorf("GET /discovery = %d, want a page listing package.json", page.Code)
+	}
+}
+
+func TestOperationsFilters(t *testing.T) {
+	t.Parallel()
+	registry := specsRegistry(t, map[string]string{
+		"pets.yaml": `openapi: 3.0.3
+info: {title: Pets, version: "1.0.0"}
+paths:
+  /pets:
+    get: {operationId: listPets, tags: [pets], responses: {"200": {description: OK}}}
+    post: {operationId: createPet, tags: [pets, admin], responses: {"201": {description: Created}}}
+  /admin/pets:
+    delete: {operationId: purgePets, tags: [admin], responses: {"204": {description: Gone}}}
+`,
+		"store.yaml": `openapi: 3.0.3
+info: {title: Store, version: "1.0.0"}
+paths:
+  /orders:
+    get: {operationId: listOrders, responses: {"200": {description: OK}}}
+`,
+	})
+	handler := handleOperations(registry)
+
+	tests := []struct {
+		query    string
+		wantIDs  []string
+		wantCode int
+	}{
+		{query: "", wantIDs: []string{"purgePets", "listPets", "createPet", "listOrders"}},
+		{query: "service=store", wantIDs: []string{"listOrders"}},
+		{query: "tag=ADMIN", wantIDs: []string{"purgePets", "createPet"}},
+		{query: "method=get", wantIDs: []string{"listPets", "listOrders"}},
+		{query: "path=/pets", wantIDs: []string{"listPets", "createPet"}},
+		{query: "service=pets&tag=pets&method=post", wantIDs: []string{"createPet"}},
+		{query: "service=nope", wantCode: http.StatusNotFound},
+		{query: "version=1.0.0", wantCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := serve(handler, "/api/operations?"+tt.query, nil, "")
+		if tt.wantCode != 0 {
+			if w.Code != tt.wantCode {
+				t.Errorf("GET ?%s = %d, want %d", tt.query, w.Code, tt.wantCode)
+			}
+			continue
+		}
+		var report OperationsReport
+		if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+			t.Fatalf("GET ?%s = %d %q: %v", tt.query, w.Code, w.Body, err)
+		}
+		var ids []string
+		for _, op := range report.Operations {
+			ids = append(ids, op.OperationID)
+		}
+		if !slices.Equal(ids, tt.wantIDs) || report.Count != len(tt.wantIDs) {
+			t.Errorf("GET ?%s = %d operations %q, want %q", tt.query, report.Count, ids, tt.wantIDs)
+		}
+	}
+}
+
+func TestPagesRender(t *testing.T) {
+	t.Parallel()
+	registry := specsRegistry(t, map[string]string{"pets.yaml": petsYAML})
+	ruleset, err := lint.ParseRuleset(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scores := newCatalogQuality(ruleset)
+	scores.rebuild(registry.Services())
+	vars := map[string]string{"service": "pets"}
+
+	tests := []struct {
+		target  string
+		handler http.HandlerFunc
+		vars    map[string]string
+		want    string
+	}{
+		{target: "/", handler: handleIndex(registry, scores), want: "Pets"},
+		{target: "/discovery", handler: handleDiscoveryPage(registry), want: "pets.yaml"},
+		{target: "/service/pets", handler: handleServiceSwagger(registry), vars: vars, want: "/api/specs/pets/"},
+		{target: "/diff?from=pets&to=pets", handler: handleDiffPage(registry), want: "pets"},
+		{target: "/service/pets/lint", handler: handleLintPage(registry, ruleset), vars: vars, want: "Pets"},
+		{target: "/quality", handler: handleQualityOverviewPage(scores), want: "Pets"},
+		{target: "/service/pets/quality", handler: handleQualityPage(registry, ruleset), vars: vars, want: "Pets"},
+	}
+	for _, tt := range tests {
+		w := serve(tt.handler, tt.target, tt.vars, "")
+		body := w.Body.String()
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/html" ||
+			!strings.Contains(body, tt.want) || !strings.Contains(body, "swaggerDarkCSS") {
+			t.Errorf("GET %s = %d %s, want a themed page mentioning %q", tt.target, w.Code,
+				w.Header().Get("Content-Type"), tt.want)
+		}
+	}
+}
@@ -56,6 +56,44 @@ if (format === 'json') {
     formatBadge.style.background = '#27ae60'; // Green for YAML
 }
 
+// Navigate to another version of the service
+const versionSelect = document.getElementById('versionSelect');
+if (versionSelect) {
+    versionSelect.addEventListener('change', function () {
+        window.location.href = this.value;
+    });
+}
+
+// Fill the revision switcher from the spec's git history; it stays hidden for untracked specs
+const revisionSelect = document.getElementById('revisionSelect');
+if (revisionSelect) {
+    fetch(revisionSelect.dataset.history)
+        .then(response => (response.ok ? response.json() : null))
+        .then(history => {
+            if (!history || history.revisions.length === 0) {
+                return;
+            }
+            history.revisions.forEach(rev => {
+                const option = document.createElement('option');
+                const tags = rev.tags ? ' [' + rev.tags.join(', ') + ']' : '';
+                option.value = rev.pageUrl;
+                option.textContent = rev.commit.substring(0, 7) + tags + ' ' + rev.date.substring(0, 10) + ' ' + rev.subject;
+                revisionSelect.appendChild(option);
+                if (rev.commit === revisionSelect.dataset.current) {
+                    document.getElementById('revisionCurrent').remove();
+                    option.selected = true;
+                }
+            });
+            document.getElementById('revisionPicker').hidden = false;
+            document.getElementById('versionSwitcher').hidden = false;
+        })
+        .catch(error => console.warn('Failed to load spec history', error));
+
+    revisionSelect.addEventListener('change', function () {
+        window.location.href = this.value;
+    });
+}
+
 // Update viewer toggle button
 const viewerToggle = document.getElementById('viewerToggle');
 if (viewerToggle) {
@@ -98,6 +136,7 @@ function initializeSwagger() {
             SwaggerUIBundle.plugins.DownloadUrl
         ],
         layout: "StandaloneLayout",
+        onComplete: openLinkedItem,
         requestInterceptor: function (req) {
             // Only proxy if enabled and it's an external request
             if (proxyEnabled &&
@@ -106,10 +145,15 @@ function initializeSwagger() {
                 !req.url.includes('/proxy')) {
                 console.log('Proxying request to:', req.url);
                 req.url = '/proxy?url=' + encodeURIComponent(req.url);
+                req.headers['X-WebSwags-Spec'] = swaggerSpecRef;
             } else if (!proxyEnabled) {
                 console.log('Direct request to:', req.url);
             }
             return req;
+        },
+        responseInterceptor: function (res) {
+            showContractBanner(res.headers || {});
+            return res;
         }
     });
 
@@ -127,6 +171,59 @@ function initializeSwagger() {
     });
 }
 
+// The proxy reports how a request and its response compare with the spec in two headers
+// ("valid: ...", "unchecked: ..." or "N violation(s) of ..."): show a banner for the last
+// exchange that broke the contract, and hide it once one conforms again
+const contractBanner = document.getElementById('contractBanner');
+document.getElementById('contractBannerClose').addEventListener('click', function () {
+    contractBanner.hidden = true;
+});
+
+function showContractBanner(headers) {
+    const found = [
+        ['Request', headers['x-webswags-validation']],
+        ['Response', headers['x-webswags-response-validation']]
+    ].filter(([, summary]) => summary && /^\d/.test(summary));
+    if (found.length === 0) {
+        if (headers['x-webswags-response-validation']) {
+            contractBanner.hidden = true;
+        }
+        return;
+    }
+    document.getElementById('contractBannerTitle').textContent =
+        '⚠️ ' + found.map(([what]) => what).join(' and ') + ' violated the contract';
+    document.getElementById('contractBannerText').textContent = found.map(([, summary]) => summary).join(' · ');
+    contractBanner.hidden = false;
+}
+
+// Search results link to an operation as #op=METHOD%20/path and to a schema as #schema=Name:
+// expand it and scroll to it once Swagger UI has rendered the spec.
+function openLinkedItem() {
+    const hash = decodeURIComponent(window.location.hash.slice(1));
+    let block = null;
+    if (hash.startsWith('op=')) {
+        const [method, path] = hash.slice(3).split(' ');
+        document.querySelectorAll('.opblock.opblock-' + method.toLowerCase()).forEach(function (candidate) {
+            const summaryPath = candidate.querySelector('.opblock-summary-path');
+            if (!block && summaryPath && summaryPath.dataset.path === path) {
+                block = candidate;
+            }
+        });
+        if (block && !block.classList.contains('is-open')) {
+            (block.querySelector('.opblock-summary-control') || block.querySelector('.opblock-summary')).click();
+        }
+    } else if (hash.startsWith('schema=')) {
+        block = document.getElementById('model-' + hash.slice(7));
+        const toggle = block && block.querySelector('.model-box-control');
+        if (toggle && toggle.getAttribute('aria-expanded') !== 'true') {
+            toggle.click();
+        }
+    }
+    if (block) {
+        block.scrollIntoView({ behavior: 'smooth', block: 'start' });
+    }
+}
+
 function initializeRedoc() {
     const container = document.getElementById('swagger-ui');
     container.style.display = 'block';